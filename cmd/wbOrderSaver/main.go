@@ -1,9 +1,34 @@
 package main
 
 import (
+	"fmt"
+	"os"
 	"wb_l0/internal/app/wbOrderSaver"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			if err := wbOrderSaver.Export(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, "export:", err)
+				os.Exit(1)
+			}
+			return
+		case "import":
+			if err := wbOrderSaver.Import(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, "import:", err)
+				os.Exit(1)
+			}
+			return
+		case "migrate":
+			if err := wbOrderSaver.Migrate(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, "migrate:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	wbOrderSaver.Run()
 }
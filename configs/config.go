@@ -4,8 +4,10 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 	"wb_l0/configs/loader"
 )
@@ -18,6 +20,19 @@ type DBConfig struct {
 	Port           string        `validate:"required"`
 	ConnectTimeout time.Duration `validate:"required"`
 	Retries        int           `validate:"required"`
+	// MigrateOnStart - применять встроенные миграции при запуске сервиса
+	MigrateOnStart bool
+	// MaxConns и MinConns - границы пула соединений, MinConns соединений держатся открытыми
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+	// ReplicaDSNs - реплики для чтения заказов. Без реплик все запросы идут в primary.
+	ReplicaDSNs []string
+	// ReplicaCheckInterval - период проверки доступности реплик
+	ReplicaCheckInterval time.Duration
+	// ReadYourWrites - сколько после изменения заказа этим экземпляром его чтения идут в primary, 0 отключает
+	ReadYourWrites time.Duration
 }
 
 type RedisConfig struct {
@@ -42,6 +57,10 @@ type KafkaConfig struct {
 	ConsumerGroup        string `validate:"required"`
 	ProducerNumberOfKeys int    `validate:"required"`
 	FlushTimeout         int    `validate:"required"`
+	// DuplicatePolicy - что делать с заказом, order_uid которого уже сохранён: skip, replace или reject
+	DuplicatePolicy string
+	// DLQTopic - топик для сообщений, отклонённых без повтора. Пустое значение отключает DLQ.
+	DLQTopic string
 }
 
 type HttpConfig struct {
@@ -49,14 +68,114 @@ type HttpConfig struct {
 	ReadTimeout  time.Duration `validate:"required"`
 	WriteTimeout time.Duration `validate:"required"`
 	IdleTimeout  time.Duration `validate:"required"`
+	BatchMaxUIDs int           `validate:"required"`
+	CacheControl string
+	// TrustedProxies - адреса и подсети прокси, которым доверяется X-Forwarded-For. Пусто - IP берётся из соединения.
+	TrustedProxies []string
+	RateLimit      RateLimitConfig
+	Stream         StreamConfig
+	// Masking - правила маскирования персональных данных: роль -> поле -> режим (full, partial)
+	Masking map[string]map[string]string
+}
+
+type RateLimitRule struct {
+	Rate  float64
+	Burst int
+}
+
+type RateLimitConfig struct {
+	Enabled bool
+	// Backend - memory (лимит на экземпляр) или redis (общий лимит)
+	Backend string
+	// Groups - лимиты групп маршрутов: public, read, batch, admin
+	Groups map[string]RateLimitRule
+}
+
+type StreamConfig struct {
+	Enabled bool
+	// BufferSize - число событий в очереди подписчика, при переполнении подписчик отключается
+	BufferSize        int
+	HeartbeatInterval time.Duration
+	MaxSubscribers    int
+}
+
+type GRPCConfig struct {
+	Enabled      bool
+	Port         string `validate:"required"`
+	Reflection   bool
+	BatchMaxUIDs int
+}
+
+type WebhookConfig struct {
+	Enabled bool
+	// Workers - число одновременных отправок
+	Workers     int
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Timeout     time.Duration
+	// PollInterval - период поиска доставок, время повторной попытки которых наступило
+	PollInterval time.Duration
+	QueueSize    int
+}
+
+type AdminConfig struct {
+	// Host по умолчанию 127.0.0.1: административный порт не должен быть доступен снаружи без явной настройки
+	Host string
+	Port string `validate:"required"`
+	// AuthEnabled - требовать роль admin на административном порту
+	AuthEnabled bool
+	// MetricsPublic - отдавать /metrics без аутентификации, чтобы Prometheus мог собирать метрики
+	MetricsPublic bool
+}
+
+type HealthConfig struct {
+	// CheckTimeout - ограничение времени одной проверки зависимости
+	CheckTimeout time.Duration
+	// KafkaMaxPollAge - допустимое время с последнего успешного опроса Kafka
+	KafkaMaxPollAge time.Duration
+}
+
+type RetryConfig struct {
+	MaxAttempts int           `validate:"required"`
+	BaseDelay   time.Duration `validate:"required"`
+	MaxDelay    time.Duration `validate:"required"`
+	Jitter      float64
+}
+
+type APIKey struct {
+	Key  string
+	Role string
+	Name string
+	// Bearer разрешает передавать ключ в Authorization: Bearer, как устаревший HTTP_ADMIN_TOKEN.
+	// Остальные ключи принимаются только в X-API-Key, а Bearer проверяется как JWT.
+	Bearer bool
+}
+
+type AuthConfig struct {
+	// Enabled выключен по умолчанию, чтобы обновлённый сервис запускался без ключей; включённая
+	// аутентификация требует хотя бы один API-ключ или ключ JWT
+	Enabled               bool
+	APIKeys               []APIKey
+	JWTHS256SecretFile    string
+	JWTRS256PublicKeyFile string
+	JWTIssuer             string
+	JWTAudience           string
+	JWTRoleClaim          string
 }
 
 type Config struct {
-	DB   DBConfig
-	RD   RedisConfig
-	KF   KafkaConfig
-	HTTP HttpConfig
-	Env  string
+	DB      DBConfig
+	RD      RedisConfig
+	KF      KafkaConfig
+	HTTP    HttpConfig
+	GRPC    GRPCConfig
+	Webhook WebhookConfig
+	Admin   AdminConfig
+	Health  HealthConfig
+	Retry   RetryConfig
+	Auth    AuthConfig
+	Env     string
 }
 
 func MustLoad(loader loader.ConfigLoader) *Config {
@@ -74,13 +193,21 @@ func MustLoad(loader loader.ConfigLoader) *Config {
 	}
 	cfg := &Config{
 		DB: DBConfig{
-			User:           envs["POSTGRES_USER"],
-			Password:       envs["POSTGRES_PASSWORD"],
-			Name:           envs["POSTGRES_DB"],
-			Host:           envs["POSTGRES_HOST"],
-			Port:           envs["POSTGRES_PORT"],
-			ConnectTimeout: getEnvAsDuration(envs["POSTGRES_CONNECT_TIMEOUT"], 5*time.Second),
-			Retries:        getEnvAsInt(envs["POSTGRES_RETRIES"], 1),
+			User:                 envs["POSTGRES_USER"],
+			Password:             envs["POSTGRES_PASSWORD"],
+			Name:                 envs["POSTGRES_DB"],
+			Host:                 envs["POSTGRES_HOST"],
+			Port:                 envs["POSTGRES_PORT"],
+			ConnectTimeout:       getEnvAsDuration(envs["POSTGRES_CONNECT_TIMEOUT"], 5*time.Second),
+			Retries:              getEnvAsInt(envs["POSTGRES_RETRIES"], 1),
+			MigrateOnStart:       getEnvAsBool(envs["POSTGRES_MIGRATE_ON_START"], false),
+			MaxConns:             int32(getEnvAsInt(envs["POSTGRES_MAX_CONNS"], 10)),
+			MinConns:             int32(getEnvAsInt(envs["POSTGRES_MIN_CONNS"], 2)),
+			MaxConnLifetime:      getEnvAsDuration(envs["POSTGRES_MAX_CONN_LIFETIME"], 5*time.Minute),
+			MaxConnIdleTime:      getEnvAsDuration(envs["POSTGRES_MAX_CONN_IDLE_TIME"], 5*time.Minute),
+			ReplicaDSNs:          parseList(envs["POSTGRES_REPLICA_DSNS"]),
+			ReplicaCheckInterval: getEnvAsDuration(envs["POSTGRES_REPLICA_CHECK_INTERVAL"], 5*time.Second),
+			ReadYourWrites:       getEnvAsDuration(envs["POSTGRES_READ_YOUR_WRITES"], 5*time.Second),
 		},
 		RD: RedisConfig{
 			Host:         envs["REDIS_HOST"],
@@ -103,12 +230,81 @@ func MustLoad(loader loader.ConfigLoader) *Config {
 			ConsumerGroup:        envs["KAFKA_CONSUMER_GROUP"],
 			ProducerNumberOfKeys: getEnvAsInt(envs["KAFKA_PRODUCER_NUM_OF_KEYS"], 20),
 			FlushTimeout:         getEnvAsInt(envs["KAFKA_FLUSH_TIMEOUT"], 5000),
+			DuplicatePolicy:      getEnvAsString(envs["KAFKA_DUPLICATE_POLICY"], "skip"),
+			DLQTopic:             envs["KAFKA_DLQ_TOPIC"],
 		},
 		HTTP: HttpConfig{
-			Port:         envs["HTTP_PORT"],
-			ReadTimeout:  getEnvAsDuration(envs["HTTP_READ_TIMEOUT"], 10*time.Second),
-			WriteTimeout: getEnvAsDuration(envs["HTTP_WRITE_TIMEOUT"], 10*time.Second),
-			IdleTimeout:  getEnvAsDuration(envs["HTTP_WRITE_TIMEOUT"], 60*time.Second),
+			Port:           envs["HTTP_PORT"],
+			ReadTimeout:    getEnvAsDuration(envs["HTTP_READ_TIMEOUT"], 10*time.Second),
+			WriteTimeout:   getEnvAsDuration(envs["HTTP_WRITE_TIMEOUT"], 10*time.Second),
+			IdleTimeout:    getEnvAsDuration(envs["HTTP_WRITE_TIMEOUT"], 60*time.Second),
+			BatchMaxUIDs:   getEnvAsInt(envs["HTTP_BATCH_MAX_UIDS"], 100),
+			CacheControl:   getEnvAsString(envs["HTTP_CACHE_CONTROL"], defaultCacheControl(env)),
+			TrustedProxies: parseList(envs["HTTP_TRUSTED_PROXIES"]),
+			RateLimit: RateLimitConfig{
+				Enabled: getEnvAsBool(envs["RATE_LIMIT_ENABLED"], true),
+				Backend: getEnvAsString(envs["RATE_LIMIT_BACKEND"], "memory"),
+				Groups: map[string]RateLimitRule{
+					"public": getEnvAsRateLimit(envs["RATE_LIMIT_PUBLIC"], RateLimitRule{Rate: 20, Burst: 40}),
+					"read":   getEnvAsRateLimit(envs["RATE_LIMIT_READ"], RateLimitRule{Rate: 50, Burst: 100}),
+					"batch":  getEnvAsRateLimit(envs["RATE_LIMIT_BATCH"], RateLimitRule{Rate: 5, Burst: 10}),
+					"admin":  getEnvAsRateLimit(envs["RATE_LIMIT_ADMIN"], RateLimitRule{Rate: 5, Burst: 10}),
+				},
+			},
+			Stream: StreamConfig{
+				Enabled:           getEnvAsBool(envs["STREAM_ENABLED"], true),
+				BufferSize:        getEnvAsInt(envs["STREAM_BUFFER_SIZE"], 64),
+				HeartbeatInterval: getEnvAsDuration(envs["STREAM_HEARTBEAT_INTERVAL"], 15*time.Second),
+				MaxSubscribers:    getEnvAsInt(envs["STREAM_MAX_SUBSCRIBERS"], 100),
+			},
+			Masking: map[string]map[string]string{
+				"reader": parseMaskingRules(getEnvAsString(envs["MASKING_READER"],
+					"phone:full,email:full,address:full,transaction:full")),
+				"support": parseMaskingRules(getEnvAsString(envs["MASKING_SUPPORT"],
+					"phone:partial,email:partial,address:partial,transaction:partial")),
+				"admin": parseMaskingRules(envs["MASKING_ADMIN"]),
+			},
+		},
+		GRPC: GRPCConfig{
+			Enabled:      getEnvAsBool(envs["GRPC_ENABLED"], true),
+			Port:         getEnvAsString(envs["GRPC_PORT"], "50051"),
+			Reflection:   getEnvAsBool(envs["GRPC_REFLECTION"], env != "prod"),
+			BatchMaxUIDs: getEnvAsInt(envs["HTTP_BATCH_MAX_UIDS"], 100),
+		},
+		Webhook: WebhookConfig{
+			Enabled:      getEnvAsBool(envs["WEBHOOK_ENABLED"], true),
+			Workers:      getEnvAsInt(envs["WEBHOOK_WORKERS"], 4),
+			MaxAttempts:  getEnvAsInt(envs["WEBHOOK_MAX_ATTEMPTS"], 8),
+			BaseDelay:    getEnvAsDuration(envs["WEBHOOK_BASE_DELAY"], 10*time.Second),
+			MaxDelay:     getEnvAsDuration(envs["WEBHOOK_MAX_DELAY"], time.Hour),
+			Timeout:      getEnvAsDuration(envs["WEBHOOK_TIMEOUT"], 5*time.Second),
+			PollInterval: getEnvAsDuration(envs["WEBHOOK_POLL_INTERVAL"], 5*time.Second),
+			QueueSize:    getEnvAsInt(envs["WEBHOOK_QUEUE_SIZE"], 1000),
+		},
+		Admin: AdminConfig{
+			Host:          getEnvAsString(envs["ADMIN_HOST"], "127.0.0.1"),
+			Port:          getEnvAsString(envs["ADMIN_PORT"], "8082"),
+			AuthEnabled:   getEnvAsBool(envs["ADMIN_AUTH_ENABLED"], true),
+			MetricsPublic: getEnvAsBool(envs["ADMIN_METRICS_PUBLIC"], true),
+		},
+		Health: HealthConfig{
+			CheckTimeout:    getEnvAsDuration(envs["HEALTH_CHECK_TIMEOUT"], 2*time.Second),
+			KafkaMaxPollAge: getEnvAsDuration(envs["HEALTH_KAFKA_MAX_POLL_AGE"], 30*time.Second),
+		},
+		Retry: RetryConfig{
+			MaxAttempts: getEnvAsInt(envs["ORDER_RETRY_MAX_ATTEMPTS"], 3),
+			BaseDelay:   getEnvAsDuration(envs["ORDER_RETRY_BASE_DELAY"], time.Second),
+			MaxDelay:    getEnvAsDuration(envs["ORDER_RETRY_MAX_DELAY"], 10*time.Second),
+			Jitter:      getEnvAsFloat(envs["ORDER_RETRY_JITTER"], 0.2),
+		},
+		Auth: AuthConfig{
+			Enabled:               getEnvAsBool(envs["AUTH_ENABLED"], false),
+			APIKeys:               parseAPIKeys(envs["AUTH_API_KEYS"], envs["HTTP_ADMIN_TOKEN"]),
+			JWTHS256SecretFile:    envs["AUTH_JWT_HS256_SECRET_FILE"],
+			JWTRS256PublicKeyFile: envs["AUTH_JWT_RS256_PUBLIC_KEY_FILE"],
+			JWTIssuer:             envs["AUTH_JWT_ISSUER"],
+			JWTAudience:           envs["AUTH_JWT_AUDIENCE"],
+			JWTRoleClaim:          getEnvAsString(envs["AUTH_JWT_ROLE_CLAIM"], "role"),
 		},
 		Env: env,
 	}
@@ -125,6 +321,13 @@ func validateConfig(cfg *Config) error {
 		cfg.DB.Host == "" || cfg.DB.Port == "" || cfg.DB.Retries <= 0 || cfg.DB.ConnectTimeout <= 0*time.Second {
 		return fmt.Errorf("incorrect database config fields")
 	}
+	if cfg.DB.MaxConns <= 0 || cfg.DB.MinConns < 0 || cfg.DB.MinConns > cfg.DB.MaxConns ||
+		cfg.DB.MaxConnLifetime <= 0 || cfg.DB.MaxConnIdleTime <= 0 {
+		return fmt.Errorf("incorrect database pool config fields")
+	}
+	if len(cfg.DB.ReplicaDSNs) > 0 && (cfg.DB.ReplicaCheckInterval <= 0 || cfg.DB.ReadYourWrites < 0) {
+		return fmt.Errorf("incorrect database replica config fields")
+	}
 
 	if cfg.RD.Host == "" || cfg.RD.DialTimeout <= 0*time.Second || cfg.RD.ReadTimeout <= 0*time.Second || cfg.RD.
 		WriteTimeout <= 0*time.Second || cfg.RD.Capacity <= 0 || cfg.RD.MaxRetries <= 0 {
@@ -136,14 +339,152 @@ func validateConfig(cfg *Config) error {
 		cfg.KF.FlushTimeout <= 0 || cfg.KF.ProducerNumberOfKeys <= 0 {
 		return fmt.Errorf("incorrect kafka config fields")
 	}
+	switch cfg.KF.DuplicatePolicy {
+	case "skip", "replace":
+	case "reject":
+		if cfg.KF.DLQTopic == "" {
+			return fmt.Errorf("incorrect kafka config fields: duplicate policy reject requires KAFKA_DLQ_TOPIC")
+		}
+	default:
+		return fmt.Errorf("incorrect kafka config fields: unknown duplicate policy %q", cfg.KF.DuplicatePolicy)
+	}
 
 	if cfg.HTTP.Port == "" || cfg.HTTP.ReadTimeout <= 0*time.Second || cfg.HTTP.WriteTimeout <= 0*time.Second ||
-		cfg.HTTP.IdleTimeout <= 0*time.Second {
+		cfg.HTTP.IdleTimeout <= 0*time.Second || cfg.HTTP.BatchMaxUIDs <= 0 {
 		return fmt.Errorf("incorrect http config fields")
 	}
+	for _, proxy := range cfg.HTTP.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("incorrect trusted proxy %q", proxy)
+		}
+	}
+	if cfg.HTTP.RateLimit.Backend != "memory" && cfg.HTTP.RateLimit.Backend != "redis" {
+		return fmt.Errorf("incorrect rate limit config fields: unknown backend %q", cfg.HTTP.RateLimit.Backend)
+	}
+	for group, rule := range cfg.HTTP.RateLimit.Groups {
+		if rule.Rate <= 0 || rule.Burst <= 0 {
+			return fmt.Errorf("incorrect rate limit config fields: group %s", group)
+		}
+	}
+	if cfg.HTTP.Stream.Enabled && (cfg.HTTP.Stream.BufferSize <= 0 ||
+		cfg.HTTP.Stream.HeartbeatInterval <= 0*time.Second || cfg.HTTP.Stream.MaxSubscribers <= 0) {
+		return fmt.Errorf("incorrect stream config fields")
+	}
+	for role, rules := range cfg.HTTP.Masking {
+		for field, mode := range rules {
+			if !maskableFields[field] || (mode != "full" && mode != "partial") {
+				return fmt.Errorf("incorrect masking config fields: %s %s:%s", role, field, mode)
+			}
+		}
+	}
+
+	if cfg.GRPC.Enabled && (cfg.GRPC.Port == "" || cfg.GRPC.Port == cfg.HTTP.Port || cfg.GRPC.BatchMaxUIDs <= 0) {
+		return fmt.Errorf("incorrect grpc config fields")
+	}
+
+	if cfg.Webhook.Enabled && (cfg.Webhook.Workers <= 0 || cfg.Webhook.MaxAttempts <= 0 ||
+		cfg.Webhook.BaseDelay <= 0*time.Second || cfg.Webhook.MaxDelay < cfg.Webhook.BaseDelay ||
+		cfg.Webhook.Timeout <= 0*time.Second || cfg.Webhook.PollInterval <= 0*time.Second || cfg.Webhook.QueueSize <= 0) {
+		return fmt.Errorf("incorrect webhook config fields")
+	}
+
+	if cfg.Admin.Port == "" || cfg.Admin.Port == cfg.HTTP.Port {
+		return fmt.Errorf("incorrect admin config fields")
+	}
+
+	if cfg.Health.CheckTimeout <= 0*time.Second || cfg.Health.KafkaMaxPollAge <= 0*time.Second {
+		return fmt.Errorf("incorrect health config fields")
+	}
+
+	if cfg.Retry.MaxAttempts <= 0 || cfg.Retry.BaseDelay <= 0*time.Second || cfg.Retry.MaxDelay < cfg.Retry.BaseDelay ||
+		cfg.Retry.Jitter < 0 || cfg.Retry.Jitter > 1 {
+		return fmt.Errorf("incorrect retry config fields")
+	}
+	if cfg.Auth.Enabled && len(cfg.Auth.APIKeys) == 0 && cfg.Auth.JWTHS256SecretFile == "" &&
+		cfg.Auth.JWTRS256PublicKeyFile == "" {
+		return fmt.Errorf("incorrect auth config fields: no api keys or jwt keys configured")
+	}
+	for _, key := range cfg.Auth.APIKeys {
+		if key.Key == "" || key.Role == "" {
+			return fmt.Errorf("incorrect auth config fields: api key %q has no key or role", key.Name)
+		}
+	}
 	return nil
 }
 
+// parseAPIKeys разбирает AUTH_API_KEYS в формате "key:role[:name],...".
+// Устаревший HTTP_ADMIN_TOKEN добавляется как ключ с ролью admin.
+func parseAPIKeys(value string, legacyAdminToken string) []APIKey {
+	var keys []APIKey
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, ":", 3)
+		key := APIKey{Key: parts[0]}
+		if len(parts) > 1 {
+			key.Role = parts[1]
+		}
+		if len(parts) > 2 {
+			key.Name = parts[2]
+		} else {
+			key.Name = key.Role
+		}
+		keys = append(keys, key)
+	}
+	if legacyAdminToken != "" {
+		keys = append(keys, APIKey{Key: legacyAdminToken, Role: "admin", Name: "legacy-admin-token", Bearer: true})
+	}
+	return keys
+}
+
+// parseList разбирает список значений через запятую, пустые значения пропускаются
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+var maskableFields = map[string]bool{"phone": true, "email": true, "address": true, "transaction": true}
+
+// parseMaskingRules разбирает правила маскирования в формате "field:mode,...", режим по умолчанию - full
+func parseMaskingRules(value string) map[string]string {
+	rules := make(map[string]string)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" || item == "none" {
+			continue
+		}
+		field, mode, ok := strings.Cut(item, ":")
+		if !ok {
+			mode = "full"
+		}
+		rules[strings.TrimSpace(field)] = strings.TrimSpace(mode)
+	}
+	return rules
+}
+
+// defaultCacheControl - значение Cache-Control для ответов с заказом, если HTTP_CACHE_CONTROL не задан.
+// Заказы содержат персональные данные, поэтому кэширование разрешено только в браузере клиента.
+func defaultCacheControl(env string) string {
+	if env == "prod" {
+		return "private, max-age=300"
+	}
+	return "no-cache"
+}
+
+func getEnvAsString(strValue string, defaultValue string) string {
+	if strValue == "" {
+		return defaultValue
+	}
+	return strValue
+}
+
 func getEnvAsDuration(strValue string, defaultValue time.Duration) time.Duration {
 	const op = "configs.getEnvAsDuration"
 	if strValue == "" {
@@ -172,6 +513,35 @@ func getEnvAsInt(strValue string, defaultValue int) int {
 	return value
 }
 
+func getEnvAsFloat(strValue string, defaultValue float64) float64 {
+	const op = "configs.getEnvAsFloat"
+	if strValue == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(strValue, 64)
+	if err != nil {
+		log.Printf("%s:forbidden value for %s, using default: %v", op, strValue, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvAsRateLimit разбирает лимит в формате "rate:burst", например "50:100"
+func getEnvAsRateLimit(strValue string, defaultValue RateLimitRule) RateLimitRule {
+	const op = "configs.getEnvAsRateLimit"
+	if strValue == "" {
+		return defaultValue
+	}
+	rateStr, burstStr, _ := strings.Cut(strValue, ":")
+	rate, rateErr := strconv.ParseFloat(rateStr, 64)
+	burst, burstErr := strconv.Atoi(burstStr)
+	if rateErr != nil || burstErr != nil {
+		log.Printf("%s:forbidden value for %s, using default: %v", op, strValue, defaultValue)
+		return defaultValue
+	}
+	return RateLimitRule{Rate: rate, Burst: burst}
+}
+
 func getEnvAsBool(strValue string, defaultValue bool) bool {
 	const op = "configs.getEnvAsBool"
 	if strValue == "" {
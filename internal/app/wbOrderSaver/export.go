@@ -0,0 +1,102 @@
+package wbOrderSaver
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+	"wb_l0/configs"
+	"wb_l0/configs/loader/dotEnvLoader"
+	"wb_l0/internal/domain"
+	"wb_l0/internal/export"
+	"wb_l0/internal/repository/postgres"
+	"wb_l0/internal/usecase"
+	"wb_l0/pkg/logger"
+)
+
+// Export выполняет подкоманду export: выгружает заказы из базы в файл CSV или NDJSON
+func Export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	env := flags.String("env", "dev", "Environment type")
+	format := flags.String("format", export.FormatCSV, "Export format: csv or ndjson")
+	out := flags.String("out", "", "Output file (default orders-<timestamp>.<format>)")
+	customerID := flags.String("customer_id", "", "Only orders of this customer")
+	createdFrom := flags.String("created_from", "", "Orders created at or after this time (RFC3339)")
+	createdTo := flags.String("created_to", "", "Orders created before this time (RFC3339)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	filter := domain.OrderFilter{CustomerID: *customerID}
+	var err error
+	if filter.CreatedFrom, err = parseTimeFlag("created_from", *createdFrom); err != nil {
+		return err
+	}
+	if filter.CreatedTo, err = parseTimeFlag("created_to", *createdTo); err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = fmt.Sprintf("orders-%s.%s", time.Now().UTC().Format("20060102-150405"), *format)
+	}
+
+	if os.Getenv("APP_ENV") == "" {
+		_ = os.Setenv("APP_ENV", *env)
+	}
+	cfg := configs.MustLoad(dotEnvLoader.DotEnvLoader{})
+	log := logger.NewLogger(cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Файл пишется под временным именем, чтобы прерванная выгрузка не оставила неполный результат
+	file, err := os.CreateTemp(filepath.Dir(*out), filepath.Base(*out)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	writer, err := export.NewWriter(*format, file)
+	if err != nil {
+		return err
+	}
+
+	db, err := postgres.NewStore(ctx, cfg, log)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Disconnect(ctx)
+
+	uc := usecase.NewOrderUsecase(db, usecase.RetryPolicy{}, log)
+	count, err := uc.ExportOrders(ctx, filter, writer.Write)
+	if err != nil {
+		return fmt.Errorf("export failed after %d orders: %w", count, err)
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := os.Rename(file.Name(), *out); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %d orders to %s\n", count, *out)
+	return nil
+}
+
+func parseTimeFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("-%s must be an RFC3339 timestamp: %w", name, err)
+	}
+	return parsed, nil
+}
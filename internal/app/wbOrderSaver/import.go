@@ -0,0 +1,96 @@
+package wbOrderSaver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"wb_l0/configs"
+	"wb_l0/configs/loader/dotEnvLoader"
+	"wb_l0/internal/domain"
+	"wb_l0/internal/repository/postgres"
+	"wb_l0/internal/usecase"
+	"wb_l0/pkg/logger"
+)
+
+// maxImportLine - максимальная длина строки NDJSON с одним заказом
+const maxImportLine = 16 << 20
+
+// Import выполняет подкоманду import: загружает заказы из файла NDJSON в базу через COPY
+func Import(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	env := flags.String("env", "dev", "Environment type")
+	in := flags.String("in", "-", "Input NDJSON file, - for stdin")
+	batchSize := flags.Int("batch", usecase.DefaultImportBatch, "Orders per transaction")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *batchSize <= 0 {
+		return fmt.Errorf("-batch must be positive")
+	}
+
+	input := os.Stdin
+	if *in != "-" {
+		file, err := os.Open(*in)
+		if err != nil {
+			return fmt.Errorf("failed to open input file: %w", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	if os.Getenv("APP_ENV") == "" {
+		_ = os.Setenv("APP_ENV", *env)
+	}
+	cfg := configs.MustLoad(dotEnvLoader.DotEnvLoader{})
+	log := logger.NewLogger(cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx = domain.WithActor(ctx, "cli:import")
+
+	db, err := postgres.NewStore(ctx, cfg, log)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Disconnect(ctx)
+
+	uc := usecase.NewOrderUsecase(db, usecase.RetryPolicy{}, log)
+	result, err := uc.ImportOrders(ctx, ndjsonOrders(input), *batchSize)
+	fmt.Fprintf(os.Stderr, "inserted %d, skipped %d existing, %d invalid\n",
+		result.Inserted, result.Skipped, result.Invalid)
+	if err != nil {
+		return fmt.Errorf("import stopped: %w", err)
+	}
+	return nil
+}
+
+// ndjsonOrders возвращает функцию, читающую по заказу из строки NDJSON. Пустые строки пропускаются,
+// строка с некорректным JSON возвращается как ошибка валидации с номером строки.
+func ndjsonOrders(r io.Reader) func() (*domain.Order, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxImportLine)
+	line := 0
+	return func() (*domain.Order, error) {
+		for scanner.Scan() {
+			line++
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			var order domain.Order
+			if err := json.Unmarshal(scanner.Bytes(), &order); err != nil {
+				return nil, fmt.Errorf("line %d: %w: %w", line, domain.ErrValidation, err)
+			}
+			return &order, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read line %d: %w", line+1, err)
+		}
+		return nil, io.EOF
+	}
+}
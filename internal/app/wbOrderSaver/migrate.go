@@ -0,0 +1,90 @@
+package wbOrderSaver
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+	"wb_l0/configs"
+	"wb_l0/configs/loader/dotEnvLoader"
+	"wb_l0/internal/repository/postgres"
+	"wb_l0/pkg/logger"
+)
+
+const migrateUsage = "usage: migrate up|down|status [-env dev] [-steps 1]"
+
+// Migrate выполняет подкоманду migrate: применяет, откатывает или показывает встроенные миграции
+func Migrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	command := args[0]
+	flags := flag.NewFlagSet("migrate "+command, flag.ContinueOnError)
+	env := flags.String("env", "dev", "Environment type")
+	steps := flags.Int("steps", 1, "Number of migrations to revert (down)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *steps <= 0 {
+		return fmt.Errorf("-steps must be positive")
+	}
+
+	if os.Getenv("APP_ENV") == "" {
+		_ = os.Setenv("APP_ENV", *env)
+	}
+	cfg := configs.MustLoad(dotEnvLoader.DotEnvLoader{})
+	log := logger.NewLogger(cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := postgres.NewStore(ctx, cfg, log)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Disconnect(ctx)
+
+	switch command {
+	case "up":
+		applied, err := db.MigrateUp(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(os.Stderr, "applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%d migrations applied\n", len(applied))
+	case "down":
+		reverted, err := db.MigrateDown(ctx, *steps)
+		for _, m := range reverted {
+			fmt.Fprintf(os.Stderr, "reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%d migrations reverted\n", len(reverted))
+	case "status":
+		statuses, err := db.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown command %q, %s", command, migrateUsage)
+	}
+	return nil
+}
@@ -3,7 +3,7 @@ package wbOrderSaver
 import (
 	"context"
 	"errors"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,13 +12,19 @@ import (
 	"time"
 	"wb_l0/configs"
 	"wb_l0/configs/loader/dotEnvLoader"
+	"wb_l0/internal/auth"
+	g "wb_l0/internal/delivery/grpc"
 	h "wb_l0/internal/delivery/http"
 	k "wb_l0/internal/delivery/kafka"
 	"wb_l0/internal/delivery/kafka/kafkaHandler"
+	"wb_l0/internal/health"
+	"wb_l0/internal/ratelimit"
 	"wb_l0/internal/repository/cachedRepo"
 	"wb_l0/internal/repository/postgres"
 	"wb_l0/internal/repository/redisCache"
+	"wb_l0/internal/stream"
 	"wb_l0/internal/usecase"
+	"wb_l0/internal/webhook"
 	"wb_l0/pkg/logger"
 )
 
@@ -36,18 +42,65 @@ func Run() {
 		log.Error("failed to connect to database", "error", err)
 		os.Exit(1)
 	}
+	if cfg.DB.MigrateOnStart {
+		applied, err := db.MigrateUp(ctx)
+		if err != nil {
+			log.Error("failed to apply migrations", "error", err)
+			os.Exit(1)
+		}
+		log.Info("Migrations applied", "count", len(applied))
+	}
+	// Без прогрева кэш справочников заполняется по мере сохранения заказов
+	if err := db.LoadDictionaries(ctx); err != nil {
+		log.Warn("failed to preload dictionary cache", "error", err)
+	}
+
+	retryPolicy := usecase.RetryPolicy{
+		MaxAttempts: cfg.Retry.MaxAttempts,
+		BaseDelay:   cfg.Retry.BaseDelay,
+		MaxDelay:    cfg.Retry.MaxDelay,
+		Jitter:      cfg.Retry.Jitter,
+	}
+
+	var broker *stream.Broker
+	var usecaseOpts []usecase.Option
+	if cfg.HTTP.Stream.Enabled {
+		broker = stream.NewBroker(cfg.HTTP.Stream.BufferSize, cfg.HTTP.Stream.MaxSubscribers)
+		usecaseOpts = append(usecaseOpts, usecase.WithNotifier(broker))
+	}
+
+	var dispatcher *webhook.Dispatcher
+	var webhooks webhook.Repository
+	if cfg.Webhook.Enabled {
+		webhooks = db
+		dispatcher = webhook.NewDispatcher(db, cfg.Webhook, log)
+		dispatcher.Start()
+		usecaseOpts = append(usecaseOpts, usecase.WithNotifier(dispatcher))
+	}
 
 	cache, err := redisCache.NewCache(ctx, cfg, "order:", log)
+	cacheAvailable := err == nil
 	var orderUsecase *usecase.OrderUsecase
-	if err == nil {
+	if cacheAvailable {
 		repo := cachedRepo.NewCachedRepo(ctx, db, cache, log, cfg)
-		orderUsecase = usecase.NewOrderUsecase(repo, 3, log)
+		orderUsecase = usecase.NewOrderUsecase(repo, retryPolicy, log, usecaseOpts...)
 
 	} else {
-		orderUsecase = usecase.NewOrderUsecase(db, 3, log)
+		orderUsecase = usecase.NewOrderUsecase(db, retryPolicy, log, usecaseOpts...)
+	}
+
+	var dlq kafkaHandler.DeadLetterSender
+	if cfg.KF.DLQTopic != "" {
+		producer, err := k.NewProducer(cfg)
+		if err != nil {
+			log.Error("failed to create DLQ producer", "error", err)
+			os.Exit(1)
+		}
+		defer producer.Close()
+		dlq = k.NewDeadLetterQueue(producer, cfg.KF.DLQTopic)
 	}
 
-	handler := kafkaHandler.NewKafkaHandler(orderUsecase, log)
+	handler := kafkaHandler.NewKafkaHandler(orderUsecase, kafkaHandler.DuplicatePolicy(cfg.KF.DuplicatePolicy), dlq, log)
 	c1, err := k.NewConsumer(cfg, handler, 1)
 	if err != nil {
 		log.Error("failed to connect to consumer")
@@ -58,7 +111,43 @@ func Run() {
 		c1.Start()
 	}()
 
-	router := h.SetupRouter(orderUsecase, log)
+	var authenticator *auth.Authenticator
+	if cfg.Auth.Enabled {
+		authenticator, err = auth.NewAuthenticator(cfg.Auth)
+		if err != nil {
+			log.Error("failed to configure authentication", "error", err)
+			os.Exit(1)
+		}
+	} else {
+		log.Warn("AUTHENTICATION IS DISABLED: HTTP, gRPC and admin endpoints accept requests without credentials "+
+			"with the admin role. Set AUTH_ENABLED=true and AUTH_API_KEYS or AUTH_JWT_* to protect them",
+			"auth_enabled", false)
+	}
+
+	var limiter ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.HTTP.RateLimit.Backend == "redis" {
+		if cacheAvailable {
+			limiter = ratelimit.NewRedisStore(cache.Client(), "ratelimit:")
+		} else {
+			log.Warn("Redis is unavailable, falling back to in-memory rate limiting")
+		}
+	}
+
+	cacheCheck := func(ctx context.Context) error {
+		if !cacheAvailable {
+			return errors.New("not connected")
+		}
+		return cache.Ping(ctx)
+	}
+	checker := health.NewChecker(cfg.Health.CheckTimeout,
+		health.Component{Name: "postgres", Critical: true, Check: db.Ping},
+		health.Component{Name: "redis", Check: cacheCheck},
+		health.Component{Name: "kafka", Check: func(context.Context) error {
+			return c1.Health(cfg.Health.KafkaMaxPollAge)
+		}},
+	)
+
+	router := h.SetupRouter(orderUsecase, cfg.HTTP, authenticator, limiter, checker, broker, log)
 
 	server := &http.Server{
 		Addr:         ":" + cfg.HTTP.Port,
@@ -67,6 +156,10 @@ func Run() {
 		WriteTimeout: cfg.HTTP.WriteTimeout,
 		IdleTimeout:  cfg.HTTP.IdleTimeout,
 	}
+	if broker != nil {
+		// Shutdown не дожидается бесконечных потоков, поэтому подписчики отключаются явно
+		server.RegisterOnShutdown(broker.Close)
+	}
 
 	go func() {
 		if serverErr := server.ListenAndServe(); serverErr != nil && !errors.Is(serverErr, http.ErrServerClosed) {
@@ -76,16 +169,35 @@ func Run() {
 		log.Info("Server started", "port", cfg.HTTP.Port)
 	}()
 
-	//prometheus.InitPrometheus()
-	httpSrv := &http.Server{
-		Addr:    ":8082",
-		Handler: promhttp.Handler(),
+	var grpcSrv *g.Server
+	if cfg.GRPC.Enabled {
+		lis, err := net.Listen("tcp", ":"+cfg.GRPC.Port)
+		if err != nil {
+			log.Error("failed to listen gRPC port", "error", err, "port", cfg.GRPC.Port)
+			os.Exit(1)
+		}
+		grpcSrv = g.NewServer(orderUsecase, cfg.GRPC, cfg.HTTP.Masking, authenticator, checker, log)
+		go func() {
+			log.Info("gRPC server started", "port", cfg.GRPC.Port)
+			if err := grpcSrv.Serve(lis); err != nil {
+				log.Error("gRPC server error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	adminRouter := h.SetupAdminRouter(cfg.Admin, authenticator, logger.Level, webhooks, log)
+	adminSrv := &http.Server{
+		Addr:        cfg.Admin.Host + ":" + cfg.Admin.Port,
+		Handler:     adminRouter,
+		ReadTimeout: cfg.HTTP.ReadTimeout,
+		IdleTimeout: cfg.HTTP.IdleTimeout,
 	}
 
 	go func() {
-		log.Info("Запуск prometheus", "port", 8082)
-		if err := httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Error("HTTP prometheus server error: ", err)
+		log.Info("Admin server started", "addr", adminSrv.Addr)
+		if err := adminSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("HTTP admin server error", "error", err)
 			os.Exit(1)
 		}
 	}()
@@ -126,13 +238,32 @@ func Run() {
 
 	go func() {
 		defer wg.Done()
-		log.Info("Shutting down prometheus server...")
-		if serverErr := httpSrv.Shutdown(ctx); serverErr != nil {
+		log.Info("Shutting down admin server...")
+		if serverErr := adminSrv.Shutdown(ctx); serverErr != nil {
 			log.Error("Server shutdown error", "error", serverErr)
 		}
-		log.Info("Prometheus server stopped")
+		log.Info("Admin server stopped")
 	}()
 
+	if dispatcher != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dispatcher.Stop(shutdownCtx)
+			log.Info("Webhook dispatcher stopped")
+		}()
+	}
+
+	if grpcSrv != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Info("Shutting down gRPC server...")
+			grpcSrv.Stop(shutdownCtx)
+			log.Info("gRPC server stopped")
+		}()
+	}
+
 	completed := make(chan struct{})
 
 	go func() {
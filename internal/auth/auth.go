@@ -0,0 +1,257 @@
+// Package auth - аутентификация запросов по статическим API-ключам и JWT (HS256/RS256) и роли доступа.
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"wb_l0/configs"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type Role string
+
+const (
+	RoleReader  Role = "reader"
+	RoleSupport Role = "support"
+	RoleAdmin   Role = "admin"
+)
+
+// rank задаёт иерархию ролей: роль с большим рангом имеет все права младших
+var rank = map[Role]int{
+	RoleReader:  1,
+	RoleSupport: 2,
+	RoleAdmin:   3,
+}
+
+// ParseRole проверяет, что роль известна
+func ParseRole(value string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(value)))
+	if _, ok := rank[role]; !ok {
+		return "", fmt.Errorf("unknown role %q", value)
+	}
+	return role, nil
+}
+
+// Allows сообщает, достаточно ли роли r для доступа к ресурсу с ролью required
+func (r Role) Allows(required Role) bool {
+	return rank[r] >= rank[required]
+}
+
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// CredentialKind - заголовок, из которого взяты учётные данные. Способ проверки выбирается по заголовку,
+// а не по виду строки, поэтому API-ключ с точками не принимается за JWT.
+type CredentialKind int
+
+const (
+	// CredentialAPIKey - X-API-Key (в gRPC - метаданные x-api-key)
+	CredentialAPIKey CredentialKind = iota + 1
+	// CredentialBearer - Authorization: Bearer <jwt>
+	CredentialBearer
+)
+
+// Principal - аутентифицированный клиент
+type Principal struct {
+	Subject string
+	Role    Role
+	Method  string
+	// KeyID различает учётные данные, у которых совпадает Subject: хеш API-ключа, sub JWT или хеш токена без sub
+	KeyID string
+}
+
+var (
+	// ErrUnauthenticated - учётные данные отсутствуют или недействительны
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden - роли клиента недостаточно для операции
+	ErrForbidden = errors.New("forbidden")
+)
+
+type apiKey struct {
+	key    []byte
+	id     string
+	name   string
+	role   Role
+	bearer bool
+}
+
+type Authenticator struct {
+	apiKeys   []apiKey
+	hsSecret  []byte
+	rsKey     *rsa.PublicKey
+	issuer    string
+	audience  string
+	roleClaim string
+}
+
+// NewAuthenticator загружает ключи JWT из файлов и разбирает API-ключи из конфигурации
+func NewAuthenticator(cfg configs.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{
+		issuer:    cfg.JWTIssuer,
+		audience:  cfg.JWTAudience,
+		roleClaim: cfg.JWTRoleClaim,
+	}
+	if a.roleClaim == "" {
+		a.roleClaim = "role"
+	}
+
+	for _, key := range cfg.APIKeys {
+		role, err := ParseRole(key.Role)
+		if err != nil {
+			return nil, fmt.Errorf("api key %q: %w", key.Name, err)
+		}
+		a.apiKeys = append(a.apiKeys, apiKey{
+			key:    []byte(key.Key),
+			id:     credentialID(key.Key),
+			name:   key.Name,
+			role:   role,
+			bearer: key.Bearer,
+		})
+	}
+
+	if cfg.JWTHS256SecretFile != "" {
+		secret, err := os.ReadFile(cfg.JWTHS256SecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read HS256 secret: %w", err)
+		}
+		a.hsSecret = []byte(strings.TrimSpace(string(secret)))
+		if len(a.hsSecret) == 0 {
+			return nil, fmt.Errorf("HS256 secret file %s is empty", cfg.JWTHS256SecretFile)
+		}
+	}
+
+	if cfg.JWTRS256PublicKeyFile != "" {
+		pem, err := os.ReadFile(cfg.JWTRS256PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read RS256 public key: %w", err)
+		}
+		if a.rsKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+			return nil, fmt.Errorf("failed to parse RS256 public key: %w", err)
+		}
+	}
+
+	return a, nil
+}
+
+// Authenticate проверяет учётные данные по заголовку kind: X-API-Key - API-ключ, Bearer - JWT.
+// В Bearer принимаются также ключи с флагом Bearer (устаревший HTTP_ADMIN_TOKEN).
+func (a *Authenticator) Authenticate(kind CredentialKind, credential string) (*Principal, error) {
+	if credential == "" {
+		return nil, fmt.Errorf("%w: no credentials", ErrUnauthenticated)
+	}
+	switch kind {
+	case CredentialAPIKey:
+		return a.authenticateAPIKey(credential, false)
+	case CredentialBearer:
+		if principal, err := a.authenticateAPIKey(credential, true); err == nil {
+			return principal, nil
+		}
+		return a.authenticateJWT(credential)
+	}
+	return nil, fmt.Errorf("%w: unsupported credential kind", ErrUnauthenticated)
+}
+
+// authenticateAPIKey ищет ключ среди всех ключей или, если bearerOnly, среди ключей с флагом Bearer
+func (a *Authenticator) authenticateAPIKey(credential string, bearerOnly bool) (*Principal, error) {
+	var found *apiKey
+	// Сравниваем со всеми ключами, чтобы время ответа не зависело от позиции ключа
+	for i := range a.apiKeys {
+		match := subtle.ConstantTimeCompare([]byte(credential), a.apiKeys[i].key) == 1
+		if match && (!bearerOnly || a.apiKeys[i].bearer) {
+			found = &a.apiKeys[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: invalid api key", ErrUnauthenticated)
+	}
+	return &Principal{Subject: found.name, Role: found.role, Method: MethodAPIKey, KeyID: found.id}, nil
+}
+
+// credentialID - идентификатор учётных данных, по которому их нельзя восстановить
+func credentialID(credential string) string {
+	sum := sha256.Sum256([]byte(credential))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (a *Authenticator) authenticateJWT(credential string) (*Principal, error) {
+	var methods []string
+	if a.hsSecret != nil {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if a.rsKey != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("%w: jwt is not configured", ErrUnauthenticated)
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if a.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.issuer))
+	}
+	if a.audience != "" {
+		opts = append(opts, jwt.WithAudience(a.audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(credential, claims, a.keyFunc, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	}
+
+	rawRole, _ := claims[a.roleClaim].(string)
+	role, err := ParseRole(rawRole)
+	if err != nil {
+		return nil, fmt.Errorf("%w: claim %s: %w", ErrUnauthenticated, a.roleClaim, err)
+	}
+	subject, _ := claims.GetSubject()
+	keyID := "sub:" + subject
+	if subject == "" {
+		keyID = credentialID(credential)
+	}
+	return &Principal{Subject: subject, Role: role, Method: MethodJWT, KeyID: keyID}, nil
+}
+
+func (a *Authenticator) keyFunc(token *jwt.Token) (any, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return a.hsSecret, nil
+	case jwt.SigningMethodRS256.Alg():
+		return a.rsKey, nil
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
+
+// Authorize проверяет, что у principal есть роль required
+func Authorize(principal *Principal, required Role) error {
+	if principal == nil {
+		return ErrUnauthenticated
+	}
+	if !principal.Role.Allows(required) {
+		return fmt.Errorf("%w: role %s is required", ErrForbidden, required)
+	}
+	return nil
+}
+
+type principalKey struct{}
+
+// WithPrincipal сохраняет клиента в контексте запроса
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext возвращает клиента, сохранённого WithPrincipal
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
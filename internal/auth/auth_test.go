@@ -0,0 +1,234 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"wb_l0/configs"
+	"wb_l0/internal/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const hsSecret = "test-secret"
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func newAuthenticator(t *testing.T) (*auth.Authenticator, *rsa.PrivateKey) {
+	t.Helper()
+	rsKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(&rsKey.PublicKey)
+	require.NoError(t, err)
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+
+	a, err := auth.NewAuthenticator(configs.AuthConfig{
+		Enabled: true,
+		APIKeys: []configs.APIKey{
+			{Key: "reader-key", Role: "reader", Name: "dashboard"},
+			{Key: "admin-key", Role: "admin", Name: "ops"},
+		},
+		JWTHS256SecretFile:    writeFile(t, "secret", []byte(hsSecret+"\n")),
+		JWTRS256PublicKeyFile: writeFile(t, "key.pem", pubPEM),
+		JWTIssuer:             "wb",
+		JWTRoleClaim:          "role",
+	})
+	require.NoError(t, err)
+	return a, rsKey
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func validClaims(role string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":  "user-1",
+		"iss":  "wb",
+		"role": role,
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	a, rsKey := newAuthenticator(t)
+
+	t.Run("api key", func(t *testing.T) {
+		principal, err := a.Authenticate(auth.CredentialAPIKey, "reader-key")
+
+		require.NoError(t, err)
+		assert.Equal(t, auth.Principal{Subject: "dashboard", Role: auth.RoleReader, Method: auth.MethodAPIKey,
+			KeyID: principal.KeyID}, *principal)
+		assert.NotEmpty(t, principal.KeyID)
+		assert.NotContains(t, principal.KeyID, "reader-key")
+	})
+
+	t.Run("unnamed api keys of one role have different key ids", func(t *testing.T) {
+		a, err := auth.NewAuthenticator(configs.AuthConfig{APIKeys: []configs.APIKey{
+			{Key: "first-key", Role: "reader", Name: "reader"},
+			{Key: "second-key", Role: "reader", Name: "reader"},
+		}})
+		require.NoError(t, err)
+
+		first, err := a.Authenticate(auth.CredentialAPIKey, "first-key")
+		require.NoError(t, err)
+		second, err := a.Authenticate(auth.CredentialAPIKey, "second-key")
+		require.NoError(t, err)
+
+		assert.Equal(t, first.Subject, second.Subject)
+		assert.NotEqual(t, first.KeyID, second.KeyID)
+	})
+
+	t.Run("api key with dots is not parsed as jwt", func(t *testing.T) {
+		a, err := auth.NewAuthenticator(configs.AuthConfig{APIKeys: []configs.APIKey{
+			{Key: "team.reader.key", Role: "reader", Name: "team"},
+		}})
+		require.NoError(t, err)
+
+		principal, err := a.Authenticate(auth.CredentialAPIKey, "team.reader.key")
+
+		require.NoError(t, err)
+		assert.Equal(t, auth.MethodAPIKey, principal.Method)
+	})
+
+	t.Run("api key in bearer is checked as jwt", func(t *testing.T) {
+		_, err := a.Authenticate(auth.CredentialBearer, "reader-key")
+
+		assert.True(t, errors.Is(err, auth.ErrUnauthenticated))
+	})
+
+	t.Run("legacy admin token is accepted in bearer", func(t *testing.T) {
+		a, err := auth.NewAuthenticator(configs.AuthConfig{APIKeys: []configs.APIKey{
+			{Key: "legacy-token", Role: "admin", Name: "legacy-admin-token", Bearer: true},
+		}})
+		require.NoError(t, err)
+
+		principal, err := a.Authenticate(auth.CredentialBearer, "legacy-token")
+
+		require.NoError(t, err)
+		assert.Equal(t, auth.RoleAdmin, principal.Role)
+	})
+
+	t.Run("jwt in api key header is rejected", func(t *testing.T) {
+		token := sign(t, jwt.SigningMethodHS256, []byte(hsSecret), validClaims("reader"))
+
+		_, err := a.Authenticate(auth.CredentialAPIKey, token)
+
+		assert.True(t, errors.Is(err, auth.ErrUnauthenticated))
+	})
+
+	t.Run("unknown api key", func(t *testing.T) {
+		_, err := a.Authenticate(auth.CredentialAPIKey, "nope")
+
+		assert.True(t, errors.Is(err, auth.ErrUnauthenticated))
+	})
+
+	t.Run("empty credential", func(t *testing.T) {
+		_, err := a.Authenticate(auth.CredentialAPIKey, "")
+
+		assert.True(t, errors.Is(err, auth.ErrUnauthenticated))
+	})
+
+	t.Run("HS256 jwt", func(t *testing.T) {
+		token := sign(t, jwt.SigningMethodHS256, []byte(hsSecret), validClaims("support"))
+
+		principal, err := a.Authenticate(auth.CredentialBearer, token)
+
+		require.NoError(t, err)
+		assert.Equal(t, auth.Principal{Subject: "user-1", Role: auth.RoleSupport, Method: auth.MethodJWT,
+			KeyID: "sub:user-1"}, *principal)
+	})
+
+	t.Run("jwt without sub is keyed by token", func(t *testing.T) {
+		claims := validClaims("reader")
+		delete(claims, "sub")
+		first := sign(t, jwt.SigningMethodHS256, []byte(hsSecret), claims)
+		claims["exp"] = time.Now().Add(2 * time.Hour).Unix()
+		second := sign(t, jwt.SigningMethodHS256, []byte(hsSecret), claims)
+
+		firstPrincipal, err := a.Authenticate(auth.CredentialBearer, first)
+		require.NoError(t, err)
+		secondPrincipal, err := a.Authenticate(auth.CredentialBearer, second)
+		require.NoError(t, err)
+
+		assert.NotEmpty(t, firstPrincipal.KeyID)
+		assert.NotEqual(t, firstPrincipal.KeyID, secondPrincipal.KeyID)
+	})
+
+	t.Run("RS256 jwt", func(t *testing.T) {
+		token := sign(t, jwt.SigningMethodRS256, rsKey, validClaims("admin"))
+
+		principal, err := a.Authenticate(auth.CredentialBearer, token)
+
+		require.NoError(t, err)
+		assert.Equal(t, auth.RoleAdmin, principal.Role)
+	})
+
+	t.Run("rejected tokens", func(t *testing.T) {
+		expired := validClaims("reader")
+		expired["exp"] = time.Now().Add(-time.Minute).Unix()
+		noExp := validClaims("reader")
+		delete(noExp, "exp")
+		wrongIssuer := validClaims("reader")
+		wrongIssuer["iss"] = "other"
+
+		tests := map[string]string{
+			"expired":        sign(t, jwt.SigningMethodHS256, []byte(hsSecret), expired),
+			"without exp":    sign(t, jwt.SigningMethodHS256, []byte(hsSecret), noExp),
+			"wrong issuer":   sign(t, jwt.SigningMethodHS256, []byte(hsSecret), wrongIssuer),
+			"wrong secret":   sign(t, jwt.SigningMethodHS256, []byte("other"), validClaims("reader")),
+			"unknown role":   sign(t, jwt.SigningMethodHS256, []byte(hsSecret), validClaims("root")),
+			"disallowed alg": sign(t, jwt.SigningMethodHS384, []byte(hsSecret), validClaims("reader")),
+		}
+		for name, token := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := a.Authenticate(auth.CredentialBearer, token)
+
+				assert.True(t, errors.Is(err, auth.ErrUnauthenticated), err)
+			})
+		}
+	})
+}
+
+func TestAuthorize(t *testing.T) {
+	support := &auth.Principal{Subject: "s", Role: auth.RoleSupport}
+
+	assert.NoError(t, auth.Authorize(support, auth.RoleReader))
+	assert.NoError(t, auth.Authorize(support, auth.RoleSupport))
+	assert.True(t, errors.Is(auth.Authorize(support, auth.RoleAdmin), auth.ErrForbidden))
+	assert.True(t, errors.Is(auth.Authorize(nil, auth.RoleReader), auth.ErrUnauthenticated))
+}
+
+func TestNewAuthenticator_InvalidConfig(t *testing.T) {
+	t.Run("unknown api key role", func(t *testing.T) {
+		_, err := auth.NewAuthenticator(configs.AuthConfig{
+			APIKeys: []configs.APIKey{{Key: "k", Role: "root", Name: "k"}},
+		})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("missing secret file", func(t *testing.T) {
+		_, err := auth.NewAuthenticator(configs.AuthConfig{
+			JWTHS256SecretFile: filepath.Join(t.TempDir(), "missing"),
+		})
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,191 @@
+package grpc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"wb_l0/internal/domain"
+	orderv1 "wb_l0/pkg/api/order/v1"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toProtoOrder(order *domain.Order) *orderv1.Order {
+	items := make([]*orderv1.Item, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, &orderv1.Item{
+			ChrtId:      int64(item.ChrtID),
+			TrackNumber: item.TrackNumber,
+			Price:       int64(item.Price),
+			Rid:         item.RID,
+			Name:        item.Name,
+			Sale:        int32(item.Sale),
+			Size:        item.Size,
+			TotalPrice:  int64(item.TotalPrice),
+			NmId:        int64(item.NMID),
+			Brand:       item.Brand,
+			Status:      int32(item.Status),
+			Quantity:    int32(item.Quantity),
+		})
+	}
+
+	result := &orderv1.Order{
+		OrderUid:    order.OrderUID,
+		TrackNumber: order.TrackNumber,
+		Entry:       order.Entry,
+		Delivery: &orderv1.Delivery{
+			Name:    order.Delivery.Name,
+			Phone:   order.Delivery.Phone,
+			Zip:     order.Delivery.Zip,
+			City:    order.Delivery.City,
+			Address: order.Delivery.Address,
+			Region:  order.Delivery.Region,
+			Email:   order.Delivery.Email,
+		},
+		Payment: &orderv1.Payment{
+			Transaction:  order.Payment.Transaction,
+			RequestId:    order.Payment.RequestID,
+			Currency:     order.Payment.Currency,
+			Provider:     order.Payment.Provider,
+			Amount:       int64(order.Payment.Amount),
+			PaymentDt:    order.Payment.PaymentDT,
+			Bank:         order.Payment.Bank,
+			DeliveryCost: int64(order.Payment.DeliveryCost),
+			GoodsTotal:   int64(order.Payment.GoodsTotal),
+			CustomFee:    int64(order.Payment.CustomFee),
+		},
+		Items:             items,
+		Locale:            order.Locale,
+		InternalSignature: order.InternalSignature,
+		CustomerId:        order.CustomerID,
+		DeliveryService:   order.DeliveryService,
+		Shardkey:          order.ShardKey,
+		SmId:              int64(order.SMID),
+		DateCreated:       timestamppb.New(order.DateCreated),
+		OofShard:          order.OOFShard,
+		Version:           int32(order.Version),
+	}
+	for _, change := range order.Timeline {
+		result.Timeline = append(result.Timeline, &orderv1.ItemStatusChange{
+			ChrtId:    int64(change.ChrtID),
+			From:      int32(change.From),
+			To:        int32(change.To),
+			Actor:     change.Actor,
+			ChangedAt: timestamppb.New(change.ChangedAt),
+		})
+	}
+	if order.Cancellation != nil {
+		result.Cancellation = &orderv1.Cancellation{
+			Reason:      order.Cancellation.Reason,
+			Actor:       order.Cancellation.Actor,
+			CancelledAt: timestamppb.New(order.Cancellation.CancelledAt),
+		}
+	}
+	for _, refund := range order.Refunds {
+		result.Refunds = append(result.Refunds, &orderv1.Refund{
+			Id:        refund.ID,
+			ChrtId:    int64(refund.ChrtID),
+			Amount:    int64(refund.Amount),
+			Reason:    refund.Reason,
+			RequestId: refund.RequestID,
+			Actor:     refund.Actor,
+			CreatedAt: timestamppb.New(refund.CreatedAt),
+		})
+	}
+	return result
+}
+
+func toProtoOrders(orders []*domain.Order) []*orderv1.Order {
+	result := make([]*orderv1.Order, 0, len(orders))
+	for _, order := range orders {
+		result = append(result, toProtoOrder(order))
+	}
+	return result
+}
+
+// fromProtoOrder переводит заказ из CreateOrder. Timeline, cancellation и refunds клиент не задаёт, они игнорируются.
+func fromProtoOrder(order *orderv1.Order) domain.Order {
+	items := make([]domain.Item, 0, len(order.GetItems()))
+	for _, item := range order.GetItems() {
+		items = append(items, domain.Item{
+			ChrtID:      int(item.GetChrtId()),
+			TrackNumber: item.GetTrackNumber(),
+			Price:       int(item.GetPrice()),
+			RID:         item.GetRid(),
+			Name:        item.GetName(),
+			Sale:        int(item.GetSale()),
+			Size:        item.GetSize(),
+			TotalPrice:  int(item.GetTotalPrice()),
+			NMID:        int(item.GetNmId()),
+			Brand:       item.GetBrand(),
+			Status:      int(item.GetStatus()),
+			Quantity:    int(item.GetQuantity()),
+		})
+	}
+
+	delivery := order.GetDelivery()
+	payment := order.GetPayment()
+	result := domain.Order{
+		OrderUID:    order.GetOrderUid(),
+		TrackNumber: order.GetTrackNumber(),
+		Entry:       order.GetEntry(),
+		Delivery: domain.Delivery{
+			Name:    delivery.GetName(),
+			Phone:   delivery.GetPhone(),
+			Zip:     delivery.GetZip(),
+			City:    delivery.GetCity(),
+			Address: delivery.GetAddress(),
+			Region:  delivery.GetRegion(),
+			Email:   delivery.GetEmail(),
+		},
+		Payment: domain.Payment{
+			Transaction:  payment.GetTransaction(),
+			RequestID:    payment.GetRequestId(),
+			Currency:     payment.GetCurrency(),
+			Provider:     payment.GetProvider(),
+			Amount:       int(payment.GetAmount()),
+			PaymentDT:    payment.GetPaymentDt(),
+			Bank:         payment.GetBank(),
+			DeliveryCost: int(payment.GetDeliveryCost()),
+			GoodsTotal:   int(payment.GetGoodsTotal()),
+			CustomFee:    int(payment.GetCustomFee()),
+		},
+		Items:             items,
+		Locale:            order.GetLocale(),
+		InternalSignature: order.GetInternalSignature(),
+		CustomerID:        order.GetCustomerId(),
+		DeliveryService:   order.GetDeliveryService(),
+		ShardKey:          order.GetShardkey(),
+		SMID:              int(order.GetSmId()),
+		OOFShard:          order.GetOofShard(),
+		Version:           int(order.GetVersion()),
+	}
+	if order.GetDateCreated() != nil {
+		result.DateCreated = order.GetDateCreated().AsTime()
+	}
+	return result
+}
+
+// encodePageToken превращает курсор в непрозрачный для клиента page_token
+func encodePageToken(cursor *domain.OrderCursor) string {
+	if cursor == nil {
+		return ""
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(token string) (*domain.OrderCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page token: %w", err)
+	}
+	var cursor domain.OrderCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.OrderUID == "" {
+		return nil, fmt.Errorf("invalid page token")
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+	"wb_l0/internal/auth"
+	"wb_l0/internal/domain"
+	orderv1 "wb_l0/pkg/api/order/v1"
+	"wb_l0/pkg/prometheus"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodRoles - роль, необходимая для вызова метода. Методы вне списка (health, reflection) доступны всем.
+var methodRoles = map[string]auth.Role{
+	orderv1.OrderService_GetOrder_FullMethodName:       auth.RoleReader,
+	orderv1.OrderService_BatchGetOrders_FullMethodName: auth.RoleReader,
+	orderv1.OrderService_ListOrders_FullMethodName:     auth.RoleReader,
+	orderv1.OrderService_CreateOrder_FullMethodName:    auth.RoleAdmin,
+}
+
+// authInterceptor проверяет учётные данные из метаданных "authorization: Bearer <jwt>" или "x-api-key".
+// nil authenticator означает выключенную аутентификацию: клиент получает роль admin.
+func authInterceptor(authenticator *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		required, protected := methodRoles[info.FullMethod]
+		if !protected {
+			return handler(ctx, req)
+		}
+
+		principal := &auth.Principal{Subject: "anonymous", Role: auth.RoleAdmin}
+		if authenticator != nil {
+			var err error
+			principal, err = authenticator.Authenticate(credential(ctx))
+			if err == nil {
+				err = auth.Authorize(principal, required)
+			}
+			if errors.Is(err, auth.ErrForbidden) {
+				return nil, status.Error(codes.PermissionDenied, err.Error())
+			}
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, "valid api key or bearer token is required")
+			}
+		}
+		ctx = domain.WithActor(auth.WithPrincipal(ctx, principal), "grpc:"+principal.Subject)
+		return handler(ctx, req)
+	}
+}
+
+// credential возвращает учётные данные и метаданные, из которых они взяты: Bearer важнее x-api-key
+func credential(ctx context.Context) (auth.CredentialKind, string) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return auth.CredentialAPIKey, ""
+	}
+	if values := md.Get("authorization"); len(values) > 0 {
+		if token, ok := strings.CutPrefix(values[0], "Bearer "); ok {
+			return auth.CredentialBearer, strings.TrimSpace(token)
+		}
+	}
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		return auth.CredentialAPIKey, values[0]
+	}
+	return auth.CredentialAPIKey, ""
+}
+
+// metricsInterceptor учитывает вызовы и их длительность по методам и кодам ответа
+func metricsInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		prometheus.GrpcRequestsTotal.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+		prometheus.GrpcRequestDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+		return resp, err
+	}
+}
+
+func loggingInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		attrs := []any{
+			"method", info.FullMethod,
+			"code", code.String(),
+			"duration_ms", time.Since(start).Milliseconds(),
+		}
+		switch code {
+		case codes.OK, codes.NotFound, codes.InvalidArgument, codes.AlreadyExists,
+			codes.Unauthenticated, codes.PermissionDenied:
+			log.Info("gRPC request completed", attrs...)
+		default:
+			log.Error("gRPC request failed", append(attrs, "error", err)...)
+		}
+		return resp, err
+	}
+}
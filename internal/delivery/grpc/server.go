@@ -0,0 +1,234 @@
+// Package grpc - gRPC API сервиса заказов поверх usecase.OrderUsecase.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"time"
+	"wb_l0/configs"
+	"wb_l0/internal/auth"
+	"wb_l0/internal/delivery/projection"
+	"wb_l0/internal/domain"
+	"wb_l0/internal/health"
+	"wb_l0/internal/usecase"
+	orderv1 "wb_l0/pkg/api/order/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthgrpc "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// healthPollInterval - период обновления статуса gRPC health service по результатам проверок зависимостей
+const healthPollInterval = 10 * time.Second
+
+// Server - gRPC сервер с OrderService, health service и reflection
+type Server struct {
+	server  *grpc.Server
+	health  *healthgrpc.Server
+	checker *health.Checker
+	log     *slog.Logger
+	stop    chan struct{}
+}
+
+func NewServer(uc *usecase.OrderUsecase, cfg configs.GRPCConfig, masking map[string]map[string]string,
+	authenticator *auth.Authenticator, checker *health.Checker, log *slog.Logger) *Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		metricsInterceptor(),
+		loggingInterceptor(log),
+		authInterceptor(authenticator),
+	))
+
+	orderv1.RegisterOrderServiceServer(server, &orderService{
+		uc:           uc,
+		projector:    projection.New(masking),
+		batchMaxUIDs: cfg.BatchMaxUIDs,
+	})
+
+	healthServer := healthgrpc.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	if cfg.Reflection {
+		reflection.Register(server)
+	}
+
+	return &Server{
+		server:  server,
+		health:  healthServer,
+		checker: checker,
+		log:     log,
+		stop:    make(chan struct{}),
+	}
+}
+
+// Serve принимает соединения на lis до вызова Stop
+func (s *Server) Serve(lis net.Listener) error {
+	s.setServing(healthpb.HealthCheckResponse_SERVING)
+	if s.checker != nil {
+		go s.pollHealth()
+	}
+	if err := s.server.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Stop дожидается завершения текущих вызовов, но не дольше ctx
+func (s *Server) Stop(ctx context.Context) {
+	close(s.stop)
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.server.Stop()
+	}
+}
+
+func (s *Server) pollHealth() {
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			report := s.checker.Ready(context.Background())
+			if report.Status == health.StatusDown {
+				s.log.Warn("gRPC health is not serving", "components", report.Components)
+				s.setServing(healthpb.HealthCheckResponse_NOT_SERVING)
+			} else {
+				s.setServing(healthpb.HealthCheckResponse_SERVING)
+			}
+		}
+	}
+}
+
+func (s *Server) setServing(servingStatus healthpb.HealthCheckResponse_ServingStatus) {
+	s.health.SetServingStatus("", servingStatus)
+	s.health.SetServingStatus(orderv1.OrderService_ServiceDesc.ServiceName, servingStatus)
+}
+
+type orderService struct {
+	orderv1.UnimplementedOrderServiceServer
+	uc           *usecase.OrderUsecase
+	projector    *projection.Projector
+	batchMaxUIDs int
+}
+
+func (s *orderService) GetOrder(ctx context.Context, req *orderv1.GetOrderRequest) (*orderv1.GetOrderResponse, error) {
+	if len(req.GetOrderUid()) != 20 {
+		return nil, status.Error(codes.InvalidArgument, "order_uid must be 20 characters long")
+	}
+
+	order, err := s.uc.GetOrder(ctx, req.GetOrderUid())
+	if err != nil {
+		return nil, toStatus(err, "failed to retrieve order")
+	}
+	return &orderv1.GetOrderResponse{Order: toProtoOrder(s.projector.Project(role(ctx), order))}, nil
+}
+
+func (s *orderService) BatchGetOrders(ctx context.Context,
+	req *orderv1.BatchGetOrdersRequest) (*orderv1.BatchGetOrdersResponse, error) {
+	uids := req.GetOrderUids()
+	if len(uids) == 0 || len(uids) > s.batchMaxUIDs {
+		return nil, status.Errorf(codes.InvalidArgument, "order_uids must contain from 1 to %d elements",
+			s.batchMaxUIDs)
+	}
+	for _, uid := range uids {
+		if len(uid) != 20 {
+			return nil, status.Errorf(codes.InvalidArgument, "order_uid %q must be 20 characters long", uid)
+		}
+	}
+
+	orders, missing, err := s.uc.GetOrders(ctx, uids)
+	if err != nil {
+		return nil, toStatus(err, "failed to retrieve orders")
+	}
+	return &orderv1.BatchGetOrdersResponse{
+		Orders:  toProtoOrders(s.projector.ProjectAll(role(ctx), orders)),
+		Missing: missing,
+	}, nil
+}
+
+func (s *orderService) ListOrders(ctx context.Context,
+	req *orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+	cursor, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+
+	filter := domain.OrderFilter{
+		CustomerID: req.GetCustomerId(),
+		After:      cursor,
+		Limit:      int(req.GetPageSize()),
+	}
+	if req.GetCreatedFrom() != nil {
+		filter.CreatedFrom = req.GetCreatedFrom().AsTime()
+	}
+	if req.GetCreatedTo() != nil {
+		filter.CreatedTo = req.GetCreatedTo().AsTime()
+	}
+
+	orders, next, err := s.uc.ListOrders(ctx, filter)
+	if err != nil {
+		return nil, toStatus(err, "failed to list orders")
+	}
+	return &orderv1.ListOrdersResponse{
+		Orders:        toProtoOrders(s.projector.ProjectAll(role(ctx), orders)),
+		NextPageToken: encodePageToken(next),
+	}, nil
+}
+
+func (s *orderService) CreateOrder(ctx context.Context,
+	req *orderv1.CreateOrderRequest) (*orderv1.CreateOrderResponse, error) {
+	if req.GetOrder() == nil {
+		return nil, status.Error(codes.InvalidArgument, "order is required")
+	}
+
+	order := fromProtoOrder(req.GetOrder())
+	if err := s.uc.CreateOrder(ctx, order); err != nil {
+		return nil, toStatus(err, "failed to create order")
+	}
+	return &orderv1.CreateOrderResponse{OrderUid: order.OrderUID}, nil
+}
+
+func role(ctx context.Context) auth.Role {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		return principal.Role
+	}
+	return ""
+}
+
+// toStatus переводит доменные ошибки в коды gRPC, подробности внутренних ошибок клиенту не отдаются
+func toStatus(err error, internalMessage string) error {
+	switch {
+	case errors.Is(err, domain.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "order not found")
+	case errors.Is(err, domain.ErrValidation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrConflict), errors.Is(err, domain.ErrContentConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, domain.ErrTransient):
+		return status.Error(codes.Unavailable, internalMessage)
+	}
+	return status.Error(codes.Internal, internalMessage)
+}
@@ -0,0 +1,267 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+	"wb_l0/configs"
+	"wb_l0/internal/auth"
+	"wb_l0/internal/domain"
+	"wb_l0/internal/usecase"
+	orderv1 "wb_l0/pkg/api/order/v1"
+	"wb_l0/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeStore хранит заказы в памяти
+type fakeStore struct {
+	orders []*domain.Order
+}
+
+// SaveOrder отклоняет заказ с уже сохранённым order_uid как расходящийся с сохранённым
+func (s *fakeStore) SaveOrder(ctx context.Context, order *domain.Order) error {
+	if _, err := s.GetOrderByUID(ctx, order.OrderUID); err == nil {
+		return &domain.ContentConflictError{OrderUID: order.OrderUID}
+	}
+	s.orders = append(s.orders, order)
+	return nil
+}
+
+func (s *fakeStore) GetOrderByUID(_ context.Context, orderUID string) (*domain.Order, error) {
+	for _, order := range s.orders {
+		if order.OrderUID == orderUID {
+			return order, nil
+		}
+	}
+	return nil, domain.ErrRecordNotFound
+}
+
+func (s *fakeStore) GetOrdersByUIDs(ctx context.Context, orderUIDs []string) ([]*domain.Order, error) {
+	var found []*domain.Order
+	for _, uid := range orderUIDs {
+		if order, err := s.GetOrderByUID(ctx, uid); err == nil {
+			found = append(found, order)
+		}
+	}
+	return found, nil
+}
+
+func (s *fakeStore) DeleteOrder(context.Context, string) error {
+	return nil
+}
+
+// ListOrders ожидает, что orders уже упорядочены от новых к старым
+func (s *fakeStore) ListOrders(_ context.Context, filter domain.OrderFilter) ([]*domain.Order, error) {
+	var result []*domain.Order
+	for _, order := range s.orders {
+		if filter.After != nil && order.OrderUID >= filter.After.OrderUID {
+			continue
+		}
+		result = append(result, order)
+		if len(result) == filter.Limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeStore) UpdateItemStatus(context.Context, *domain.ItemStatusChange) error {
+	return nil
+}
+
+func (s *fakeStore) SaveRefund(context.Context, *domain.Refund) error {
+	return nil
+}
+
+func (s *fakeStore) UpdateOrder(context.Context, *domain.Order, int) error {
+	return nil
+}
+
+func (s *fakeStore) GetAuditLog(context.Context, string) ([]domain.AuditEntry, error) {
+	return nil, nil
+}
+
+func newTestClient(t *testing.T, store *fakeStore) orderv1.OrderServiceClient {
+	t.Helper()
+	log := logger.NewTestLogger()
+	authenticator, err := auth.NewAuthenticator(configs.AuthConfig{
+		APIKeys: []configs.APIKey{
+			{Key: "reader-key", Role: "reader", Name: "reader"},
+			{Key: "admin-key", Role: "admin", Name: "admin"},
+		},
+	})
+	require.NoError(t, err)
+
+	uc := usecase.NewOrderUsecase(store, usecase.RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond,
+		MaxDelay: time.Millisecond}, log)
+	server := NewServer(uc, configs.GRPCConfig{BatchMaxUIDs: 10},
+		map[string]map[string]string{"reader": {"phone": "full"}}, authenticator, nil, log)
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(func() { server.Stop(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	t.Run("health service", func(t *testing.T) {
+		resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	})
+
+	return orderv1.NewOrderServiceClient(conn)
+}
+
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+}
+
+func testOrder(uid string) *domain.Order {
+	order := domain.CreateTestOrder(1)
+	order.OrderUID = uid
+	order.Payment.Transaction = uid
+	return &order
+}
+
+func TestOrderService_GetOrder(t *testing.T) {
+	order := testOrder("00000000000000000001")
+	changed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	history := testOrder("00000000000000000002")
+	history.Version = 3
+	history.Timeline = []domain.ItemStatusChange{{ChrtID: history.Items[0].ChrtID, From: domain.ItemStatusCreated,
+		To: domain.ItemStatusApproved, Actor: "http:ops", ChangedAt: changed}}
+	history.Refunds = []domain.Refund{{ID: 7, ChrtID: history.Items[0].ChrtID, Amount: 100, Reason: "damaged",
+		Actor: "http:ops", CreatedAt: changed}}
+	history.Cancellation = &domain.Cancellation{Reason: "fraud", Actor: "kafka", CancelledAt: changed}
+	client := newTestClient(t, &fakeStore{orders: []*domain.Order{order, history}})
+
+	t.Run("reader gets masked order", func(t *testing.T) {
+		resp, err := client.GetOrder(withKey("reader-key"), &orderv1.GetOrderRequest{OrderUid: order.OrderUID})
+
+		require.NoError(t, err)
+		assert.Equal(t, order.OrderUID, resp.GetOrder().GetOrderUid())
+		assert.Equal(t, "***", resp.GetOrder().GetDelivery().GetPhone())
+		assert.Len(t, resp.GetOrder().GetItems(), len(order.Items))
+		assert.True(t, order.DateCreated.Equal(resp.GetOrder().GetDateCreated().AsTime()))
+	})
+
+	t.Run("admin gets full order", func(t *testing.T) {
+		resp, err := client.GetOrder(withKey("admin-key"), &orderv1.GetOrderRequest{OrderUid: order.OrderUID})
+
+		require.NoError(t, err)
+		assert.Equal(t, order.Delivery.Phone, resp.GetOrder().GetDelivery().GetPhone())
+	})
+
+	t.Run("version, timeline, cancellation and refunds", func(t *testing.T) {
+		resp, err := client.GetOrder(withKey("reader-key"), &orderv1.GetOrderRequest{OrderUid: history.OrderUID})
+
+		require.NoError(t, err)
+		got := resp.GetOrder()
+		assert.Equal(t, int32(3), got.GetVersion())
+		require.Len(t, got.GetTimeline(), 1)
+		assert.Equal(t, int32(domain.ItemStatusApproved), got.GetTimeline()[0].GetTo())
+		assert.True(t, changed.Equal(got.GetTimeline()[0].GetChangedAt().AsTime()))
+		require.Len(t, got.GetRefunds(), 1)
+		assert.Equal(t, int64(7), got.GetRefunds()[0].GetId())
+		assert.Equal(t, int64(100), got.GetRefunds()[0].GetAmount())
+		assert.Equal(t, "fraud", got.GetCancellation().GetReason())
+	})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		uid  string
+		code codes.Code
+	}{
+		{name: "no credentials", ctx: context.Background(), uid: order.OrderUID, code: codes.Unauthenticated},
+		{name: "invalid uid", ctx: withKey("reader-key"), uid: "short", code: codes.InvalidArgument},
+		{name: "not found", ctx: withKey("reader-key"), uid: "00000000000000000009", code: codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetOrder(tt.ctx, &orderv1.GetOrderRequest{OrderUid: tt.uid})
+
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
+
+func TestOrderService_ListOrders(t *testing.T) {
+	client := newTestClient(t, &fakeStore{orders: []*domain.Order{
+		testOrder("00000000000000000003"),
+		testOrder("00000000000000000002"),
+		testOrder("00000000000000000001"),
+	}})
+
+	first, err := client.ListOrders(withKey("reader-key"), &orderv1.ListOrdersRequest{PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, first.GetOrders(), 2)
+	require.NotEmpty(t, first.GetNextPageToken())
+
+	second, err := client.ListOrders(withKey("reader-key"),
+		&orderv1.ListOrdersRequest{PageSize: 2, PageToken: first.GetNextPageToken()})
+	require.NoError(t, err)
+	require.Len(t, second.GetOrders(), 1)
+	assert.Equal(t, "00000000000000000001", second.GetOrders()[0].GetOrderUid())
+	assert.Empty(t, second.GetNextPageToken())
+
+	_, err = client.ListOrders(withKey("reader-key"), &orderv1.ListOrdersRequest{PageToken: "%%%"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestOrderService_CreateOrder(t *testing.T) {
+	store := &fakeStore{}
+	client := newTestClient(t, store)
+	order := testOrder("b563feb7b2b84b6aaaa1")
+	order.Version = 2
+
+	t.Run("reader is forbidden", func(t *testing.T) {
+		_, err := client.CreateOrder(withKey("reader-key"), &orderv1.CreateOrderRequest{Order: toProtoOrder(order)})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("admin creates order", func(t *testing.T) {
+		resp, err := client.CreateOrder(withKey("admin-key"), &orderv1.CreateOrderRequest{Order: toProtoOrder(order)})
+
+		require.NoError(t, err)
+		assert.Equal(t, order.OrderUID, resp.GetOrderUid())
+		require.Len(t, store.orders, 1)
+		assert.Equal(t, order.Items, store.orders[0].Items)
+		assert.Equal(t, 2, store.orders[0].Version)
+	})
+
+	t.Run("conflicting order is not silently skipped", func(t *testing.T) {
+		changed := toProtoOrder(order)
+		changed.Delivery.City = "Moscow"
+
+		_, err := client.CreateOrder(withKey("admin-key"), &orderv1.CreateOrderRequest{Order: changed})
+
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+		assert.Len(t, store.orders, 1)
+	})
+
+	t.Run("invalid order", func(t *testing.T) {
+		invalid := toProtoOrder(order)
+		invalid.Items = nil
+
+		_, err := client.CreateOrder(withKey("admin-key"), &orderv1.CreateOrderRequest{Order: invalid})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
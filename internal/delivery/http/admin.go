@@ -0,0 +1,122 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+	"wb_l0/configs"
+	_ "wb_l0/docs"
+	"wb_l0/internal/auth"
+	"wb_l0/internal/webhook"
+
+	"github.com/gin-contrib/pprof"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// SetupAdminRouter настраивает административный сервер: pprof, swagger, метрики, управление рантаймом
+// и подписки на вебхуки (если webhooks не nil)
+func SetupAdminRouter(cfg configs.AdminConfig, authenticator *auth.Authenticator, level *slog.LevelVar,
+	webhooks webhook.Repository, log *slog.Logger) *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	if !cfg.AuthEnabled {
+		authenticator = nil
+	}
+	adminAuth := Auth(authenticator, auth.RoleAdmin)
+
+	if cfg.MetricsPublic {
+		router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	} else {
+		router.GET("/metrics", adminAuth, gin.WrapH(promhttp.Handler()))
+	}
+
+	admin := router.Group("", adminAuth)
+	admin.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	pprof.RouteRegister(admin, "/debug/pprof")
+
+	adminHandler := &AdminHandler{level: level, started: time.Now(), log: log}
+	admin.GET("/admin/runtime", adminHandler.Runtime)
+	admin.GET("/admin/log-level", adminHandler.GetLogLevel)
+	admin.PUT("/admin/log-level", adminHandler.SetLogLevel)
+
+	if webhooks != nil {
+		webhookHandler := &WebhookHandler{repo: webhooks, log: log}
+		admin.GET("/admin/webhooks", webhookHandler.List)
+		admin.POST("/admin/webhooks", webhookHandler.Create)
+		admin.DELETE("/admin/webhooks/:id", webhookHandler.Delete)
+		admin.GET("/admin/webhooks/:id/deliveries", webhookHandler.Deliveries)
+	}
+
+	return router
+}
+
+type AdminHandler struct {
+	level   *slog.LevelVar
+	started time.Time
+	log     *slog.Logger
+}
+
+type RuntimeInfo struct {
+	GoVersion     string `json:"go_version"`
+	Version       string `json:"version"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	Goroutines    int    `json:"goroutines"`
+	GOMAXPROCS    int    `json:"gomaxprocs"`
+	HeapAllocMB   uint64 `json:"heap_alloc_mb"`
+	SysMB         uint64 `json:"sys_mb"`
+	NumGC         uint32 `json:"num_gc"`
+}
+
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// Runtime возвращает сведения о процессе
+func (h *AdminHandler) Runtime(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	info := RuntimeInfo{
+		GoVersion:     runtime.Version(),
+		UptimeSeconds: int64(time.Since(h.started).Seconds()),
+		Goroutines:    runtime.NumGoroutine(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		HeapAllocMB:   mem.HeapAlloc >> 20,
+		SysMB:         mem.Sys >> 20,
+		NumGC:         mem.NumGC,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		info.Version = build.Main.Version
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// GetLogLevel возвращает текущий уровень логирования
+func (h *AdminHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": strings.ToLower(h.level.Level().String())})
+}
+
+// SetLogLevel меняет уровень логирования без перезапуска: debug, info, warn, error
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	var req LogLevelRequest
+	var level slog.Level
+	if err := c.ShouldBindJSON(&req); err != nil || level.UnmarshalText([]byte(req.Level)) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "level must be one of debug, info, warn, error",
+		})
+		return
+	}
+
+	previous := h.level.Level()
+	h.level.Set(level)
+	h.log.Warn("Log level changed", "from", previous.String(), "to", level.String())
+	c.JSON(http.StatusOK, gin.H{"level": strings.ToLower(level.String())})
+}
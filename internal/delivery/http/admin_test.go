@@ -0,0 +1,54 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"wb_l0/configs"
+	"wb_l0/internal/auth"
+	"wb_l0/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupAdminRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator, err := auth.NewAuthenticator(configs.AuthConfig{
+		APIKeys: []configs.APIKey{
+			{Key: "admin-key", Role: "admin", Name: "ops"},
+			{Key: "reader-key", Role: "reader", Name: "web"},
+		},
+	})
+	require.NoError(t, err)
+	level := new(slog.LevelVar)
+	router := SetupAdminRouter(configs.AdminConfig{AuthEnabled: true, MetricsPublic: true}, authenticator, level, nil,
+		logger.NewTestLogger())
+
+	request := func(method, path, key, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/metrics", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "/debug/pprof/", "", "").Code)
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/admin/runtime", "reader-key", "").Code)
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/admin/runtime", "admin-key", "").Code)
+
+	t.Run("log level", func(t *testing.T) {
+		w := request(http.MethodPut, "/admin/log-level", "admin-key", `{"level":"warn"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, slog.LevelWarn, level.Level())
+		assert.Equal(t, http.StatusBadRequest,
+			request(http.MethodPut, "/admin/log-level", "admin-key", `{"level":"loud"}`).Code)
+	})
+}
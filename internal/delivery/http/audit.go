@@ -0,0 +1,56 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"wb_l0/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuditLog возвращает журнал аудита заказа
+// @Summary Get order audit log
+// @Description Append-only log of order mutations: who changed the order (kafka, http:<subject>, grpc:<subject>, cli:<command>), when, the operation and a diff of changed fields. The log is kept after the order is deleted. Values are not masked, requires admin token.
+// @Tags orders
+// @Produce json
+// @Security BearerAuth
+// @Security APIKey
+// @Param order_uid path string true "Order UID"
+// @Success 200 {array} domain.AuditEntry
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /order/{order_uid}/audit [get]
+func (h *OrderHandler) GetAuditLog(c *gin.Context) {
+	orderUID := c.Param("order_uid")
+	if len(orderUID) != 20 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_order_uid",
+			"message": "order_uid must be 20 characters long",
+		})
+		return
+	}
+
+	entries, err := h.uc.GetAuditLog(c.Request.Context(), orderUID)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":     "not_found",
+				"message":   "audit log is empty",
+				"order_uid": orderUID,
+			})
+			return
+		}
+		h.log.Error("Failed to get audit log", "error", err, "orderUID", orderUID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "failed to get audit log",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
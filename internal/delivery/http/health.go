@@ -0,0 +1,52 @@
+package http
+
+import (
+	"net/http"
+	"time"
+	"wb_l0/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+	started time.Time
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker, started: time.Now()}
+}
+
+// Live сообщает, что процесс жив и обрабатывает запросы. Зависимости не проверяются.
+// @Summary Liveness probe
+// @Description Returns 200 while the process is able to serve requests. Dependencies are not checked.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /health/live [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":         health.StatusOK,
+		"timestamp":      time.Now().Format(time.RFC3339),
+		"uptime_seconds": int64(time.Since(h.started).Seconds()),
+	})
+}
+
+// Ready проверяет зависимости. Недоступный кэш или Kafka дают статус degraded с кодом 200,
+// недоступная база - статус down с кодом 503.
+// @Summary Readiness probe
+// @Description Checks Postgres, Redis and Kafka consumer in parallel. "degraded" (200) when only optional dependencies fail, "down" (503) when the database fails.
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /health/ready [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.checker.Ready(c.Request.Context())
+
+	code := http.StatusOK
+	if report.Status == health.StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
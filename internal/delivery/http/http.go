@@ -3,32 +3,60 @@ package http
 import (
 	"log/slog"
 	"net/http"
-	_ "wb_l0/docs"
+	"wb_l0/configs"
+	"wb_l0/internal/auth"
+	"wb_l0/internal/health"
+	"wb_l0/internal/ratelimit"
+	"wb_l0/internal/stream"
 	"wb_l0/internal/usecase"
 	"wb_l0/pkg/prometheus"
 
-	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRouter(uc *usecase.OrderUsecase, log *slog.Logger) *gin.Engine {
+const apiV1Prefix = "/api/v1"
+
+// SetupRouter настраивает маршруты HTTP API и веб-интерфейса
+// @title WB Order Saver API
+// @version 1.0
+// @description Order storage service API
+// @BasePath /api/v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description "Bearer <jwt>", API keys go to X-API-Key. Roles: reader, support, admin
+// @securityDefinitions.apikey APIKey
+// @in header
+// @name X-API-Key
+func SetupRouter(uc *usecase.OrderUsecase, cfg configs.HttpConfig, authenticator *auth.Authenticator,
+	limiter ratelimit.Store, checker *health.Checker, broker *stream.Broker, log *slog.Logger) *gin.Engine {
 	router := gin.Default()
+	// По умолчанию gin доверяет X-Forwarded-For от любого клиента, и лимит по IP легко обойти
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Error("Invalid trusted proxies, X-Forwarded-For is ignored", "error", err)
+		_ = router.SetTrustedProxies(nil)
+	}
 
 	router.Static("/static", "./web")
 	router.LoadHTMLGlob("web/*.html")
 
 	router.Use(gin.Recovery())
 
-	orderHandler := NewOrderHandler(uc, log)
+	orderHandler := NewOrderHandler(uc, cfg, log)
+	healthHandler := NewHealthHandler(checker)
 
 	router.Use(prometheus.Middleware())
 
-	router.GET("/health", orderHandler.HealthCheck)
-	router.GET("/order/:order_uid", orderHandler.GetOrderByUID)
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	pprof.Register(router, "/debug/pprof")
+	limits := newRouteLimits(cfg.RateLimit, limiter)
+	registerAPIRoutes(router.Group(apiV1Prefix), orderHandler, healthHandler, authenticator, limits)
+	registerLegacyRoutes(router.Group("", Deprecated(apiV1Prefix)), orderHandler, authenticator, limits)
+
+	if broker != nil {
+		streamHandler := NewStreamHandler(broker, cfg.Stream.HeartbeatInterval, log)
+		streams := router.Group(apiV1Prefix, Auth(authenticator, auth.RoleReader), limits.middleware("read"))
+		streams.GET("/orders/stream", streamHandler.SSE)
+		streams.GET("/orders/stream/ws", streamHandler.WebSocket)
+	}
 
 	router.GET("/", func(c *gin.Context) {
 		c.HTML(http.StatusOK, "index.html", nil)
@@ -40,3 +68,57 @@ func SetupRouter(uc *usecase.OrderUsecase, log *slog.Logger) *gin.Engine {
 
 	return router
 }
+
+func registerAPIRoutes(group *gin.RouterGroup, orderHandler *OrderHandler, healthHandler *HealthHandler,
+	authenticator *auth.Authenticator, limits routeLimits) {
+	public := group.Group("", limits.middleware("public"))
+	public.GET("/health", orderHandler.HealthCheck)
+	public.GET("/health/live", healthHandler.Live)
+	public.GET("/health/ready", healthHandler.Ready)
+
+	reader := group.Group("", Auth(authenticator, auth.RoleReader))
+	reader.GET("/order/:order_uid", limits.middleware("read"), orderHandler.GetOrderByUID)
+	reader.POST("/orders/batch-get", limits.middleware("batch"), orderHandler.BatchGetOrders)
+
+	support := group.Group("", Auth(authenticator, auth.RoleSupport))
+	support.GET("/orders/export", limits.middleware("batch"), orderHandler.ExportOrders)
+	support.PATCH("/order/:order_uid/items/:chrt_id/status", limits.middleware("admin"),
+		orderHandler.ChangeItemStatus)
+	support.POST("/order/:order_uid/cancel", limits.middleware("admin"), orderHandler.CancelOrder)
+	support.POST("/order/:order_uid/items/:chrt_id/refund", limits.middleware("admin"), orderHandler.RefundItem)
+
+	admin := group.Group("", Auth(authenticator, auth.RoleAdmin), limits.middleware("admin"))
+	admin.DELETE("/order/:order_uid", orderHandler.DeleteOrder)
+	admin.PUT("/order/:order_uid", orderHandler.UpdateOrder)
+	admin.GET("/order/:order_uid/audit", orderHandler.GetAuditLog)
+}
+
+// registerLegacyRoutes регистрирует старые пути без версии. Оставлены только пути, которые существовали
+// до /api/v1, новые маршруты доступны лишь под префиксом.
+func registerLegacyRoutes(group *gin.RouterGroup, orderHandler *OrderHandler, authenticator *auth.Authenticator,
+	limits routeLimits) {
+	group.GET("/health", limits.middleware("public"), orderHandler.HealthCheck)
+	group.GET("/order/:order_uid", Auth(authenticator, auth.RoleReader), limits.middleware("read"),
+		orderHandler.GetOrderByUID)
+}
+
+// routeLimits - лимиты запросов по группам маршрутов
+type routeLimits struct {
+	store ratelimit.Store
+	rules map[string]configs.RateLimitRule
+}
+
+func newRouteLimits(cfg configs.RateLimitConfig, store ratelimit.Store) routeLimits {
+	if !cfg.Enabled {
+		store = nil
+	}
+	return routeLimits{store: store, rules: cfg.Groups}
+}
+
+func (l routeLimits) middleware(group string) gin.HandlerFunc {
+	rule, ok := l.rules[group]
+	if !ok {
+		return RateLimit(nil, group, ratelimit.Rule{})
+	}
+	return RateLimit(l.store, group, ratelimit.Rule{Rate: rule.Rate, Burst: rule.Burst})
+}
@@ -1,46 +1,79 @@
 package http
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+	"wb_l0/configs"
+	"wb_l0/internal/auth"
+	"wb_l0/internal/delivery/projection"
 	"wb_l0/internal/domain"
+	"wb_l0/internal/export"
 	"wb_l0/internal/usecase"
 	"wb_l0/pkg/prometheus"
 )
 
 type OrderHandler struct {
-	uc  *usecase.OrderUsecase
-	log *slog.Logger
+	uc        *usecase.OrderUsecase
+	cfg       configs.HttpConfig
+	projector *projection.Projector
+	log       *slog.Logger
 }
 
 type OrderStatus string
 
 const (
 	OrderStatusSuccess       OrderStatus = "success"
+	OrderStatusNotModified   OrderStatus = "not_modified"
 	OrderStatusBadRequest    OrderStatus = "bad_request"
 	OrderStatusInvalidUID    OrderStatus = "invalid_uid"
 	OrderStatusNotFound      OrderStatus = "not_found"
 	OrderStatusInternalError OrderStatus = "internal_error"
 )
 
-func NewOrderHandler(uc *usecase.OrderUsecase, logger *slog.Logger) *OrderHandler {
+type BatchGetRequest struct {
+	OrderUIDs []string `json:"order_uids" binding:"required"`
+}
+
+type ChangeItemStatusRequest struct {
+	Status int `json:"status" binding:"required"`
+}
+
+type BatchGetResponse struct {
+	Orders  []*domain.Order `json:"orders"`
+	Missing []string        `json:"missing"`
+}
+
+func NewOrderHandler(uc *usecase.OrderUsecase, cfg configs.HttpConfig, logger *slog.Logger) *OrderHandler {
 	return &OrderHandler{
-		uc:  uc,
-		log: logger,
+		uc:        uc,
+		cfg:       cfg,
+		projector: projection.New(cfg.Masking),
+		log:       logger,
 	}
 }
 
 // GetOrderByUID возвращает заказ по order_uid
 // @Summary Get order by UID
-// @Description Get order details by order_uid
+// @Description Get order details by order_uid. Phone, email, address and payment transaction are masked according to the caller's role.
 // @Tags orders
 // @Produce json
 // @Param order_uid path string true "Order UID"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Security BearerAuth
+// @Security APIKey
 // @Success 200 {object} domain.Order
+// @Success 304 "Not modified"
+// @Header 200 {string} ETag "Content hash of the order"
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /order/{order_uid} [get]
@@ -81,9 +114,9 @@ func (h *OrderHandler) GetOrderByUID(c *gin.Context) {
 		return
 	}
 
-	order, err := h.uc.GetOrder(c.Request.Context(), orderUID)
+	order, hash, err := h.uc.GetOrderWithHash(c.Request.Context(), orderUID)
 	if err != nil {
-		if err == domain.ErrRecordNotFound {
+		if errors.Is(err, domain.ErrRecordNotFound) {
 			status = OrderStatusNotFound
 			h.log.Error("Order not found", "orderUID", orderUID)
 			c.JSON(http.StatusNotFound, gin.H{
@@ -103,18 +136,330 @@ func (h *OrderHandler) GetOrderByUID(c *gin.Context) {
 		return
 	}
 
+	role := principalRole(c)
+	etag := h.etag(role, hash)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", h.cfg.CacheControl)
+	c.Header("Vary", "Authorization, X-API-Key")
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		status = OrderStatusNotModified
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	duration := time.Since(startTime)
 	h.log.Info("Order retrieved", "order_uid", orderUID, "duration", duration)
 
 	c.Header("X-Execution-Time-MS", fmt.Sprintf("%d", time.Since(startTime).Milliseconds()))
 	c.Header("X-Server-Timestamp", time.Now().Format(time.RFC3339))
 
-	c.JSON(http.StatusOK, order)
+	c.JSON(http.StatusOK, h.projector.Project(role, order))
+}
+
+// BatchGetOrders возвращает несколько заказов за один запрос
+// @Summary Batch get orders
+// @Description Get up to HTTP_BATCH_MAX_UIDS orders by order_uid. Orders that do not exist are listed in "missing".
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param request body BatchGetRequest true "Order UIDs"
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {object} BatchGetResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /orders/batch-get [post]
+func (h *OrderHandler) BatchGetOrders(c *gin.Context) {
+	startTime := time.Now()
+
+	var req BatchGetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error("Invalid batch request", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "body must be a JSON object with order_uids array",
+		})
+		return
+	}
+
+	if len(req.OrderUIDs) == 0 || len(req.OrderUIDs) > h.cfg.BatchMaxUIDs {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": fmt.Sprintf("order_uids must contain from 1 to %d elements", h.cfg.BatchMaxUIDs),
+		})
+		return
+	}
+
+	for _, orderUID := range req.OrderUIDs {
+		if len(orderUID) != 20 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "invalid_order_uid",
+				"message":   "order_uid must be 20 characters long",
+				"order_uid": orderUID,
+			})
+			return
+		}
+	}
+
+	orders, missing, err := h.uc.GetOrders(c.Request.Context(), req.OrderUIDs)
+	if err != nil {
+		h.log.Error("Failed to get orders", "error", err, "count", len(req.OrderUIDs))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "failed to retrieve orders",
+		})
+		return
+	}
+
+	h.log.Info("Batch request completed",
+		"requested", len(req.OrderUIDs),
+		"found", len(orders),
+		"missing", len(missing),
+		"duration_ms", time.Since(startTime).Milliseconds(),
+	)
+
+	c.Header("X-Execution-Time-MS", fmt.Sprintf("%d", time.Since(startTime).Milliseconds()))
+	c.Header("Vary", "Authorization, X-API-Key")
+	c.JSON(http.StatusOK, BatchGetResponse{
+		Orders:  h.projector.ProjectAll(principalRole(c), orders),
+		Missing: missing,
+	})
+}
+
+// ExportOrders выгружает заказы в CSV (строка на товар) или NDJSON (строка на заказ) потоком
+// @Summary Export orders
+// @Description Streams all orders matching the filter, newest first. CSV has one row per item with order columns repeated, NDJSON has one order per line. Personal data is masked according to the caller's role.
+// @Tags orders
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string true "Export format" Enums(csv, ndjson)
+// @Param customer_id query string false "Only orders of this customer"
+// @Param created_from query string false "Orders created at or after this time (RFC3339)"
+// @Param created_to query string false "Orders created before this time (RFC3339)"
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {string} string "Export file"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /orders/export [get]
+func (h *OrderHandler) ExportOrders(c *gin.Context) {
+	startTime := time.Now()
+
+	format := c.Query("format")
+	writer, err := export.NewWriter(format, c.Writer)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Выгрузка может идти дольше WriteTimeout сервера
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="orders-%s.%s"`,
+		startTime.UTC().Format("20060102-150405"), format))
+	c.Status(http.StatusOK)
+
+	role := principalRole(c)
+	count, err := h.uc.ExportOrders(c.Request.Context(), filter, func(order *domain.Order) error {
+		return writer.Write(h.projector.Project(role, order))
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		// Заголовки уже отправлены, клиент получит оборванный файл
+		h.log.Error("Order export failed", "error", err, "format", format, "exported", count)
+		c.Abort()
+		return
+	}
+
+	h.log.Info("Order export completed",
+		"format", format,
+		"count", count,
+		"duration_ms", time.Since(startTime).Milliseconds(),
+	)
+}
+
+// parseOrderFilter читает фильтр списка заказов из параметров customer_id, created_from и created_to
+func parseOrderFilter(c *gin.Context) (domain.OrderFilter, error) {
+	filter := domain.OrderFilter{CustomerID: c.Query("customer_id")}
+	for param, target := range map[string]*time.Time{
+		"created_from": &filter.CreatedFrom,
+		"created_to":   &filter.CreatedTo,
+	} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return domain.OrderFilter{}, fmt.Errorf("%s must be an RFC3339 timestamp", param)
+		}
+		*target = parsed
+	}
+	return filter, nil
+}
+
+// DeleteOrder удаляет заказ по order_uid из базы и кэша
+// @Summary Delete order by UID
+// @Description Delete order and invalidate its cache entry. Requires admin token.
+// @Tags orders
+// @Produce json
+// @Security BearerAuth
+// @Security APIKey
+// @Param order_uid path string true "Order UID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /order/{order_uid} [delete]
+func (h *OrderHandler) DeleteOrder(c *gin.Context) {
+	orderUID := c.Param("order_uid")
+
+	if len(orderUID) != 20 {
+		h.log.Error("Order_uid is invalid", "orderUID", orderUID)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_order_uid",
+			"message": "order_uid must be 20 characters long",
+		})
+		return
+	}
+
+	if err := h.uc.DeleteOrder(c.Request.Context(), orderUID); err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			h.log.Warn("Order to delete not found", "orderUID", orderUID)
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":     "not_found",
+				"message":   "order not found",
+				"order_uid": orderUID,
+			})
+			return
+		}
+
+		h.log.Error("Failed to delete order", "error", err, "orderUID", orderUID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "failed to delete order",
+		})
+		return
+	}
+
+	h.log.Info("Order deleted", "order_uid", orderUID)
+	c.Status(http.StatusNoContent)
+}
+
+// ChangeItemStatus меняет статус позиции заказа
+// @Summary Change item status
+// @Description Move an order item to a new status. Allowed transitions: 200 -> 202 -> 300, any status -> 400. Every change is recorded in the order timeline.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param order_uid path string true "Order UID"
+// @Param chrt_id path int true "Item chrt_id"
+// @Param request body ChangeItemStatusRequest true "New status"
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {object} domain.ItemStatusChange
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /order/{order_uid}/items/{chrt_id}/status [patch]
+func (h *OrderHandler) ChangeItemStatus(c *gin.Context) {
+	orderUID, chrtID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+	var req ChangeItemStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "body must be a JSON object with status",
+		})
+		return
+	}
+
+	change, err := h.uc.ChangeItemStatus(c.Request.Context(), orderUID, chrtID, req.Status, principalActor(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":     "not_found",
+				"message":   "order item not found",
+				"order_uid": orderUID,
+			})
+		case errors.Is(err, domain.ErrInvalidTransition):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "invalid_transition",
+				"message": err.Error(),
+			})
+		case errors.Is(err, domain.ErrConflict):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "conflict",
+				"message": "item status was changed concurrently, retry the request",
+			})
+		default:
+			h.log.Error("Failed to change item status", "error", err, "orderUID", orderUID, "chrt_id", chrtID)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "internal_error",
+				"message": "failed to change item status",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+// itemParams читает order_uid и chrt_id из пути, при ошибке отвечает 400
+func itemParams(c *gin.Context) (string, int, bool) {
+	orderUID := c.Param("order_uid")
+	if len(orderUID) != 20 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_order_uid",
+			"message": "order_uid must be 20 characters long",
+		})
+		return "", 0, false
+	}
+	chrtID, err := strconv.Atoi(c.Param("chrt_id"))
+	if err != nil || chrtID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "chrt_id must be a positive integer",
+		})
+		return "", 0, false
+	}
+	return orderUID, chrtID, true
 }
 
 // HealthCheck endpoint
 // @Summary Health check
-// @Description Check if service is healthy
+// @Description Always returns ok. Use /health/live and /health/ready to check the service and its dependencies.
 // @Tags health
 // @Produce json
 // @Success 200 {object} map[string]string
@@ -126,3 +471,26 @@ func (h *OrderHandler) HealthCheck(c *gin.Context) {
 		"service":   "order-api",
 	})
 }
+
+// etag возвращает ETag заказа для роли. Представления заказа для разных ролей различаются,
+// поэтому различаются и их ETag.
+func (h *OrderHandler) etag(role auth.Role, hash string) string {
+	if h.projector.Masks(role) {
+		return `"` + hash + "." + string(role) + `"`
+	}
+	return `"` + hash + `"`
+}
+
+// etagMatches проверяет заголовок If-None-Match (список значений, W/ и "*")
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"wb_l0/configs"
+	"wb_l0/internal/auth"
+	"wb_l0/internal/domain"
+	"wb_l0/internal/usecase"
+	"wb_l0/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// orderStore отдаёт один заказ на любые чтения
+type orderStore struct {
+	order *domain.Order
+}
+
+func (s *orderStore) SaveOrder(context.Context, *domain.Order) error {
+	return nil
+}
+
+func (s *orderStore) GetOrderByUID(_ context.Context, orderUID string) (*domain.Order, error) {
+	if orderUID != s.order.OrderUID {
+		return nil, domain.ErrRecordNotFound
+	}
+	return s.order, nil
+}
+
+func (s *orderStore) GetOrdersByUIDs(context.Context, []string) ([]*domain.Order, error) {
+	return []*domain.Order{s.order}, nil
+}
+
+func (s *orderStore) DeleteOrder(context.Context, string) error {
+	return nil
+}
+
+func (s *orderStore) ListOrders(_ context.Context, filter domain.OrderFilter) ([]*domain.Order, error) {
+	if filter.After != nil {
+		return nil, nil
+	}
+	return []*domain.Order{s.order}, nil
+}
+
+func (s *orderStore) UpdateItemStatus(context.Context, *domain.ItemStatusChange) error {
+	return nil
+}
+
+func (s *orderStore) SaveRefund(context.Context, *domain.Refund) error {
+	return nil
+}
+
+func (s *orderStore) UpdateOrder(context.Context, *domain.Order, int) error {
+	return nil
+}
+
+func (s *orderStore) GetAuditLog(context.Context, string) ([]domain.AuditEntry, error) {
+	return nil, nil
+}
+
+func TestOrderHandler_Masking(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	order := domain.CreateTestOrder(1)
+	order.OrderUID = "b563feb7b2b84b6test1"
+	order.Delivery.Phone = "+79720000000"
+	order.Delivery.Email = "test@gmail.com"
+	order.Delivery.Address = "Ploshad Mira 15"
+	order.Payment.Transaction = "b563feb7b2b84b6test1"
+
+	authenticator, err := auth.NewAuthenticator(configs.AuthConfig{
+		APIKeys: []configs.APIKey{
+			{Key: "reader-key", Role: "reader"},
+			{Key: "support-key", Role: "support"},
+		},
+	})
+	require.NoError(t, err)
+	cfg := configs.HttpConfig{
+		BatchMaxUIDs: 10,
+		Masking: map[string]map[string]string{
+			"reader":  {"phone": "full", "email": "full", "address": "full", "transaction": "full"},
+			"support": {"phone": "partial", "email": "partial", "address": "partial", "transaction": "partial"},
+		},
+	}
+	uc := usecase.NewOrderUsecase(&orderStore{order: &order}, usecase.RetryPolicy{MaxAttempts: 1},
+		logger.NewTestLogger())
+	router := gin.New()
+	registerAPIRoutes(router.Group(apiV1Prefix), NewOrderHandler(uc, cfg, logger.NewTestLogger()),
+		NewHealthHandler(nil), authenticator, newRouteLimits(configs.RateLimitConfig{}, nil))
+
+	request := func(method, path, key, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-API-Key", key)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	// Частичное маскирование оставляет последние цифры телефона, первую букву и домен email,
+	// первое слово адреса и последние 4 символа транзакции
+	assertPartial := func(t *testing.T, got *domain.Order) {
+		t.Helper()
+		assert.Equal(t, "+*******0000", got.Delivery.Phone)
+		assert.Equal(t, "t***@gmail.com", got.Delivery.Email)
+		assert.Equal(t, "Ploshad ***", got.Delivery.Address)
+		assert.Equal(t, "****************est1", got.Payment.Transaction)
+		assert.Equal(t, order.Delivery.Name, got.Delivery.Name)
+	}
+
+	t.Run("single order", func(t *testing.T) {
+		w := request(http.MethodGet, "/api/v1/order/"+order.OrderUID, "support-key", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var got domain.Order
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assertPartial(t, &got)
+		assert.Equal(t, "+79720000000", order.Delivery.Phone, "stored order must not change")
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		w := request(http.MethodPost, "/api/v1/orders/batch-get", "reader-key",
+			`{"order_uids": ["`+order.OrderUID+`"]}`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var got BatchGetResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		require.Len(t, got.Orders, 1)
+		assert.Equal(t, "***", got.Orders[0].Delivery.Phone)
+		assert.Equal(t, "***", got.Orders[0].Delivery.Email)
+		assert.Equal(t, "***", got.Orders[0].Delivery.Address)
+		assert.Equal(t, "***", got.Orders[0].Payment.Transaction)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		w := request(http.MethodGet, "/api/v1/orders/export?format=ndjson", "support-key", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var got domain.Order
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assertPartial(t, &got)
+	})
+}
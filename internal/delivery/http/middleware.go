@@ -0,0 +1,140 @@
+package http
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"wb_l0/internal/auth"
+	"wb_l0/internal/domain"
+	"wb_l0/internal/ratelimit"
+	"wb_l0/pkg/prometheus"
+
+	"github.com/gin-gonic/gin"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// Auth пропускает только клиентов с ролью не ниже required.
+// Учётные данные берутся из "Authorization: Bearer <jwt>" или X-API-Key.
+// nil authenticator означает выключенную аутентификацию: клиент получает роль admin.
+func Auth(authenticator *auth.Authenticator, required auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := &auth.Principal{Subject: "anonymous", Role: auth.RoleAdmin}
+		if authenticator != nil {
+			var err error
+			principal, err = authenticator.Authenticate(credential(c.Request))
+			if err == nil {
+				err = auth.Authorize(principal, required)
+			}
+			if err != nil {
+				abortAuth(c, err)
+				return
+			}
+		}
+
+		c.Set(principalContextKey, principal)
+		ctx := auth.WithPrincipal(c.Request.Context(), principal)
+		c.Request = c.Request.WithContext(domain.WithActor(ctx, "http:"+principal.Subject))
+		c.Next()
+	}
+}
+
+const principalContextKey = "principal"
+
+// principalRole возвращает роль клиента, сохранённую Auth
+func principalRole(c *gin.Context) auth.Role {
+	if value, ok := c.Get(principalContextKey); ok {
+		if principal, ok := value.(*auth.Principal); ok {
+			return principal.Role
+		}
+	}
+	return ""
+}
+
+// principalActor возвращает инициатора изменений для истории: "http:<subject>"
+func principalActor(c *gin.Context) string {
+	if value, ok := c.Get(principalContextKey); ok {
+		if principal, ok := value.(*auth.Principal); ok {
+			return "http:" + principal.Subject
+		}
+	}
+	return "http:anonymous"
+}
+
+// credential возвращает учётные данные и заголовок, из которого они взяты: Bearer важнее X-API-Key
+func credential(r *http.Request) (auth.CredentialKind, string) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return auth.CredentialBearer, strings.TrimSpace(token)
+	}
+	return auth.CredentialAPIKey, r.Header.Get(apiKeyHeader)
+}
+
+func abortAuth(c *gin.Context, err error) {
+	if errors.Is(err, auth.ErrForbidden) {
+		prometheus.HttpAuthFailuresTotal.WithLabelValues("forbidden").Inc()
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": err.Error(),
+		})
+		return
+	}
+	prometheus.HttpAuthFailuresTotal.WithLabelValues("unauthenticated").Inc()
+	c.Header("WWW-Authenticate", `Bearer realm="wb-order-saver"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error":   "unauthorized",
+		"message": "valid api key or bearer token is required",
+	})
+}
+
+// RateLimit ограничивает частоту запросов группы маршрутов по API-ключу клиента или, без него, по IP.
+// Должен стоять после Auth. При недоступности хранилища запросы пропускаются.
+func RateLimit(store ratelimit.Store, group string, rule ratelimit.Rule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store == nil {
+			c.Next()
+			return
+		}
+
+		result, err := store.Allow(c.Request.Context(), group+":"+clientKey(c), rule)
+		if err != nil {
+			prometheus.HttpRateLimitErrorsTotal.WithLabelValues(group).Inc()
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(rule.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		if !result.Allowed {
+			prometheus.HttpRateLimitedTotal.WithLabelValues(group).Inc()
+			c.Header("Retry-After", strconv.Itoa(max(1, int(math.Ceil(result.RetryAfter.Seconds())))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":   "rate_limited",
+				"message": "too many requests",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// clientKey - ключ корзины: учётные данные клиента или IP для анонимных запросов.
+// Subject не подходит: у безымянных API-ключей одной роли он общий.
+func clientKey(c *gin.Context) string {
+	if value, ok := c.Get(principalContextKey); ok {
+		if principal, ok := value.(*auth.Principal); ok && principal.Method != "" {
+			return principal.Method + ":" + principal.KeyID
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// Deprecated помечает ответ заголовками Deprecation и Link на тот же путь под successorPrefix
+func Deprecated(successorPrefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+successorPrefix+c.Request.URL.Path+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
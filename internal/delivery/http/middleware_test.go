@@ -0,0 +1,100 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wb_l0/configs"
+	"wb_l0/internal/auth"
+	"wb_l0/internal/ratelimit"
+	"wb_l0/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/limited", RateLimit(ratelimit.NewMemoryStore(), "read", ratelimit.Rule{Rate: 0.5, Burst: 2}),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.RemoteAddr = ip + ":1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, request("10.0.0.1").Code)
+
+	throttled := request("10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, throttled.Code)
+	assert.Equal(t, "2", throttled.Header().Get("Retry-After"))
+	assert.Equal(t, "0", throttled.Header().Get("X-RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.2").Code, "other clients are not affected")
+}
+
+func TestRateLimit_KeyedByCredential(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Безымянные ключи одной роли получают одинаковый Subject
+	router.GET("/limited", func(c *gin.Context) {
+		c.Set(principalContextKey, &auth.Principal{Subject: "reader", Role: auth.RoleReader,
+			Method: auth.MethodAPIKey, KeyID: c.GetHeader("X-Key-ID")})
+	}, RateLimit(ratelimit.NewMemoryStore(), "read", ratelimit.Rule{Rate: 0.5, Burst: 1}),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(keyID string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.Header.Set("X-Key-ID", keyID)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("first"))
+	assert.Equal(t, http.StatusTooManyRequests, request("first"))
+	assert.Equal(t, http.StatusOK, request("second"), "other keys of the same role are not affected")
+}
+
+func TestLegacyRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator, err := auth.NewAuthenticator(configs.AuthConfig{
+		APIKeys: []configs.APIKey{{Key: "reader-key", Role: "reader"}},
+	})
+	require.NoError(t, err)
+	router := gin.New()
+	orderHandler := NewOrderHandler(nil, configs.HttpConfig{}, logger.NewTestLogger())
+	limits := newRouteLimits(configs.RateLimitConfig{}, nil)
+	registerAPIRoutes(router.Group(apiV1Prefix), orderHandler, NewHealthHandler(nil), authenticator, limits)
+	registerLegacyRoutes(router.Group("", Deprecated(apiV1Prefix)), orderHandler, authenticator, limits)
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	legacy := request(http.MethodGet, "/health")
+	assert.Equal(t, http.StatusOK, legacy.Code)
+	assert.Equal(t, "true", legacy.Header().Get("Deprecation"))
+	assert.Equal(t, `</api/v1/health>; rel="successor-version"`, legacy.Header().Get("Link"))
+
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "/order/b563feb7b2b84b6test").Code,
+		"legacy order path is still served")
+
+	current := request(http.MethodGet, "/api/v1/health")
+	assert.Equal(t, http.StatusOK, current.Code)
+	assert.Empty(t, current.Header().Get("Deprecation"))
+
+	// Маршруты, появившиеся вместе с /api/v1, без версии не регистрируются
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPost, "/orders/batch-get").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/orders/export").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/health/ready").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/order/b563feb7b2b84b6test/audit").Code)
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"wb_l0/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RefundRequest struct {
+	Reason string `json:"reason" binding:"required"`
+	// Amount - сумма возврата, 0 - весь не возвращённый остаток
+	Amount int `json:"amount"`
+	// RequestID - ключ идемпотентности, повтор с тем же ключом отвечает 409
+	RequestID string `json:"request_id"`
+}
+
+// CancelOrder отменяет заказ с возвратом оплаты
+// @Summary Cancel order
+// @Description Cancel the order and refund the payment. Without amount the whole amount not yet refunded is returned.
+// @Tags refunds
+// @Accept json
+// @Produce json
+// @Param order_uid path string true "Order UID"
+// @Param request body RefundRequest true "Reason and optional amount"
+// @Security BearerAuth
+// @Security APIKey
+// @Success 201 {object} domain.Refund
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /order/{order_uid}/cancel [post]
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	orderUID := c.Param("order_uid")
+	if len(orderUID) != 20 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_order_uid",
+			"message": "order_uid must be 20 characters long",
+		})
+		return
+	}
+	h.refund(c, orderUID, 0)
+}
+
+// RefundItem возвращает оплату за позицию заказа
+// @Summary Refund order item
+// @Description Refund an order item. Without amount the item price not yet refunded is returned. Refunds never exceed the item price or the payment amount.
+// @Tags refunds
+// @Accept json
+// @Produce json
+// @Param order_uid path string true "Order UID"
+// @Param chrt_id path int true "Item chrt_id"
+// @Param request body RefundRequest true "Reason and optional amount"
+// @Security BearerAuth
+// @Security APIKey
+// @Success 201 {object} domain.Refund
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /order/{order_uid}/items/{chrt_id}/refund [post]
+func (h *OrderHandler) RefundItem(c *gin.Context) {
+	orderUID, chrtID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+	h.refund(c, orderUID, chrtID)
+}
+
+func (h *OrderHandler) refund(c *gin.Context, orderUID string, chrtID int) {
+	var req RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "body must be a JSON object with reason",
+		})
+		return
+	}
+
+	refund, err := h.uc.Refund(c.Request.Context(), domain.Refund{
+		OrderUID:  orderUID,
+		ChrtID:    chrtID,
+		Amount:    req.Amount,
+		Reason:    req.Reason,
+		RequestID: req.RequestID,
+		Actor:     principalActor(c),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":     "not_found",
+				"message":   "order or item not found",
+				"order_uid": orderUID,
+			})
+		case errors.Is(err, domain.ErrValidation):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": err.Error(),
+			})
+		case errors.Is(err, domain.ErrRefundExceeded):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   "refund_exceeded",
+				"message": err.Error(),
+			})
+		case errors.Is(err, domain.ErrConflict):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "conflict",
+				"message": err.Error(),
+			})
+		default:
+			h.log.Error("Failed to create refund", "error", err, "orderUID", orderUID, "chrt_id", chrtID)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "internal_error",
+				"message": "failed to create refund",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, refund)
+}
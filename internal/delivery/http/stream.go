@@ -0,0 +1,192 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"wb_l0/internal/stream"
+	"wb_l0/pkg/prometheus"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const wsWriteTimeout = 5 * time.Second
+
+// StreamHandler отдаёт поток новых заказов через Server-Sent Events и WebSocket
+type StreamHandler struct {
+	broker    *stream.Broker
+	heartbeat time.Duration
+	upgrader  websocket.Upgrader
+	log       *slog.Logger
+}
+
+func NewStreamHandler(broker *stream.Broker, heartbeat time.Duration, log *slog.Logger) *StreamHandler {
+	return &StreamHandler{
+		broker:    broker,
+		heartbeat: heartbeat,
+		upgrader:  websocket.Upgrader{HandshakeTimeout: wsWriteTimeout},
+		log:       log,
+	}
+}
+
+// SSE передаёт сводки о новых заказах как Server-Sent Events
+// @Summary Stream new orders (SSE)
+// @Description Pushes a summary event "order" for every newly saved order. A ": ping" comment is sent as heartbeat. Slow clients are disconnected with an "error" event.
+// @Tags orders
+// @Produce text/event-stream
+// @Param customer_id query string false "Only orders of this customer"
+// @Param delivery_service query string false "Only orders of this delivery service"
+// @Param min_amount query int false "Only orders with payment amount not less than this"
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {object} stream.Summary
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /orders/stream [get]
+func (h *StreamHandler) SSE(c *gin.Context) {
+	sub, ok := h.subscribe(c, "sse")
+	if !ok {
+		return
+	}
+	defer h.unsubscribe(sub, "sse")
+
+	// WriteTimeout сервера рассчитан на обычные запросы и оборвал бы поток
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-sub.Done():
+			if errors.Is(sub.Err(), stream.ErrSlowSubscriber) {
+				c.Render(-1, sse.Event{Event: "error", Data: gin.H{"error": sub.Err().Error()}})
+				c.Writer.Flush()
+			}
+			return
+		case summary := <-sub.Events():
+			c.Render(-1, sse.Event{Id: summary.OrderUID, Event: "order", Data: summary})
+			c.Writer.Flush()
+		case <-ticker.C:
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// WebSocket передаёт сводки о новых заказах JSON-сообщениями, heartbeat - ping-фреймы
+// @Summary Stream new orders (WebSocket)
+// @Description Same as /orders/stream over WebSocket: every text message is an order summary. Slow clients are disconnected with close code 1013.
+// @Tags orders
+// @Param customer_id query string false "Only orders of this customer"
+// @Param delivery_service query string false "Only orders of this delivery service"
+// @Param min_amount query int false "Only orders with payment amount not less than this"
+// @Security BearerAuth
+// @Security APIKey
+// @Success 101 "Switching Protocols"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /orders/stream/ws [get]
+func (h *StreamHandler) WebSocket(c *gin.Context) {
+	sub, ok := h.subscribe(c, "websocket")
+	if !ok {
+		return
+	}
+	defer h.unsubscribe(sub, "websocket")
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.log.Warn("WebSocket upgrade failed", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// Сообщения клиента не ожидаются, чтение нужно для обработки pong и close
+	closed := make(chan struct{})
+	_ = conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-sub.Done():
+			code := websocket.CloseGoingAway
+			if errors.Is(sub.Err(), stream.ErrSlowSubscriber) {
+				code = websocket.CloseTryAgainLater
+			}
+			_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, sub.Err().Error()),
+				time.Now().Add(wsWriteTimeout))
+			return
+		case summary := <-sub.Events():
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteJSON(summary); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *StreamHandler) subscribe(c *gin.Context, transport string) (*stream.Subscription, bool) {
+	filter := stream.Filter{
+		CustomerID:      c.Query("customer_id"),
+		DeliveryService: c.Query("delivery_service"),
+	}
+	if value := c.Query("min_amount"); value != "" {
+		minAmount, err := strconv.Atoi(value)
+		if err != nil || minAmount < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_amount must be a non-negative integer"})
+			return nil, false
+		}
+		filter.MinAmount = minAmount
+	}
+
+	sub, err := h.broker.Subscribe(filter)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	prometheus.StreamSubscribers.WithLabelValues(transport).Inc()
+	return sub, true
+}
+
+func (h *StreamHandler) unsubscribe(sub *stream.Subscription, transport string) {
+	prometheus.StreamSubscribers.WithLabelValues(transport).Dec()
+	h.broker.Unsubscribe(sub)
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"wb_l0/configs"
+	"wb_l0/internal/auth"
+	"wb_l0/internal/stream"
+	"wb_l0/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStreamServer(t *testing.T, broker *stream.Broker) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	authenticator, err := auth.NewAuthenticator(configs.AuthConfig{
+		APIKeys: []configs.APIKey{{Key: "reader-key", Role: "reader", Name: "wall"}},
+	})
+	require.NoError(t, err)
+
+	handler := NewStreamHandler(broker, 50*time.Millisecond, logger.NewTestLogger())
+	router := gin.New()
+	group := router.Group("", Auth(authenticator, auth.RoleReader))
+	group.GET("/orders/stream", handler.SSE)
+	group.GET("/orders/stream/ws", handler.WebSocket)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// publishUntilReceived повторяет публикацию, пока подписчик не получит событие: подписка создаётся асинхронно
+func publishUntilReceived(broker *stream.Broker, received <-chan struct{}, summaries ...stream.Summary) {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		for _, summary := range summaries {
+			broker.Publish(summary)
+		}
+		select {
+		case <-received:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func TestStreamHandler_SSE(t *testing.T) {
+	broker := stream.NewBroker(16, 0)
+	server := newStreamServer(t, broker)
+
+	t.Run("requires authentication", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/orders/stream")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("rejects invalid filter", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/orders/stream?min_amount=-1", nil)
+		req.Header.Set("X-API-Key", "reader-key")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("streams filtered orders and heartbeats", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/orders/stream?customer_id=test", nil)
+		req.Header.Set("X-API-Key", "reader-key")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		received := make(chan struct{})
+		lines := make(chan string, 64)
+		go func() {
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				lines <- scanner.Text()
+			}
+			close(lines)
+		}()
+		go publishUntilReceived(broker, received,
+			stream.Summary{OrderUID: "other", CustomerID: "other"},
+			stream.Summary{OrderUID: "00000000000000000001", CustomerID: "test", Amount: 1817})
+
+		var gotOrder, gotPing bool
+		for line := range lines {
+			switch {
+			case strings.HasPrefix(line, "data:"):
+				assert.Contains(t, line, `"order_uid":"00000000000000000001"`)
+				if !gotOrder {
+					close(received)
+				}
+				gotOrder = true
+			case line == ": ping":
+				gotPing = true
+			}
+			if gotOrder && gotPing {
+				break
+			}
+		}
+		assert.True(t, gotOrder)
+		assert.True(t, gotPing)
+	})
+}
+
+func TestStreamHandler_WebSocket(t *testing.T) {
+	broker := stream.NewBroker(16, 0)
+	server := newStreamServer(t, broker)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/orders/stream/ws?min_amount=1000"
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"X-API-Key": []string{"reader-key"}})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	received := make(chan struct{})
+	go publishUntilReceived(broker, received,
+		stream.Summary{OrderUID: "cheap", Amount: 10},
+		stream.Summary{OrderUID: "00000000000000000002", Amount: 1817})
+
+	var summary stream.Summary
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	require.NoError(t, conn.ReadJSON(&summary))
+	close(received)
+	assert.Equal(t, "00000000000000000002", summary.OrderUID)
+
+	broker.Close()
+	for {
+		if _, _, err = conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"wb_l0/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateOrder заменяет заказ новым содержимым с проверкой версии
+// @Summary Update order
+// @Description Replace the order with a new payload. The update is applied only if the order was not changed since it was read: pass its ETag in If-Match or its version in the body "version" field. History, cancellation and refunds are kept, statuses of existing items are not changed. Requires admin token.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKey
+// @Param order_uid path string true "Order UID"
+// @Param If-Match header string false "ETag from a previous response"
+// @Param request body domain.Order true "New order payload"
+// @Success 200 {object} domain.Order
+// @Header 200 {string} ETag "Content hash of the updated order"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 428 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /order/{order_uid} [put]
+func (h *OrderHandler) UpdateOrder(c *gin.Context) {
+	orderUID := c.Param("order_uid")
+	if len(orderUID) != 20 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_order_uid",
+			"message": "order_uid must be 20 characters long",
+		})
+		return
+	}
+	var order domain.Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "body must be a JSON order",
+		})
+		return
+	}
+	if order.OrderUID != "" && order.OrderUID != orderUID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "order_uid in body does not match the path",
+		})
+		return
+	}
+	order.OrderUID = orderUID
+
+	role := principalRole(c)
+	ifMatch := c.GetHeader("If-Match")
+	expectedVersion := order.Version
+	switch {
+	case ifMatch != "":
+		current, hash, err := h.uc.GetOrderWithHash(c.Request.Context(), orderUID)
+		if err != nil {
+			h.updateError(c, orderUID, err, false)
+			return
+		}
+		if !etagMatches(ifMatch, h.etag(role, hash)) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{
+				"error":   "precondition_failed",
+				"message": "order was changed, read it again and retry",
+			})
+			return
+		}
+		expectedVersion = current.Version
+	case expectedVersion == 0:
+		c.JSON(http.StatusPreconditionRequired, gin.H{
+			"error":   "precondition_required",
+			"message": "If-Match header or version field is required",
+		})
+		return
+	}
+
+	if _, err := h.uc.UpdateOrder(c.Request.Context(), order, expectedVersion); err != nil {
+		h.updateError(c, orderUID, err, ifMatch != "")
+		return
+	}
+
+	updated, hash, err := h.uc.GetOrderWithHash(c.Request.Context(), orderUID)
+	if err != nil {
+		h.updateError(c, orderUID, err, false)
+		return
+	}
+	c.Header("ETag", h.etag(role, hash))
+	c.JSON(http.StatusOK, h.projector.Project(role, updated))
+}
+
+// updateError отвечает на ошибку обновления. Конфликт версий при If-Match - это 412, иначе 409.
+func (h *OrderHandler) updateError(c *gin.Context, orderUID string, err error, ifMatch bool) {
+	switch {
+	case errors.Is(err, domain.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":     "not_found",
+			"message":   "order not found",
+			"order_uid": orderUID,
+		})
+	case errors.Is(err, domain.ErrValidation):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+	case errors.Is(err, domain.ErrRefundExceeded):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "refund_exceeded",
+			"message": err.Error(),
+		})
+	case errors.Is(err, domain.ErrConflict) && ifMatch:
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"error":   "precondition_failed",
+			"message": "order was changed, read it again and retry",
+		})
+	case errors.Is(err, domain.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "version_conflict",
+			"message": err.Error(),
+		})
+	default:
+		h.log.Error("Failed to update order", "error", err, "orderUID", orderUID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "failed to update order",
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"wb_l0/internal/domain"
+	"wb_l0/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+// WebhookHandler - административное API подписок на вебхуки
+type WebhookHandler struct {
+	repo webhook.Repository
+	log  *slog.Logger
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required"`
+	// Secret - ключ HMAC, генерируется, если не задан
+	Secret     string   `json:"secret"`
+	Events     []string `json:"events"`
+	CustomerID string   `json:"customer_id"`
+}
+
+// CreatedWebhook - созданная подписка. Секрет возвращается только при создании.
+type CreatedWebhook struct {
+	webhook.Subscription
+	Secret string `json:"secret"`
+}
+
+// Create создаёт подписку
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "body must be a JSON object with url",
+		})
+		return
+	}
+
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "url must be an absolute http or https URL",
+		})
+		return
+	}
+	if len(req.Events) == 0 {
+		req.Events = []string{webhook.EventOrderCreated}
+	}
+	for _, event := range req.Events {
+		if !slices.Contains(webhook.Events, event) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": "unknown event " + strconv.Quote(event),
+			})
+			return
+		}
+	}
+	if req.Secret == "" {
+		secret := make([]byte, 32)
+		_, _ = rand.Read(secret)
+		req.Secret = hex.EncodeToString(secret)
+	}
+
+	sub := webhook.Subscription{
+		URL:        req.URL,
+		Secret:     req.Secret,
+		Events:     req.Events,
+		CustomerID: req.CustomerID,
+		Active:     true,
+	}
+	if err := h.repo.CreateSubscription(c.Request.Context(), &sub); err != nil {
+		h.log.Error("Failed to create webhook subscription", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "failed to create subscription",
+		})
+		return
+	}
+
+	h.log.Info("Webhook subscription created", "subscription_id", sub.ID, "url", sub.URL, "events", sub.Events)
+	c.JSON(http.StatusCreated, CreatedWebhook{Subscription: sub, Secret: sub.Secret})
+}
+
+// List возвращает все подписки без секретов
+func (h *WebhookHandler) List(c *gin.Context) {
+	subscriptions, err := h.repo.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		h.log.Error("Failed to list webhook subscriptions", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "failed to list subscriptions",
+		})
+		return
+	}
+	if subscriptions == nil {
+		subscriptions = []webhook.Subscription{}
+	}
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subscriptions})
+}
+
+// Delete удаляет подписку вместе с журналом доставок
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, ok := subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteSubscription(c.Request.Context(), id); err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "subscription not found",
+			})
+			return
+		}
+		h.log.Error("Failed to delete webhook subscription", "error", err, "subscription_id", id)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "failed to delete subscription",
+		})
+		return
+	}
+
+	h.log.Info("Webhook subscription deleted", "subscription_id", id)
+	c.Status(http.StatusNoContent)
+}
+
+// Deliveries возвращает последние доставки подписки: статус, число попыток и результат последней попытки
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	id, ok := subscriptionID(c)
+	if !ok {
+		return
+	}
+	limit := defaultDeliveriesLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxDeliveriesLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": "limit must be from 1 to " + strconv.Itoa(maxDeliveriesLimit),
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.repo.ListDeliveries(c.Request.Context(), id, limit)
+	if err != nil {
+		h.log.Error("Failed to list webhook deliveries", "error", err, "subscription_id", id)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "failed to list deliveries",
+		})
+		return
+	}
+	if deliveries == nil {
+		deliveries = []webhook.Delivery{}
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+func subscriptionID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "subscription id must be a positive integer",
+		})
+		return 0, false
+	}
+	return id, true
+}
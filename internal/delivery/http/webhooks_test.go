@@ -0,0 +1,111 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"wb_l0/configs"
+	"wb_l0/internal/auth"
+	"wb_l0/internal/domain"
+	"wb_l0/internal/webhook"
+	"wb_l0/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWebhookRepo struct {
+	webhook.Repository
+	subscriptions []webhook.Subscription
+	deliveries    []webhook.Delivery
+	limit         int
+}
+
+func (r *fakeWebhookRepo) CreateSubscription(_ context.Context, sub *webhook.Subscription) error {
+	sub.ID = int64(len(r.subscriptions) + 1)
+	sub.CreatedAt = time.Now()
+	r.subscriptions = append(r.subscriptions, *sub)
+	return nil
+}
+
+func (r *fakeWebhookRepo) ListSubscriptions(context.Context) ([]webhook.Subscription, error) {
+	return r.subscriptions, nil
+}
+
+func (r *fakeWebhookRepo) DeleteSubscription(_ context.Context, id int64) error {
+	if id > int64(len(r.subscriptions)) {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *fakeWebhookRepo) ListDeliveries(_ context.Context, _ int64, limit int) ([]webhook.Delivery, error) {
+	r.limit = limit
+	return r.deliveries, nil
+}
+
+func TestWebhookHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator, err := auth.NewAuthenticator(configs.AuthConfig{
+		APIKeys: []configs.APIKey{{Key: "admin-key", Role: "admin", Name: "ops"}},
+	})
+	require.NoError(t, err)
+	repo := &fakeWebhookRepo{
+		deliveries: []webhook.Delivery{{ID: 3, SubscriptionID: 1, Status: webhook.StatusFailed, Attempts: 8,
+			LastStatusCode: 502, LastError: "unexpected status 502", Payload: []byte(`{}`)}},
+	}
+	router := SetupAdminRouter(configs.AdminConfig{AuthEnabled: true}, authenticator, new(slog.LevelVar), repo,
+		logger.NewTestLogger())
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "admin-key")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("create generates secret and returns it once", func(t *testing.T) {
+		w := request(http.MethodPost, "/admin/webhooks", `{"url":"https://partner.example/hook"}`)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		var created map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Len(t, created["secret"], 64)
+		assert.Equal(t, []any{webhook.EventOrderCreated}, created["events"])
+
+		w = request(http.MethodGet, "/admin/webhooks", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "https://partner.example/hook")
+		assert.NotContains(t, w.Body.String(), "secret")
+	})
+
+	t.Run("create rejects invalid input", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest,
+			request(http.MethodPost, "/admin/webhooks", `{"url":"ftp://partner.example"}`).Code)
+		assert.Equal(t, http.StatusBadRequest,
+			request(http.MethodPost, "/admin/webhooks", `{"url":"https://partner.example","events":["order.deleted"]}`).Code)
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/admin/webhooks", `{}`).Code)
+	})
+
+	t.Run("deliveries", func(t *testing.T) {
+		w := request(http.MethodGet, "/admin/webhooks/1/deliveries?limit=10", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 10, repo.limit)
+		assert.Contains(t, w.Body.String(), `"last_error":"unexpected status 502"`)
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodGet, "/admin/webhooks/1/deliveries?limit=0", "").Code)
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodGet, "/admin/webhooks/abc/deliveries", "").Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, "/admin/webhooks/1", "").Code)
+		assert.Equal(t, http.StatusNotFound, request(http.MethodDelete, "/admin/webhooks/42", "").Code)
+	})
+}
@@ -1,7 +1,10 @@
 package kafka
 
 import (
+	"errors"
 	"fmt"
+	"sync/atomic"
+	"time"
 	"wb_l0/configs"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
@@ -9,7 +12,8 @@ import (
 )
 
 const (
-	noTimeout = -1
+	// pollTimeout ограничивает ожидание сообщения, чтобы пустой топик не выглядел как зависший консьюмер
+	pollTimeout = time.Second
 )
 
 type Handler interface {
@@ -21,6 +25,8 @@ type Consumer struct {
 	handler        Handler
 	stop           bool
 	consumerNumber int
+	// lastPoll - unix nano последнего успешного обращения к брокеру
+	lastPoll atomic.Int64
 }
 
 func NewConsumer(cfg *configs.Config, handler Handler, consumerNumber int) (*Consumer,
@@ -55,8 +61,11 @@ func (c *Consumer) Start() {
 		if c.stop {
 			break
 		}
-		kafkaMsg, err := c.consumer.ReadMessage(noTimeout)
-		if err != nil {
+		kafkaMsg, err := c.consumer.ReadMessage(pollTimeout)
+		var kafkaErr kafka.Error
+		if err == nil || (errors.As(err, &kafkaErr) && kafkaErr.IsTimeout()) {
+			c.lastPoll.Store(time.Now().UnixNano())
+		} else {
 			logrus.Errorf("error reading message from kafka %v", err)
 		}
 		if kafkaMsg == nil {
@@ -73,6 +82,25 @@ func (c *Consumer) Start() {
 	}
 }
 
+// Health проверяет, что консьюмеру назначены партиции и он опрашивал брокер не позже maxPollAge назад
+func (c *Consumer) Health(maxPollAge time.Duration) error {
+	lastPoll := c.lastPoll.Load()
+	if lastPoll == 0 {
+		return fmt.Errorf("consumer has not polled yet")
+	}
+	if age := time.Since(time.Unix(0, lastPoll)); age > maxPollAge {
+		return fmt.Errorf("last successful poll was %s ago", age.Truncate(time.Second))
+	}
+	assignment, err := c.consumer.Assignment()
+	if err != nil {
+		return fmt.Errorf("failed to get assignment: %w", err)
+	}
+	if len(assignment) == 0 {
+		return fmt.Errorf("no partitions assigned")
+	}
+	return nil
+}
+
 func (c *Consumer) Stop() error {
 	c.stop = true
 	if _, err := c.consumer.Commit(); err != nil {
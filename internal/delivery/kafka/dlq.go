@@ -0,0 +1,48 @@
+package kafka
+
+import (
+	"strconv"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Заголовки сообщения в DLQ
+const (
+	DLQReasonHeader    = "dlq-reason"
+	DLQErrorHeader     = "dlq-error"
+	DLQTopicHeader     = "dlq-source-topic"
+	DLQPartitionHeader = "dlq-source-partition"
+	DLQOffsetHeader    = "dlq-source-offset"
+)
+
+// DeadLetterQueue публикует отклонённые сообщения в отдельный топик без изменений,
+// причина и исходная позиция передаются в заголовках
+type DeadLetterQueue struct {
+	producer *Producer
+	topic    string
+}
+
+func NewDeadLetterQueue(producer *Producer, topic string) *DeadLetterQueue {
+	return &DeadLetterQueue{producer: producer, topic: topic}
+}
+
+func (q *DeadLetterQueue) Send(message []byte, key, reason string, cause error, source kafka.TopicPartition) error {
+	headers := []kafka.Header{
+		{Key: DLQReasonHeader, Value: []byte(reason)},
+		{Key: DLQPartitionHeader, Value: []byte(strconv.Itoa(int(source.Partition)))},
+		{Key: DLQOffsetHeader, Value: []byte(source.Offset.String())},
+	}
+	if source.Topic != nil {
+		headers = append(headers, kafka.Header{Key: DLQTopicHeader, Value: []byte(*source.Topic)})
+	}
+	if cause != nil {
+		headers = append(headers, kafka.Header{Key: DLQErrorHeader, Value: []byte(cause.Error())})
+	}
+
+	return q.producer.send(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &q.topic, Partition: kafka.PartitionAny},
+		Key:            []byte(key),
+		Value:          message,
+		Headers:        headers,
+	})
+}
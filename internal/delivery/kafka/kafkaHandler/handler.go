@@ -3,6 +3,7 @@ package kafkaHandler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -13,14 +14,55 @@ import (
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 )
 
+// RefundEventType - тип сообщения с возвратом. Сообщения без event_type считаются заказами.
+const RefundEventType = "order.refund"
+
+// kafkaActor - инициатор изменений, пришедших из Kafka, в истории и журнале аудита
+const kafkaActor = "kafka"
+
+// RefundEvent - сообщение о возврате. Без chrt_id заказ отменяется, amount 0 - вернуть весь остаток.
+// request_id защищает от повторной обработки сообщения.
+type RefundEvent struct {
+	EventType string `json:"event_type"`
+	OrderUID  string `json:"order_uid"`
+	ChrtID    int    `json:"chrt_id"`
+	Amount    int    `json:"amount"`
+	Reason    string `json:"reason"`
+	RequestID string `json:"request_id"`
+}
+
+// DuplicatePolicy определяет, что делать с заказом из Kafka, order_uid которого уже сохранён с другими
+// данными. Повтор заказа с теми же данными всегда пропускается.
+type DuplicatePolicy string
+
+const (
+	// DuplicateSkip - пропустить заказ, политика по умолчанию
+	DuplicateSkip DuplicatePolicy = "skip"
+	// DuplicateReplace - заменить сохранённый заказ, если версия нового больше
+	DuplicateReplace DuplicatePolicy = "replace"
+	// DuplicateReject - отправить сообщение в DLQ
+	DuplicateReject DuplicatePolicy = "reject"
+)
+
+// DeadLetterSender публикует сообщение, отклонённое без повтора, с причиной отказа
+type DeadLetterSender interface {
+	Send(message []byte, key, reason string, cause error, source kafka.TopicPartition) error
+}
+
 type KafkaHandler struct {
 	orderUsecase *usecase.OrderUsecase
-	log          *slog.Logger
+	duplicates   DuplicatePolicy
+	// dlq может быть nil, тогда отклонённые сообщения только подтверждаются
+	dlq DeadLetterSender
+	log *slog.Logger
 }
 
-func NewKafkaHandler(orderUsecase *usecase.OrderUsecase, log *slog.Logger) *KafkaHandler {
+func NewKafkaHandler(orderUsecase *usecase.OrderUsecase, duplicates DuplicatePolicy, dlq DeadLetterSender,
+	log *slog.Logger) *KafkaHandler {
 	return &KafkaHandler{
 		orderUsecase,
+		duplicates,
+		dlq,
 		log,
 	}
 }
@@ -30,7 +72,9 @@ func (h *KafkaHandler) HandleMessage(message []byte, topic kafka.TopicPartition,
 
 	prometheus.KafkaWorkersBusy.Inc()
 	defer prometheus.KafkaWorkersBusy.Dec()
-	defer prometheus.KafkaProcessingDuration.WithLabelValues(*topic.Topic).Observe(time.Since(startTime).Seconds())
+	defer func() {
+		prometheus.KafkaProcessingDuration.WithLabelValues(*topic.Topic).Observe(time.Since(startTime).Seconds())
+	}()
 
 	h.log.Debug("Kafka message received",
 		"topic", topic.Topic,
@@ -42,6 +86,15 @@ func (h *KafkaHandler) HandleMessage(message []byte, topic kafka.TopicPartition,
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
+	ctx = domain.WithActor(ctx, kafkaActor)
+
+	var envelope struct {
+		EventType string `json:"event_type"`
+	}
+	if json.Unmarshal(message, &envelope) == nil && envelope.EventType == RefundEventType {
+		return h.handleRefund(ctx, message, topic, cn)
+	}
+
 	order, err := h.parseOrder(message)
 	if err != nil {
 		prometheus.KafkaErrorsTotal.WithLabelValues(*topic.Topic, "processing").Inc()
@@ -58,10 +111,16 @@ func (h *KafkaHandler) HandleMessage(message []byte, topic kafka.TopicPartition,
 	}
 	prometheus.KafkaMessagesProcessed.WithLabelValues(*topic.Topic, "success").Inc()
 
-	if err = h.orderUsecase.CreateOrder(ctx, order); err != nil {
+	err = h.orderUsecase.CreateOrder(ctx, order)
+	if errors.Is(err, domain.ErrContentConflict) {
+		err = h.handleDuplicate(ctx, order, err)
+	}
+	if err != nil {
+		errorType := classifyError(err)
+		prometheus.KafkaErrorsTotal.WithLabelValues(*topic.Topic, errorType).Inc()
 		h.log.Error("Failed to create order",
 			"order_uid", order.OrderUID,
-			"error_type", "transport",
+			"error_type", errorType,
 			"error", err,
 			"topic", topic.Topic,
 			"partition", topic.Partition,
@@ -69,6 +128,12 @@ func (h *KafkaHandler) HandleMessage(message []byte, topic kafka.TopicPartition,
 			"consumer", cn,
 			"message_size", len(message),
 		)
+		if errorType == "content_conflict" {
+			return h.deadLetter(message, order.OrderUID, errorType, err, topic)
+		}
+		if isPermanent(errorType) {
+			return nil
+		}
 		return err
 	}
 
@@ -81,6 +146,94 @@ func (h *KafkaHandler) HandleMessage(message []byte, topic kafka.TopicPartition,
 	return nil
 }
 
+// handleDuplicate применяет политику DuplicatePolicy к заказу, расходящемуся с сохранённым
+func (h *KafkaHandler) handleDuplicate(ctx context.Context, order domain.Order, err error) error {
+	switch h.duplicates {
+	case DuplicateReject:
+		return err
+	case DuplicateReplace:
+		// Версия читается из primary: устаревшая версия из кэша или реплики дала бы ErrConflict,
+		// и обновление было бы потеряно после подтверждения сообщения
+		stored, getErr := h.orderUsecase.GetOrder(domain.WithPrimaryRead(ctx), order.OrderUID)
+		if getErr != nil {
+			return fmt.Errorf("failed to get stored order: %w", getErr)
+		}
+		if order.Version > stored.Version {
+			_, err := h.orderUsecase.UpdateOrder(ctx, order, stored.Version)
+			return err
+		}
+		h.log.Warn("Duplicate order is not newer than stored, skipped",
+			"order_uid", order.OrderUID,
+			"version", order.Version,
+			"stored_version", stored.Version,
+		)
+		return nil
+	default:
+		h.log.Warn("Conflicting order skipped", "order_uid", order.OrderUID, "error", err)
+		return nil
+	}
+}
+
+func (h *KafkaHandler) handleRefund(ctx context.Context, message []byte, topic kafka.TopicPartition, cn int) error {
+	var event RefundEvent
+	if err := json.Unmarshal(message, &event); err != nil {
+		prometheus.KafkaMessagesProcessed.WithLabelValues(*topic.Topic, "error_parsing").Inc()
+		h.log.Error("Failed to parse refund event", "error", err, "topic", topic.Topic, "offset", topic.Offset)
+		return nil
+	}
+	prometheus.KafkaMessagesProcessed.WithLabelValues(*topic.Topic, "success").Inc()
+
+	refund, err := h.orderUsecase.Refund(ctx, domain.Refund{
+		OrderUID:  event.OrderUID,
+		ChrtID:    event.ChrtID,
+		Amount:    event.Amount,
+		Reason:    event.Reason,
+		RequestID: event.RequestID,
+		Actor:     kafkaActor,
+	})
+	if err != nil {
+		errorType := classifyError(err)
+		prometheus.KafkaErrorsTotal.WithLabelValues(*topic.Topic, errorType).Inc()
+		h.log.Error("Failed to process refund event",
+			"order_uid", event.OrderUID,
+			"chrt_id", event.ChrtID,
+			"request_id", event.RequestID,
+			"error_type", errorType,
+			"error", err,
+			"topic", topic.Topic,
+			"partition", topic.Partition,
+			"offset", topic.Offset,
+			"consumer", cn,
+		)
+		if isPermanent(errorType) {
+			return nil
+		}
+		return err
+	}
+
+	h.log.Info("Refund event processed",
+		"order_uid", refund.OrderUID,
+		"chrt_id", refund.ChrtID,
+		"amount", refund.Amount,
+	)
+	return nil
+}
+
+// deadLetter отправляет сообщение в DLQ. Если отправить не удалось, сообщение не подтверждается
+// и будет обработано повторно.
+func (h *KafkaHandler) deadLetter(message []byte, key, reason string, cause error, topic kafka.TopicPartition) error {
+	if h.dlq == nil {
+		return nil
+	}
+	if err := h.dlq.Send(message, key, reason, cause, topic); err != nil {
+		h.log.Error("Failed to send message to DLQ", "reason", reason, "offset", topic.Offset, "error", err)
+		return fmt.Errorf("failed to send message to DLQ: %w", err)
+	}
+	prometheus.KafkaDeadLetteredTotal.WithLabelValues(*topic.Topic, reason).Inc()
+	h.log.Warn("Message sent to DLQ", "order_uid", key, "reason", reason, "offset", topic.Offset)
+	return nil
+}
+
 func (h *KafkaHandler) parseOrder(message []byte) (domain.Order, error) {
 	var order domain.Order
 
@@ -90,3 +243,28 @@ func (h *KafkaHandler) parseOrder(message []byte) (domain.Order, error) {
 
 	return order, nil
 }
+
+func classifyError(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrValidation), errors.Is(err, domain.ErrRefundExceeded):
+		return "validation"
+	case errors.Is(err, domain.ErrRecordNotFound):
+		return "not_found"
+	case errors.Is(err, domain.ErrAlreadyExists):
+		return "duplicate"
+	case errors.Is(err, domain.ErrContentConflict):
+		return "content_conflict"
+	case errors.Is(err, domain.ErrConflict):
+		return "conflict"
+	case errors.Is(err, domain.ErrTransient):
+		return "transient"
+	default:
+		return "transport"
+	}
+}
+
+// isPermanent сообщает, что повтор сообщения с такой ошибкой не поможет и его можно подтвердить
+func isPermanent(errorType string) bool {
+	return errorType == "validation" || errorType == "conflict" || errorType == "not_found" ||
+		errorType == "duplicate" || errorType == "content_conflict"
+}
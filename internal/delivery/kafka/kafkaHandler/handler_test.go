@@ -0,0 +1,138 @@
+package kafkaHandler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+	"wb_l0/internal/domain"
+	"wb_l0/internal/usecase"
+	"wb_l0/pkg/logger"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conflictStore отвечает на SaveOrder расхождением с сохранённым заказом stored
+type conflictStore struct {
+	stored       domain.Order
+	primaryReads int
+	updated      []int
+}
+
+func (s *conflictStore) SaveOrder(_ context.Context, order *domain.Order) error {
+	return &domain.ContentConflictError{
+		OrderUID: order.OrderUID,
+		Diff:     map[string]domain.AuditChange{"payment.amount": {Before: 1817, After: 1900}},
+	}
+}
+
+func (s *conflictStore) GetOrderByUID(ctx context.Context, _ string) (*domain.Order, error) {
+	if domain.PrimaryReadFromContext(ctx) {
+		s.primaryReads++
+	}
+	stored := s.stored
+	return &stored, nil
+}
+
+func (s *conflictStore) GetOrdersByUIDs(context.Context, []string) ([]*domain.Order, error) {
+	return nil, nil
+}
+
+func (s *conflictStore) DeleteOrder(context.Context, string) error {
+	return nil
+}
+
+func (s *conflictStore) ListOrders(context.Context, domain.OrderFilter) ([]*domain.Order, error) {
+	return nil, nil
+}
+
+func (s *conflictStore) UpdateItemStatus(context.Context, *domain.ItemStatusChange) error {
+	return nil
+}
+
+func (s *conflictStore) SaveRefund(context.Context, *domain.Refund) error {
+	return nil
+}
+
+func (s *conflictStore) UpdateOrder(_ context.Context, _ *domain.Order, expectedVersion int) error {
+	s.updated = append(s.updated, expectedVersion)
+	return nil
+}
+
+func (s *conflictStore) GetAuditLog(context.Context, string) ([]domain.AuditEntry, error) {
+	return nil, nil
+}
+
+// recordingDLQ запоминает причины отправленных в DLQ сообщений
+type recordingDLQ struct {
+	reasons []string
+}
+
+func (d *recordingDLQ) Send(_ []byte, _, reason string, _ error, _ kafka.TopicPartition) error {
+	d.reasons = append(d.reasons, reason)
+	return nil
+}
+
+func TestKafkaHandler_DuplicatePolicy(t *testing.T) {
+	topic := "orders"
+	partition := kafka.TopicPartition{Topic: &topic}
+	retry := usecase.RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	message := func(t *testing.T, version int) []byte {
+		t.Helper()
+		order := domain.CreateTestOrder(1)
+		order.Version = version
+		data, err := json.Marshal(order)
+		require.NoError(t, err)
+		return data
+	}
+	newHandler := func(policy DuplicatePolicy) (*KafkaHandler, *conflictStore, *recordingDLQ) {
+		store := &conflictStore{stored: domain.CreateTestOrder(1)}
+		store.stored.Version = 2
+		dlq := &recordingDLQ{}
+		uc := usecase.NewOrderUsecase(store, retry, logger.NewTestLogger())
+		return NewKafkaHandler(uc, policy, dlq, logger.NewTestLogger()), store, dlq
+	}
+
+	t.Run("skip acks the message", func(t *testing.T) {
+		handler, store, dlq := newHandler(DuplicateSkip)
+
+		err := handler.HandleMessage(message(t, 5), partition, 0)
+
+		assert.NoError(t, err)
+		assert.Empty(t, store.updated)
+		assert.Empty(t, dlq.reasons)
+	})
+
+	t.Run("reject sends the message to DLQ", func(t *testing.T) {
+		handler, store, dlq := newHandler(DuplicateReject)
+
+		err := handler.HandleMessage(message(t, 5), partition, 0)
+
+		assert.NoError(t, err)
+		assert.Empty(t, store.updated)
+		assert.Equal(t, []string{"content_conflict"}, dlq.reasons)
+	})
+
+	t.Run("replace updates newer order using the primary version", func(t *testing.T) {
+		handler, store, dlq := newHandler(DuplicateReplace)
+
+		err := handler.HandleMessage(message(t, 5), partition, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{2}, store.updated)
+		assert.Equal(t, 1, store.primaryReads)
+		assert.Empty(t, dlq.reasons)
+	})
+
+	t.Run("replace skips older order", func(t *testing.T) {
+		handler, store, _ := newHandler(DuplicateReplace)
+
+		err := handler.HandleMessage(message(t, 1), partition, 0)
+
+		assert.NoError(t, err)
+		assert.Empty(t, store.updated)
+	})
+}
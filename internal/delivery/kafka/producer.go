@@ -35,6 +35,11 @@ func (p *Producer) Produce(message, topic, key string) error {
 		Value: []byte(message),
 		Key:   []byte(key),
 	}
+	return p.send(kafkaMsg)
+}
+
+// send отправляет сообщение и дожидается подтверждения брокера
+func (p *Producer) send(kafkaMsg *kafka.Message) error {
 	kafkaChan := make(chan kafka.Event)
 	err := p.producer.Produce(kafkaMsg, kafkaChan)
 	if err != nil {
@@ -0,0 +1,123 @@
+// Package projection - представление заказа для клиента с маскированием персональных данных по роли.
+// Пакет вынесен из delivery/http, потому что те же правила применяет gRPC-сервер.
+package projection
+
+import (
+	"strings"
+	"unicode"
+	"wb_l0/internal/auth"
+	"wb_l0/internal/domain"
+)
+
+const (
+	maskFull    = "full"
+	maskPartial = "partial"
+	maskSymbol  = "*"
+)
+
+// Projector формирует представление заказа для роли клиента, маскируя персональные данные
+type Projector struct {
+	rules map[auth.Role]map[string]string
+}
+
+// New создаёт Projector по правилам из конфигурации: роль -> поле -> режим.
+// Роли без правил видят заказ целиком.
+func New(rules map[string]map[string]string) *Projector {
+	p := &Projector{rules: make(map[auth.Role]map[string]string, len(rules))}
+	for role, fields := range rules {
+		if len(fields) > 0 {
+			p.rules[auth.Role(role)] = fields
+		}
+	}
+	return p
+}
+
+// Masks сообщает, изменяет ли проекция заказы для роли
+func (p *Projector) Masks(role auth.Role) bool {
+	return len(p.rules[role]) > 0
+}
+
+// Project возвращает копию заказа с замаскированными полями, исходный заказ не меняется
+func (p *Projector) Project(role auth.Role, order *domain.Order) *domain.Order {
+	rules := p.rules[role]
+	if order == nil || len(rules) == 0 {
+		return order
+	}
+
+	projected := *order
+	projected.Delivery.Phone = maskValue(rules["phone"], order.Delivery.Phone, maskPhone)
+	projected.Delivery.Email = maskValue(rules["email"], order.Delivery.Email, maskEmail)
+	projected.Delivery.Address = maskValue(rules["address"], order.Delivery.Address, maskAddress)
+	projected.Payment.Transaction = maskValue(rules["transaction"], order.Payment.Transaction, maskTail)
+	return &projected
+}
+
+// ProjectAll применяет Project к каждому заказу
+func (p *Projector) ProjectAll(role auth.Role, orders []*domain.Order) []*domain.Order {
+	if !p.Masks(role) {
+		return orders
+	}
+	projected := make([]*domain.Order, len(orders))
+	for i, order := range orders {
+		projected[i] = p.Project(role, order)
+	}
+	return projected
+}
+
+func maskValue(mode, value string, partial func(string) string) string {
+	switch {
+	case value == "":
+		return value
+	case mode == maskFull:
+		return strings.Repeat(maskSymbol, 3)
+	case mode == maskPartial:
+		return partial(value)
+	}
+	return value
+}
+
+// maskPhone оставляет "+" и последние 4 цифры: +*******4567
+func maskPhone(phone string) string {
+	digits := 0
+	for _, r := range phone {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+	var b strings.Builder
+	for _, r := range phone {
+		if unicode.IsDigit(r) {
+			digits--
+			if digits >= 4 {
+				r = '*'
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// maskEmail оставляет первый символ имени и домен: t***@gmail.com
+func maskEmail(email string) string {
+	local, domainPart, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return strings.Repeat(maskSymbol, 3)
+	}
+	first := []rune(local)[0]
+	return string(first) + strings.Repeat(maskSymbol, 3) + "@" + domainPart
+}
+
+// maskAddress оставляет только первое слово адреса: Ploshad ***
+func maskAddress(address string) string {
+	first, _, _ := strings.Cut(strings.TrimSpace(address), " ")
+	return first + " " + strings.Repeat(maskSymbol, 3)
+}
+
+// maskTail оставляет последние 4 символа, сохраняя длину значения
+func maskTail(value string) string {
+	runes := []rune(value)
+	if len(runes) <= 4 {
+		return strings.Repeat(maskSymbol, len(runes))
+	}
+	return strings.Repeat(maskSymbol, len(runes)-4) + string(runes[len(runes)-4:])
+}
@@ -0,0 +1,76 @@
+package projection
+
+import (
+	"testing"
+	"wb_l0/internal/auth"
+	"wb_l0/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjector_Project(t *testing.T) {
+	projector := New(map[string]map[string]string{
+		"reader":  {"phone": "full", "email": "full", "address": "full", "transaction": "full"},
+		"support": {"phone": "partial", "email": "partial", "address": "partial", "transaction": "partial"},
+		"admin":   {},
+	})
+	order := domain.CreateTestOrder(1)
+	order.Delivery.Phone = "+79720000000"
+	order.Delivery.Email = "test@gmail.com"
+	order.Delivery.Address = "Ploshad Mira 15"
+	order.Payment.Transaction = "b563feb7b2b84b6test1"
+
+	t.Run("reader sees fully masked fields", func(t *testing.T) {
+		projected := projector.Project(auth.RoleReader, &order)
+
+		assert.Equal(t, "***", projected.Delivery.Phone)
+		assert.Equal(t, "***", projected.Delivery.Email)
+		assert.Equal(t, "***", projected.Delivery.Address)
+		assert.Equal(t, "***", projected.Payment.Transaction)
+		assert.Equal(t, order.Delivery.Name, projected.Delivery.Name)
+		assert.Equal(t, "+79720000000", order.Delivery.Phone, "source order must not change")
+	})
+
+	t.Run("support sees partially masked fields", func(t *testing.T) {
+		projected := projector.Project(auth.RoleSupport, &order)
+
+		assert.Equal(t, "+*******0000", projected.Delivery.Phone)
+		assert.Equal(t, "t***@gmail.com", projected.Delivery.Email)
+		assert.Equal(t, "Ploshad ***", projected.Delivery.Address)
+		assert.Equal(t, "****************est1", projected.Payment.Transaction)
+	})
+
+	t.Run("admin sees original order", func(t *testing.T) {
+		assert.False(t, projector.Masks(auth.RoleAdmin))
+		assert.Same(t, &order, projector.Project(auth.RoleAdmin, &order))
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		projected := projector.ProjectAll(auth.RoleReader, []*domain.Order{&order, &order})
+
+		assert.Len(t, projected, 2)
+		for _, o := range projected {
+			assert.Equal(t, "***", o.Delivery.Phone)
+		}
+	})
+}
+
+func TestPartialMasks(t *testing.T) {
+	tests := []struct {
+		name, value, want string
+		mask              func(string) string
+	}{
+		{"address keeps only the first word", "  Ploshad Mira 15", "Ploshad ***", maskAddress},
+		{"single word address", "Moscow", "Moscow ***", maskAddress},
+		{"email keeps the first letter and the domain", "test@mail.example.com", "t***@mail.example.com", maskEmail},
+		{"email without local part", "@gmail.com", "***", maskEmail},
+		{"not an email", "test", "***", maskEmail},
+		{"phone keeps the last 4 digits", "+7 (972) 000-12-34", "+* (***) ***-12-34", maskPhone},
+		{"short transaction", "abc", "***", maskTail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.mask(tt.value))
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Операции журнала аудита
+const (
+	AuditCreate     = "create"
+	AuditDelete     = "delete"
+	AuditUpdate     = "update"
+	AuditItemStatus = "item_status"
+	AuditRefund     = "refund"
+	AuditCancel     = "cancel"
+)
+
+// ActorSystem - инициатор изменений, если он не указан в контексте
+const ActorSystem = "system"
+
+// AuditEntry - запись журнала аудита заказа. Записи не изменяются и не удаляются, в том числе вместе с заказом.
+type AuditEntry struct {
+	ID        int64                  `json:"id"`
+	OrderUID  string                 `json:"order_uid"`
+	Actor     string                 `json:"actor"`
+	Operation string                 `json:"operation"`
+	Diff      map[string]AuditChange `json:"diff"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// AuditChange - значение поля до и после изменения, null - поля не было
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type actorKey struct{}
+
+// WithActor сохраняет в контексте инициатора изменений: "kafka", "http:<subject>", "grpc:<subject>", "cli:<command>"
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext возвращает инициатора, сохранённого WithActor, или ActorSystem
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return ActorSystem
+}
+
+// Diff сравнивает JSON-представления before и after и возвращает изменённые поля по путям вида
+// "delivery.city" и "items.0.status". nil означает отсутствие документа, например до создания заказа.
+func Diff(before, after any) (map[string]AuditChange, error) {
+	left, err := flatten(before)
+	if err != nil {
+		return nil, err
+	}
+	right, err := flatten(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := make(map[string]AuditChange)
+	for path, value := range left {
+		if other, ok := right[path]; !ok || !reflect.DeepEqual(value, other) {
+			diff[path] = AuditChange{Before: value, After: other}
+		}
+	}
+	for path, value := range right {
+		if _, ok := left[path]; !ok {
+			diff[path] = AuditChange{After: value}
+		}
+	}
+	return diff, nil
+}
+
+func flatten(value any) (map[string]any, error) {
+	leaves := make(map[string]any)
+	if value == nil || reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil() {
+		return leaves, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit document: %w", err)
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode audit document: %w", err)
+	}
+	walk("", doc, leaves)
+	return leaves, nil
+}
+
+func walk(prefix string, value any, leaves map[string]any) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			walk(join(key), child, leaves)
+		}
+	case []any:
+		for i, child := range v {
+			walk(join(strconv.Itoa(i)), child, leaves)
+		}
+	default:
+		leaves[prefix] = v
+	}
+}
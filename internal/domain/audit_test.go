@@ -0,0 +1,49 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+	"wb_l0/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	before := domain.CreateTestOrder(1)
+	after := before
+	after.Delivery.City = "Kazan"
+	after.Items = append([]domain.Item(nil), before.Items...)
+	after.Items[0].Status = domain.ItemStatusNotAvailable
+
+	t.Run("changed fields only", func(t *testing.T) {
+		diff, err := domain.Diff(before, after)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]domain.AuditChange{
+			"delivery.city":  {Before: before.Delivery.City, After: "Kazan"},
+			"items.0.status": {Before: float64(202), After: float64(400)},
+		}, diff)
+	})
+
+	t.Run("created document", func(t *testing.T) {
+		diff, err := domain.Diff(nil, &after)
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.AuditChange{After: "Kazan"}, diff["delivery.city"])
+		assert.Equal(t, domain.AuditChange{After: after.OrderUID}, diff["order_uid"])
+	})
+
+	t.Run("deleted document", func(t *testing.T) {
+		var deleted *domain.Order
+		diff, err := domain.Diff(&before, deleted)
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.AuditChange{Before: before.Delivery.City}, diff["delivery.city"])
+	})
+}
+
+func TestActorFromContext(t *testing.T) {
+	assert.Equal(t, domain.ActorSystem, domain.ActorFromContext(context.Background()))
+	assert.Equal(t, "kafka", domain.ActorFromContext(domain.WithActor(context.Background(), "kafka")))
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "context"
+
+type primaryReadKey struct{}
+
+// WithPrimaryRead требует читать из primary в обход кэша и реплик, например когда прочитанное
+// значение кэшируется или используется как ожидаемая версия для обновления
+func WithPrimaryRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadKey{}, true)
+}
+
+// PrimaryReadFromContext сообщает, запрошено ли чтение из primary через WithPrimaryRead
+func PrimaryReadFromContext(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryReadKey{}).(bool)
+	return primary
+}
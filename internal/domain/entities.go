@@ -19,6 +19,12 @@ type Order struct {
 	SMID              int       `json:"sm_id" validate:"required,min=0"`
 	DateCreated       time.Time `json:"date_created" validate:"required"`
 	OOFShard          string    `json:"oof_shard" validate:"required,numeric,max=10"`
+	// Version увеличивается при каждом обновлении заказа, новый заказ сохраняется с версией 1
+	Version int `json:"version" validate:"min=0"`
+	// Timeline, Cancellation и Refunds заполняются при чтении из базы
+	Timeline     []ItemStatusChange `json:"timeline,omitempty"`
+	Cancellation *Cancellation      `json:"cancellation,omitempty"`
+	Refunds      []Refund           `json:"refunds,omitempty"`
 }
 
 type Delivery struct {
@@ -56,4 +62,6 @@ type Item struct {
 	NMID        int    `json:"nm_id" validate:"required,min=1"`
 	Brand       string `json:"brand" validate:"required,min=2,max=255"`
 	Status      int    `json:"status" validate:"required,min=100,max=600"`
+	// Quantity - количество единиц товара в строке заказа, 0 означает одну единицу
+	Quantity int `json:"quantity,omitempty" validate:"omitempty,min=1"`
 }
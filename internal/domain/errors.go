@@ -1,7 +1,49 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
 
 var (
 	ErrRecordNotFound = errors.New("record not found")
+	// ErrValidation - данные заказа не проходят проверки, повтор не поможет
+	ErrValidation = errors.New("validation failed")
+	// ErrConflict - запись противоречит уже сохранённым данным
+	ErrConflict = errors.New("conflict")
+	// ErrAlreadyExists - заказ с таким order_uid уже сохранён
+	ErrAlreadyExists = errors.New("order already exists")
+	// ErrContentConflict - заказ с таким order_uid уже сохранён с другими данными
+	ErrContentConflict = errors.New("order content conflict")
+	// ErrInvalidTransition - переход в запрошенный статус из текущего не допускается
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// ErrTransient - временная ошибка инфраструктуры, операцию можно повторить
+	ErrTransient = errors.New("transient error")
 )
+
+// ContentConflictError возвращается, когда повторный заказ отличается от сохранённого.
+// Diff содержит расходящиеся поля: Before - сохранённое значение, After - пришедшее.
+type ContentConflictError struct {
+	OrderUID string
+	Diff     map[string]AuditChange
+}
+
+func (e *ContentConflictError) Error() string {
+	return fmt.Sprintf("order %s differs from stored in fields: %s", e.OrderUID, strings.Join(e.Fields(), ", "))
+}
+
+func (e *ContentConflictError) Unwrap() error {
+	return ErrContentConflict
+}
+
+// Fields возвращает отсортированные пути расходящихся полей
+func (e *ContentConflictError) Fields() []string {
+	fields := make([]string, 0, len(e.Diff))
+	for path := range e.Diff {
+		fields = append(fields, path)
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// OrderFilter - условия выборки списка заказов. Заказы упорядочены от новых к старым.
+type OrderFilter struct {
+	CustomerID  string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// After - курсор постраничной выборки: вернуть заказы, идущие после него
+	After *OrderCursor
+	Limit int
+}
+
+// OrderCursor - позиция заказа в списке, отсортированном по (date_created, order_uid) по убыванию
+type OrderCursor struct {
+	DateCreated time.Time `json:"date_created"`
+	OrderUID    string    `json:"order_uid"`
+}
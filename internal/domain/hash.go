@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// ContentHash возвращает SHA-256 канонического JSON заказа.
+// Позиции сортируются, а дата приводится к UTC с точностью Postgres (микросекунды),
+// поэтому заказ из Kafka и тот же заказ, прочитанный из базы, дают одинаковый хеш.
+// История статусов в хеш не входит: каждый переход и так меняет статус позиции.
+func (o *Order) ContentHash() (string, error) {
+	return hashJSON(o.canonical())
+}
+
+// PayloadHash возвращает хеш данных заказа, присланных источником: в отличие от ContentHash
+// в него не входят версия, отмена и возвраты. Повтор того же сообщения даёт тот же хеш.
+func (o *Order) PayloadHash() (string, error) {
+	return hashJSON(o.payload())
+}
+
+// PayloadDiff возвращает поля канонических данных, которыми other отличается от o
+func (o *Order) PayloadDiff(other *Order) (map[string]AuditChange, error) {
+	before, after := o.payload(), other.payload()
+	return Diff(&before, &after)
+}
+
+func (o *Order) canonical() Order {
+	canonical := *o
+	canonical.Timeline = nil
+	canonical.DateCreated = o.DateCreated.UTC().Truncate(time.Microsecond)
+	canonical.Items = make([]Item, len(o.Items))
+	copy(canonical.Items, o.Items)
+	// Одна единица - значение по умолчанию, хэш заказов без quantity не меняется
+	for i := range canonical.Items {
+		if canonical.Items[i].Quantity == 1 {
+			canonical.Items[i].Quantity = 0
+		}
+	}
+	sort.SliceStable(canonical.Items, func(i, j int) bool {
+		if canonical.Items[i].ChrtID != canonical.Items[j].ChrtID {
+			return canonical.Items[i].ChrtID < canonical.Items[j].ChrtID
+		}
+		return canonical.Items[i].RID < canonical.Items[j].RID
+	})
+	return canonical
+}
+
+func (o *Order) payload() Order {
+	payload := o.canonical()
+	payload.Version = 0
+	payload.Cancellation = nil
+	payload.Refunds = nil
+	return payload
+}
+
+func hashJSON(value any) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,105 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+	"wb_l0/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrder_ContentHash(t *testing.T) {
+	order := domain.CreateTestOrder(1)
+	order.DateCreated = time.Date(2025, 1, 2, 3, 4, 5, 123456789, time.FixedZone("MSK", 3*60*60))
+	second := order.Items[0]
+	second.ChrtID = 1
+	second.RID = "bb4219087a764ae0b473"
+	order.Items = append(order.Items, second)
+
+	hash, err := order.ContentHash()
+	require.NoError(t, err)
+	assert.Len(t, hash, 64)
+
+	t.Run("stable across item order and time representation", func(t *testing.T) {
+		fromDB := order
+		fromDB.Items = []domain.Item{order.Items[1], order.Items[0]}
+		fromDB.DateCreated = order.DateCreated.UTC().Truncate(time.Microsecond)
+
+		dbHash, err := fromDB.ContentHash()
+
+		require.NoError(t, err)
+		assert.Equal(t, hash, dbHash)
+		assert.Equal(t, uint(9934930), uint(order.Items[0].ChrtID), "original items must stay unsorted")
+	})
+
+	t.Run("ignores status timeline", func(t *testing.T) {
+		withTimeline := order
+		withTimeline.Timeline = []domain.ItemStatusChange{{ChrtID: 1, From: 200, To: 202, Actor: "http:ops"}}
+
+		timelineHash, err := withTimeline.ContentHash()
+
+		require.NoError(t, err)
+		assert.Equal(t, hash, timelineHash)
+	})
+
+	t.Run("changes with content", func(t *testing.T) {
+		changed := order
+		changed.Items = append([]domain.Item(nil), order.Items...)
+		changed.Items[0].Price++
+
+		changedHash, err := changed.ContentHash()
+
+		require.NoError(t, err)
+		assert.NotEqual(t, hash, changedHash)
+	})
+}
+
+func TestOrder_PayloadHash(t *testing.T) {
+	order := domain.CreateTestOrder(1)
+	hash, err := order.PayloadHash()
+	require.NoError(t, err)
+
+	t.Run("ignores version and read model", func(t *testing.T) {
+		stored := order
+		stored.Version = 3
+		stored.Cancellation = &domain.Cancellation{Reason: "customer request", Actor: "http:ops"}
+		stored.Refunds = []domain.Refund{{ID: 1, Amount: 100}}
+
+		storedHash, err := stored.PayloadHash()
+
+		require.NoError(t, err)
+		assert.Equal(t, hash, storedHash)
+		contentHash, err := stored.ContentHash()
+		require.NoError(t, err)
+		assert.NotEqual(t, hash, contentHash)
+	})
+
+	t.Run("single unit quantity is the default", func(t *testing.T) {
+		stored := order
+		stored.Items = []domain.Item{order.Items[0]}
+		stored.Items[0].Quantity = 1
+
+		storedHash, err := stored.PayloadHash()
+
+		require.NoError(t, err)
+		assert.Equal(t, hash, storedHash)
+		stored.Items[0].Quantity = 2
+		storedHash, err = stored.PayloadHash()
+		require.NoError(t, err)
+		assert.NotEqual(t, hash, storedHash)
+	})
+
+	t.Run("diff lists changed fields", func(t *testing.T) {
+		changed := order
+		changed.Version = 2
+		changed.Payment.Amount++
+
+		diff, err := order.PayloadDiff(&changed)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]domain.AuditChange{
+			"payment.amount": {Before: float64(order.Payment.Amount), After: float64(changed.Payment.Amount)},
+		}, diff)
+	})
+}
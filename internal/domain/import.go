@@ -0,0 +1,11 @@
+package domain
+
+// ImportResult - итог массовой загрузки заказов
+type ImportResult struct {
+	// Inserted - сохранённые заказы
+	Inserted int
+	// Skipped - заказы, order_uid которых уже был в базе или раньше встретился в загрузке
+	Skipped int
+	// Invalid - строки, не прошедшие разбор или валидацию
+	Invalid int
+}
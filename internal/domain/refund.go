@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRefundExceeded - сумма возвратов превысила бы сумму оплаты заказа или позиции
+var ErrRefundExceeded = errors.New("refund exceeds paid amount")
+
+// Refund - возврат по оплате заказа. Возврат без ChrtID относится к заказу целиком и отменяет его.
+type Refund struct {
+	ID       int64  `json:"id"`
+	OrderUID string `json:"-"`
+	ChrtID   int    `json:"chrt_id,omitempty"`
+	Amount   int    `json:"amount"`
+	Reason   string `json:"reason"`
+	// RequestID - ключ идемпотентности: повторный возврат с тем же ключом отклоняется
+	RequestID string    `json:"request_id,omitempty"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Cancellation - отмена заказа
+type Cancellation struct {
+	Reason      string    `json:"reason"`
+	Actor       string    `json:"actor"`
+	CancelledAt time.Time `json:"cancelled_at"`
+}
+
+// RefundedAmount возвращает сумму всех возвратов по заказу
+func (o *Order) RefundedAmount() int {
+	total := 0
+	for _, refund := range o.Refunds {
+		total += refund.Amount
+	}
+	return total
+}
+
+// ItemRefundedAmount возвращает сумму возвратов по позиции chrtID
+func (o *Order) ItemRefundedAmount(chrtID int) int {
+	total := 0
+	for _, refund := range o.Refunds {
+		if refund.ChrtID == chrtID {
+			total += refund.Amount
+		}
+	}
+	return total
+}
+
+// ItemPaidAmount возвращает стоимость позиции chrtID. Один chrt_id может встречаться в нескольких строках
+// заказа, тогда их стоимости складываются. found ложно, если позиции в заказе нет.
+func (o *Order) ItemPaidAmount(chrtID int) (paid int, found bool) {
+	for _, item := range o.Items {
+		if item.ChrtID == chrtID {
+			paid += item.TotalPrice
+			found = true
+		}
+	}
+	return paid, found
+}
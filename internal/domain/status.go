@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// Статусы позиции заказа, справочник item_statuses
+const (
+	ItemStatusCreated      = 200
+	ItemStatusApproved     = 202
+	ItemStatusSale         = 300
+	ItemStatusNotAvailable = 400
+)
+
+// itemStatusTransitions - допустимые переходы, кроме перехода в ItemStatusNotAvailable
+var itemStatusTransitions = map[int][]int{
+	ItemStatusCreated:  {ItemStatusApproved},
+	ItemStatusApproved: {ItemStatusSale},
+}
+
+// CanChangeItemStatus сообщает, допустим ли переход позиции из статуса from в статус to.
+// В ItemStatusNotAvailable можно перейти из любого статуса.
+func CanChangeItemStatus(from, to int) bool {
+	if to == ItemStatusNotAvailable {
+		return from != ItemStatusNotAvailable
+	}
+	return slices.Contains(itemStatusTransitions[from], to)
+}
+
+// ItemStatusChange - переход позиции заказа в новый статус
+type ItemStatusChange struct {
+	OrderUID  string    `json:"-"`
+	ChrtID    int       `json:"chrt_id"`
+	From      int       `json:"from"`
+	To        int       `json:"to"`
+	Actor     string    `json:"actor"`
+	ChangedAt time.Time `json:"changed_at"`
+}
@@ -0,0 +1,30 @@
+package domain_test
+
+import (
+	"testing"
+	"wb_l0/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanChangeItemStatus(t *testing.T) {
+	tests := []struct {
+		from, to int
+		want     bool
+	}{
+		{domain.ItemStatusCreated, domain.ItemStatusApproved, true},
+		{domain.ItemStatusApproved, domain.ItemStatusSale, true},
+		{domain.ItemStatusCreated, domain.ItemStatusNotAvailable, true},
+		{domain.ItemStatusSale, domain.ItemStatusNotAvailable, true},
+		{150, domain.ItemStatusNotAvailable, true},
+		{domain.ItemStatusCreated, domain.ItemStatusSale, false},
+		{domain.ItemStatusSale, domain.ItemStatusApproved, false},
+		{domain.ItemStatusApproved, domain.ItemStatusApproved, false},
+		{domain.ItemStatusNotAvailable, domain.ItemStatusNotAvailable, false},
+		{domain.ItemStatusNotAvailable, domain.ItemStatusCreated, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, domain.CanChangeItemStatus(tt.from, tt.to), "%d -> %d", tt.from, tt.to)
+	}
+}
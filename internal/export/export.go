@@ -0,0 +1,131 @@
+// Package export записывает заказы в CSV и NDJSON потоково, по одному заказу за вызов.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+	"wb_l0/internal/domain"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Writer пишет заказы в выбранном формате. Flush обязателен после последнего заказа.
+type Writer interface {
+	Write(order *domain.Order) error
+	Flush() error
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	}
+	return nil, fmt.Errorf("unsupported export format %q: use %s or %s", format, FormatCSV, FormatNDJSON)
+}
+
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// csvHeader - колонки CSV: поля заказа повторяются в каждой строке, по строке на товар.
+// item_quantity для позиции без количества равно 1.
+var csvHeader = []string{
+	"order_uid", "track_number", "entry", "locale", "internal_signature", "customer_id", "delivery_service",
+	"shardkey", "sm_id", "date_created", "oof_shard",
+	"delivery_name", "delivery_phone", "delivery_zip", "delivery_city", "delivery_address", "delivery_region",
+	"delivery_email",
+	"payment_transaction", "payment_request_id", "payment_currency", "payment_provider", "payment_amount",
+	"payment_dt", "payment_bank", "payment_delivery_cost", "payment_goods_total", "payment_custom_fee",
+	"item_chrt_id", "item_track_number", "item_price", "item_rid", "item_name", "item_sale", "item_size",
+	"item_total_price", "item_nm_id", "item_brand", "item_status",
+	"item_quantity",
+}
+
+type csvWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+// Write пишет по строке на каждый товар заказа. Заказ без товаров даёт одну строку с пустыми колонками товара.
+func (c *csvWriter) Write(order *domain.Order) error {
+	if !c.headerWritten {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+
+	orderColumns := []string{
+		order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature, order.CustomerID,
+		order.DeliveryService, order.ShardKey, strconv.Itoa(order.SMID),
+		order.DateCreated.UTC().Format(time.RFC3339), order.OOFShard,
+		order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip, order.Delivery.City, order.Delivery.Address,
+		order.Delivery.Region, order.Delivery.Email,
+		order.Payment.Transaction, order.Payment.RequestID, order.Payment.Currency, order.Payment.Provider,
+		strconv.Itoa(order.Payment.Amount), strconv.FormatInt(order.Payment.PaymentDT, 10), order.Payment.Bank,
+		strconv.Itoa(order.Payment.DeliveryCost), strconv.Itoa(order.Payment.GoodsTotal),
+		strconv.Itoa(order.Payment.CustomFee),
+	}
+
+	if len(order.Items) == 0 {
+		return c.w.Write(append(orderColumns, make([]string, len(csvHeader)-len(orderColumns))...))
+	}
+	for _, item := range order.Items {
+		record := append(orderColumns[:len(orderColumns):len(orderColumns)],
+			strconv.Itoa(item.ChrtID), item.TrackNumber, strconv.Itoa(item.Price), item.RID, item.Name,
+			strconv.Itoa(item.Sale), item.Size, strconv.Itoa(item.TotalPrice), strconv.Itoa(item.NMID), item.Brand,
+			strconv.Itoa(item.Status), strconv.Itoa(max(item.Quantity, 1)),
+		)
+		if err := c.w.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *csvWriter) Flush() error {
+	if !c.headerWritten {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	buf := bufio.NewWriter(w)
+	return &ndjsonWriter{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+// Write пишет заказ одной строкой JSON в том же виде, что и API
+func (n *ndjsonWriter) Write(order *domain.Order) error {
+	return n.enc.Encode(order)
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return n.buf.Flush()
+}
@@ -0,0 +1,90 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"wb_l0/internal/domain"
+	"wb_l0/internal/export"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVWriter(t *testing.T) {
+	withItems := domain.CreateTestOrder(1)
+	withItems.Items = append(withItems.Items, withItems.Items[0])
+	withItems.Items[1].ChrtID = 42
+	withItems.Items[1].Quantity = 3
+	noItems := domain.CreateTestOrder(2)
+	noItems.Items = nil
+
+	var buf bytes.Buffer
+	writer, err := export.NewWriter(export.FormatCSV, &buf)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(&withItems))
+	require.NoError(t, writer.Write(&noItems))
+	require.NoError(t, writer.Flush())
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	header := records[0]
+	column := func(record []string, name string) string {
+		for i, h := range header {
+			if h == name {
+				return record[i]
+			}
+		}
+		t.Fatalf("no column %s", name)
+		return ""
+	}
+	assert.Equal(t, withItems.OrderUID, column(records[1], "order_uid"))
+	assert.Equal(t, "9934930", column(records[1], "item_chrt_id"))
+	assert.Equal(t, "1", column(records[1], "item_quantity"))
+	assert.Equal(t, withItems.OrderUID, column(records[2], "order_uid"))
+	assert.Equal(t, "42", column(records[2], "item_chrt_id"))
+	assert.Equal(t, "3", column(records[2], "item_quantity"))
+	assert.Equal(t, "1817", column(records[2], "payment_amount"))
+	assert.Equal(t, noItems.OrderUID, column(records[3], "order_uid"))
+	assert.Empty(t, column(records[3], "item_chrt_id"))
+	assert.Empty(t, column(records[3], "item_quantity"))
+}
+
+func TestCSVWriter_EmptyExportHasHeader(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := export.NewWriter(export.FormatCSV, &buf)
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Flush())
+
+	assert.True(t, strings.HasPrefix(buf.String(), "order_uid,track_number,"))
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	first := domain.CreateTestOrder(1)
+	second := domain.CreateTestOrder(2)
+
+	var buf bytes.Buffer
+	writer, err := export.NewWriter(export.FormatNDJSON, &buf)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(&first))
+	require.NoError(t, writer.Write(&second))
+	require.NoError(t, writer.Flush())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var decoded domain.Order
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &decoded))
+	assert.Equal(t, second.OrderUID, decoded.OrderUID)
+	assert.Equal(t, second.Items, decoded.Items)
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	_, err := export.NewWriter("xlsx", &bytes.Buffer{})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+// Package health - проверки готовности сервиса и его зависимостей.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusOK Status = "ok"
+	// StatusDegraded - необязательная зависимость недоступна, сервис работает с ограничениями
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// Component - проверяемая зависимость. Отказ Critical компонента делает сервис неготовым,
+// отказ остальных - деградировавшим.
+type Component struct {
+	Name     string
+	Critical bool
+	Check    func(ctx context.Context) error
+}
+
+type ComponentStatus struct {
+	Status    Status `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Critical  bool   `json:"critical"`
+	Error     string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status     Status                     `json:"status"`
+	Timestamp  string                     `json:"timestamp"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+type Checker struct {
+	components []Component
+	timeout    time.Duration
+}
+
+// NewChecker создаёт Checker, каждая проверка ограничена timeout
+func NewChecker(timeout time.Duration, components ...Component) *Checker {
+	return &Checker{components: components, timeout: timeout}
+}
+
+// Ready параллельно выполняет все проверки и сводит их в общий статус
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := Report{
+		Status:     StatusOK,
+		Timestamp:  time.Now().Format(time.RFC3339),
+		Components: make(map[string]ComponentStatus, len(c.components)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, component := range c.components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := c.check(ctx, component)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[component.Name] = status
+			if status.Status == StatusOK {
+				return
+			}
+			if component.Critical {
+				report.Status = StatusDown
+			} else if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) check(ctx context.Context, component Component) ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- component.Check(ctx)
+	}()
+
+	var err error
+	// Проверка может не уважать контекст, поэтому ждём не дольше таймаута
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	status := ComponentStatus{
+		Status:    StatusOK,
+		LatencyMs: time.Since(start).Milliseconds(),
+		Critical:  component.Critical,
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
@@ -0,0 +1,82 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wb_l0/internal/health"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ok(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("connection refused") }
+
+func hanging(context.Context) error {
+	time.Sleep(time.Second)
+	return nil
+}
+
+func TestChecker_Ready(t *testing.T) {
+	tests := []struct {
+		name       string
+		components []health.Component
+		expected   health.Status
+	}{
+		{
+			name: "all components are up",
+			components: []health.Component{
+				{Name: "postgres", Critical: true, Check: ok},
+				{Name: "redis", Check: ok},
+			},
+			expected: health.StatusOK,
+		},
+		{
+			name: "cache is down",
+			components: []health.Component{
+				{Name: "postgres", Critical: true, Check: ok},
+				{Name: "redis", Check: failing},
+			},
+			expected: health.StatusDegraded,
+		},
+		{
+			name: "database is down",
+			components: []health.Component{
+				{Name: "postgres", Critical: true, Check: failing},
+				{Name: "redis", Check: failing},
+			},
+			expected: health.StatusDown,
+		},
+		{
+			name: "hanging check times out",
+			components: []health.Component{
+				{Name: "postgres", Critical: true, Check: hanging},
+			},
+			expected: health.StatusDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := health.NewChecker(50*time.Millisecond, tt.components...)
+
+			start := time.Now()
+			report := checker.Ready(context.Background())
+
+			assert.Less(t, time.Since(start), 500*time.Millisecond)
+			assert.Equal(t, tt.expected, report.Status)
+			assert.Len(t, report.Components, len(tt.components))
+		})
+	}
+
+	t.Run("component details", func(t *testing.T) {
+		checker := health.NewChecker(time.Second, health.Component{Name: "redis", Check: failing})
+
+		report := checker.Ready(context.Background())
+
+		assert.Equal(t, health.ComponentStatus{Status: health.StatusDown, Error: "connection refused"},
+			report.Components["redis"])
+	})
+}
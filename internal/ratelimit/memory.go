@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	rule   Rule
+}
+
+// MemoryStore хранит корзины в памяти процесса. Лимиты действуют на каждый экземпляр сервиса отдельно.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Allow(_ context.Context, key string, rule Rule) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rule.Burst), last: now, rule: rule}
+		s.buckets[key] = b
+	}
+
+	var result Result
+	b.tokens, result = take(b.tokens, now.Sub(b.last), rule)
+	b.last = now
+	b.rule = rule
+	return result, nil
+}
+
+// sweep удаляет корзины, которые успели наполниться полностью: они неотличимы от новых
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		refill := time.Duration(float64(b.rule.Burst) / b.rule.Rate * float64(time.Second))
+		if now.Sub(b.last) > refill {
+			delete(s.buckets, key)
+		}
+	}
+}
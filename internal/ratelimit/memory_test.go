@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Allow(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	rule := Rule{Rate: 2, Burst: 3}
+	ctx := context.Background()
+
+	t.Run("burst is allowed, then throttled", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			result, err := store.Allow(ctx, "a", rule)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, 2-i, result.Remaining)
+		}
+
+		result, err := store.Allow(ctx, "a", rule)
+
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		result, err := store.Allow(ctx, "b", rule)
+
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("tokens refill over time", func(t *testing.T) {
+		now = now.Add(500 * time.Millisecond)
+
+		result, err := store.Allow(ctx, "a", rule)
+
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("idle buckets are swept", func(t *testing.T) {
+		now = now.Add(2 * sweepInterval)
+
+		_, err := store.Allow(ctx, "c", rule)
+
+		require.NoError(t, err)
+		assert.Len(t, store.buckets, 1)
+	})
+}
@@ -0,0 +1,38 @@
+// Package ratelimit - ограничение частоты запросов алгоритмом token bucket.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Rule - параметры корзины: Rate токенов в секунду и ёмкость Burst
+type Rule struct {
+	Rate  float64
+	Burst int
+}
+
+// Result - решение по одному запросу
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter - через сколько появится следующий токен, если запрос отклонён
+	RetryAfter time.Duration
+}
+
+// Store хранит состояние корзин: в памяти процесса или общее для всех экземпляров в Redis
+type Store interface {
+	Allow(ctx context.Context, key string, rule Rule) (Result, error)
+}
+
+// take пополняет корзину за прошедшее время и пытается забрать из неё один токен
+func take(tokens float64, elapsed time.Duration, rule Rule) (float64, Result) {
+	tokens = math.Min(float64(rule.Burst), tokens+elapsed.Seconds()*rule.Rate)
+	if tokens >= 1 {
+		tokens--
+		return tokens, Result{Allowed: true, Remaining: int(tokens)}
+	}
+	wait := time.Duration((1 - tokens) / rule.Rate * float64(time.Second))
+	return tokens, Result{Allowed: false, RetryAfter: wait}
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript атомарно пополняет корзину и забирает токен.
+// Время берётся у Redis, чтобы расхождение часов экземпляров не влияло на лимит.
+// Возвращает {allowed, tokens, retry_after_ms}.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, tostring(tokens), retry}
+`)
+
+// RedisStore хранит корзины в Redis, лимит общий для всех экземпляров сервиса
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	values, err := tokenBucketScript.Run(ctx, s.client, []string{s.prefix + key}, rule.Rate, rule.Burst).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit script: %w", err)
+	}
+	if len(values) != 3 {
+		return Result{}, fmt.Errorf("rate limit script: unexpected reply %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	tokensStr, _ := values[1].(string)
+	tokens, _ := strconv.ParseFloat(tokensStr, 64)
+	retryMs, _ := values[2].(int64)
+
+	return Result{
+		Allowed:    allowed == 1,
+		Remaining:  int(math.Floor(tokens)),
+		RetryAfter: time.Duration(retryMs) * time.Millisecond,
+	}, nil
+}
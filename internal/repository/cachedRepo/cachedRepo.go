@@ -2,6 +2,7 @@ package cachedRepo
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"time"
 	"wb_l0/configs"
@@ -17,14 +18,23 @@ import (
 
 type OrderRepository interface {
 	GetOrderByUID(ctx context.Context, orderUID string) (*domain.Order, error)
+	GetOrdersByUIDs(ctx context.Context, orderUIDs []string) ([]*domain.Order, error)
 	DeleteOrder(ctx context.Context, orderUID string) error
 	SaveOrder(ctx context.Context, order *domain.Order) error
+	UpdateOrder(ctx context.Context, order *domain.Order, expectedVersion int) error
 	GetLastOrdersUIDs(ctx context.Context, limit int) ([]string, error)
+	ListOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error)
+	UpdateItemStatus(ctx context.Context, change *domain.ItemStatusChange) error
+	SaveRefund(ctx context.Context, refund *domain.Refund) error
+	GetAuditLog(ctx context.Context, orderUID string) ([]domain.AuditEntry, error)
 }
 
 type CacheRepository interface {
 	GetOrderByUID(ctx context.Context, orderUID string) (*domain.Order, error)
+	GetOrderWithHash(ctx context.Context, orderUID string) (*domain.Order, string, error)
+	GetOrdersByUIDs(ctx context.Context, orderUIDs []string) (map[string]*domain.Order, error)
 	SaveOrder(ctx context.Context, order *domain.Order) error
+	DeleteOrder(ctx context.Context, orderUID string) error
 	CountOrders(ctx context.Context) (int, error)
 }
 
@@ -63,8 +73,19 @@ func NewCachedRepo(ctx context.Context, repo OrderRepository, cache CacheReposit
 }
 
 func (r *CachedRepo) GetOrderByUID(ctx context.Context, orderUID string) (*domain.Order, error) {
+	order, _, err := r.GetOrderWithHash(ctx, orderUID)
+	return order, err
+}
+
+// GetOrderWithHash возвращает заказ и хеш его содержимого. При попадании в кэш
+// хеш берётся из Redis и не пересчитывается. Промах читается из primary, чтобы не закэшировать
+// на весь TTL устаревший заказ с отстающей реплики. С domain.WithPrimaryRead кэш не читается.
+func (r *CachedRepo) GetOrderWithHash(ctx context.Context, orderUID string) (*domain.Order, string, error) {
+	if domain.PrimaryReadFromContext(ctx) {
+		return r.loadOrder(ctx, orderUID)
+	}
 	r.log.Debug("attempting to get order from cache", "orderUID", orderUID)
-	order, err := r.cache.GetOrderByUID(ctx, orderUID)
+	order, hash, err := r.cache.GetOrderWithHash(ctx, orderUID)
 	if err == nil && order != nil {
 		prometheus.CacheOperations.WithLabelValues("hit").Inc()
 		r.log.Debug("order found in cache")
@@ -72,7 +93,7 @@ func (r *CachedRepo) GetOrderByUID(ctx context.Context, orderUID string) (*domai
 		// ALARM LEAK
 		//sl = append(sl, *order)
 
-		return order, nil
+		return order, hash, nil
 	}
 	if err != nil && err != domain.ErrRecordNotFound {
 		prometheus.CacheOperations.WithLabelValues("error").Inc()
@@ -81,11 +102,19 @@ func (r *CachedRepo) GetOrderByUID(ctx context.Context, orderUID string) (*domai
 	}
 	prometheus.CacheOperations.WithLabelValues("miss").Inc()
 	r.log.Debug("order not found in cache, querying database", "orderUID", orderUID)
+	return r.loadOrder(domain.WithPrimaryRead(ctx), orderUID)
+}
 
-	order, err = r.repo.GetOrderByUID(ctx, orderUID)
+// loadOrder читает заказ из базы и сохраняет его в кэш
+func (r *CachedRepo) loadOrder(ctx context.Context, orderUID string) (*domain.Order, string, error) {
+	order, err := r.repo.GetOrderByUID(ctx, orderUID)
 	if err != nil {
 		r.log.Error("failed to get order from database", "error", err)
-		return nil, err
+		return nil, "", err
+	}
+	hash, err := order.ContentHash()
+	if err != nil {
+		return nil, "", err
 	}
 	r.log.Debug("order found in database, saving to cache")
 
@@ -94,15 +123,64 @@ func (r *CachedRepo) GetOrderByUID(ctx context.Context, orderUID string) (*domai
 	}
 
 	r.log.Info("order retrieved successfully", "source", "database")
-	return order, nil
+	return order, hash, nil
 
 }
 
+func (r *CachedRepo) GetOrdersByUIDs(ctx context.Context, orderUIDs []string) ([]*domain.Order, error) {
+	r.log.Debug("attempting to get orders from cache", "count", len(orderUIDs))
+	cached, err := r.cache.GetOrdersByUIDs(ctx, orderUIDs)
+	if err != nil {
+		prometheus.CacheOperations.WithLabelValues("error").Inc()
+		r.log.Warn("error getting batch from cache, falling back to database", "error", err)
+		cached = nil
+	}
+
+	misses := make([]string, 0, len(orderUIDs)-len(cached))
+	for _, uid := range orderUIDs {
+		if _, ok := cached[uid]; !ok {
+			misses = append(misses, uid)
+		}
+	}
+	prometheus.CacheOperations.WithLabelValues("hit").Add(float64(len(cached)))
+	prometheus.CacheOperations.WithLabelValues("miss").Add(float64(len(misses)))
+
+	fromDB := make(map[string]*domain.Order, len(misses))
+	if len(misses) > 0 {
+		r.log.Debug("orders not found in cache, querying database", "count", len(misses))
+		orders, err := r.repo.GetOrdersByUIDs(domain.WithPrimaryRead(ctx), misses)
+		if err != nil {
+			r.log.Error("failed to get orders from database", "error", err)
+			return nil, err
+		}
+		for _, order := range orders {
+			fromDB[order.OrderUID] = order
+			if err := r.cache.SaveOrder(ctx, order); err != nil {
+				r.log.Warn("failed to save order to cache", "error", err, "orderUID", order.OrderUID)
+			}
+		}
+	}
+
+	result := make([]*domain.Order, 0, len(cached)+len(fromDB))
+	for _, uid := range orderUIDs {
+		if order, ok := cached[uid]; ok {
+			result = append(result, order)
+		} else if order, ok := fromDB[uid]; ok {
+			result = append(result, order)
+		}
+	}
+	r.log.Info("orders retrieved successfully", "cached", len(cached), "database", len(fromDB))
+	return result, nil
+}
+
 func (r *CachedRepo) SaveOrder(ctx context.Context, order *domain.Order) error {
 
 	r.log.Debug("saving order to database")
 
 	if err := r.repo.SaveOrder(ctx, order); err != nil {
+		if errors.Is(err, domain.ErrAlreadyExists) || errors.Is(err, domain.ErrContentConflict) {
+			return err
+		}
 		r.log.Error("failed to save order to database", "error", err,
 			"orderUID", order.OrderUID)
 		return err
@@ -119,14 +197,68 @@ func (r *CachedRepo) SaveOrder(ctx context.Context, order *domain.Order) error {
 	return nil
 }
 
+// ListOrders читает страницу заказов из базы: выборки по фильтру в кэше не хранятся
+func (r *CachedRepo) ListOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error) {
+	return r.repo.ListOrders(ctx, filter)
+}
+
+// UpdateItemStatus меняет статус позиции в базе и удаляет заказ из кэша, чтобы следующее чтение
+// вернуло новый статус и историю
+func (r *CachedRepo) UpdateItemStatus(ctx context.Context, change *domain.ItemStatusChange) error {
+	if err := r.repo.UpdateItemStatus(ctx, change); err != nil {
+		return err
+	}
+	if err := r.cache.DeleteOrder(ctx, change.OrderUID); err != nil {
+		r.log.Warn("failed to delete order from cache", "error", err, "orderUID", change.OrderUID)
+	}
+	return nil
+}
+
+// SaveRefund сохраняет возврат в базе и удаляет заказ из кэша
+func (r *CachedRepo) SaveRefund(ctx context.Context, refund *domain.Refund) error {
+	if err := r.repo.SaveRefund(ctx, refund); err != nil {
+		return err
+	}
+	if err := r.cache.DeleteOrder(ctx, refund.OrderUID); err != nil {
+		r.log.Warn("failed to delete order from cache", "error", err, "orderUID", refund.OrderUID)
+	}
+	return nil
+}
+
+// UpdateOrder обновляет заказ в базе и удаляет его из кэша
+func (r *CachedRepo) UpdateOrder(ctx context.Context, order *domain.Order, expectedVersion int) error {
+	if err := r.repo.UpdateOrder(ctx, order, expectedVersion); err != nil {
+		return err
+	}
+	if err := r.cache.DeleteOrder(ctx, order.OrderUID); err != nil {
+		r.log.Warn("failed to delete order from cache", "error", err, "orderUID", order.OrderUID)
+	}
+	return nil
+}
+
+// GetAuditLog читает журнал аудита из базы: журнал не кэшируется
+func (r *CachedRepo) GetAuditLog(ctx context.Context, orderUID string) ([]domain.AuditEntry, error) {
+	return r.repo.GetAuditLog(ctx, orderUID)
+}
+
 func (r *CachedRepo) DeleteOrder(ctx context.Context, orderUID string) error {
 	r.log.Debug("deleting order from database")
 
-	if err := r.repo.DeleteOrder(ctx, orderUID); err != nil {
+	err := r.repo.DeleteOrder(ctx, orderUID)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
 		r.log.Error("failed to delete order from database", "error", err)
 		return err
 	}
-	r.log.Info("order deleted from database successfully", "orderUID", orderUID)
+
+	// Запись могла остаться в кэше, даже если в базе заказа уже нет
+	if cacheErr := r.cache.DeleteOrder(ctx, orderUID); cacheErr != nil {
+		r.log.Warn("failed to delete order from cache", "error", cacheErr, "orderUID", orderUID)
+	}
+	if err != nil {
+		return err
+	}
+
+	r.log.Info("order deleted successfully", "orderUID", orderUID)
 	return nil
 }
 
@@ -152,7 +284,8 @@ func warmUpCache(ctx context.Context, capacity int, repo OrderRepository, cache
 			log.Warn("Cache warm-up interrupted by context cancellation")
 			return ctx.Err()
 		default:
-			order, err := repo.GetOrderByUID(ctx, orderUID)
+			// Кэш заполняется только из primary, реплика может отставать
+			order, err := repo.GetOrderByUID(domain.WithPrimaryRead(ctx), orderUID)
 			if err != nil {
 				log.Warn("Failed to get order for cache warm-up",
 					"order_uid", orderUID,
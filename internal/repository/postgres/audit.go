@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"wb_l0/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const insertAuditQuery = `
+        INSERT INTO order_audit (order_uid, actor, operation, diff) VALUES ($1, $2, $3, $4)`
+
+// insertAudit записывает изменение заказа в журнал аудита в транзакции самого изменения,
+// поэтому запись появляется тогда и только тогда, когда изменение зафиксировано
+func insertAudit(ctx context.Context, tx pgx.Tx, orderUID, actor, operation string, before, after any) error {
+	diff, err := auditDiff(before, after)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, insertAuditQuery, orderUID, actor, operation, diff); err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", classifyError(err))
+	}
+	return nil
+}
+
+// queueAudit добавляет запись аудита в пакет самого изменения
+func queueAudit(b *writeBatch, orderUID, actor, operation string, before, after any) error {
+	diff, err := auditDiff(before, after)
+	if err != nil {
+		return err
+	}
+	b.queue("insert audit entry", insertAuditQuery, orderUID, actor, operation, diff)
+	return nil
+}
+
+func auditDiff(before, after any) (string, error) {
+	diff, err := domain.Diff(before, after)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit diff: %w", err)
+	}
+	return string(data), nil
+}
+
+// GetAuditLog возвращает журнал аудита заказа в порядке изменений. Журнал сохраняется и после удаления заказа.
+func (s *Store) GetAuditLog(ctx context.Context, orderUID string) ([]domain.AuditEntry, error) {
+	return routeRead(ctx, s, []string{orderUID}, func(db pool) ([]domain.AuditEntry, error) {
+		return getAuditLog(ctx, db, orderUID)
+	})
+}
+
+func getAuditLog(ctx context.Context, db pool, orderUID string) ([]domain.AuditEntry, error) {
+	rows, err := db.Query(ctx, `
+        SELECT id, order_uid, actor, operation, diff, created_at
+        FROM order_audit
+        WHERE order_uid = $1
+        ORDER BY id`, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	var entries []domain.AuditEntry
+	for rows.Next() {
+		var entry domain.AuditEntry
+		var diff []byte
+		if err := rows.Scan(&entry.ID, &entry.OrderUID, &entry.Actor, &entry.Operation, &diff,
+			&entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if err := json.Unmarshal(diff, &entry.Diff); err != nil {
+			return nil, fmt.Errorf("failed to decode audit diff: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit log: %w", classifyError(err))
+	}
+	return entries, nil
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wb_l0/internal/domain"
+	"wb_l0/pkg/logger"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_GetAuditLog(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	store := &Store{db: mock, log: logger.NewTestLogger()}
+
+	const uid = "b563feb7b2b84b6test1"
+	columns := []string{"id", "order_uid", "actor", "operation", "diff", "created_at"}
+
+	t.Run("entries in order", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT.*FROM order_audit`).
+			WithArgs(uid).
+			WillReturnRows(pgxmock.NewRows(columns).
+				AddRow(1, uid, "kafka", domain.AuditCreate, []byte(`{"order_uid":{"before":null,"after":"`+uid+`"}}`),
+					time.Now()).
+				AddRow(2, uid, "http:ops", domain.AuditItemStatus,
+					[]byte(`{"items[chrt_id=1].status":{"before":200,"after":202}}`), time.Now()))
+
+		entries, err := store.GetAuditLog(context.Background(), uid)
+
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, domain.AuditChange{After: uid}, entries[0].Diff["order_uid"])
+		assert.Equal(t, "http:ops", entries[1].Actor)
+		assert.Equal(t, domain.AuditChange{Before: float64(200), After: float64(202)},
+			entries[1].Diff["items[chrt_id=1].status"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no entries", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT.*FROM order_audit`).
+			WithArgs(uid).
+			WillReturnRows(pgxmock.NewRows(columns))
+
+		entries, err := store.GetAuditLog(context.Background(), uid)
+
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// batchSender - пул или транзакция: пакет запросов уходит в базу за один round-trip
+type batchSender interface {
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// writeBatch - пакет изменяющих запросов. Запросы выполняются по порядку, первая ошибка
+// останавливает пакет и возвращается с описанием запроса. Пакет, отправленный через пул, а не
+// транзакцию, выполняется в неявной транзакции: при ошибке не применяется ни один запрос.
+type writeBatch struct {
+	batch pgx.Batch
+	ops   []batchOp
+	// learned - id значений справочников, созданных пакетом. Попадают в кэш после фиксации.
+	learned []dictEntry
+	pending map[dictEntry]struct{}
+}
+
+type batchOp struct {
+	name string
+	scan func(row pgx.Row) error
+}
+
+// queue добавляет запрос в пакет, op описывает его в тексте ошибки
+func (w *writeBatch) queue(op, query string, args ...any) {
+	w.batch.Queue(query, args...)
+	w.ops = append(w.ops, batchOp{name: op})
+}
+
+// queueRow добавляет запрос, единственная строка результата которого передаётся в scan
+func (w *writeBatch) queueRow(op, query string, scan func(row pgx.Row) error, args ...any) {
+	w.batch.Queue(query, args...)
+	w.ops = append(w.ops, batchOp{name: op, scan: scan})
+}
+
+func (w *writeBatch) exec(ctx context.Context, sender batchSender) error {
+	br := sender.SendBatch(ctx, &w.batch)
+	for _, op := range w.ops {
+		var err error
+		if op.scan != nil {
+			err = op.scan(br.QueryRow())
+		} else {
+			_, err = br.Exec()
+		}
+		if err != nil {
+			return closeBatch(br, fmt.Errorf("failed to %s: %w", op.name, classifyError(err)))
+		}
+	}
+	return closeBatch(br, nil)
+}
+
+// closeBatch закрывает результаты пакета и возвращает err, а если его нет - ошибку закрытия
+func closeBatch(br pgx.BatchResults, err error) error {
+	if closeErr := br.Close(); err == nil && closeErr != nil {
+		return fmt.Errorf("failed to close batch: %w", classifyError(closeErr))
+	}
+	return err
+}
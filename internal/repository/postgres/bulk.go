@@ -0,0 +1,225 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"wb_l0/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Временные таблицы загрузки живут до конца транзакции. Заказ с доставкой и оплатой - одна строка
+// import_orders, позиции - строки import_items.
+const createImportTablesQuery = `
+        CREATE TEMP TABLE import_orders (
+            order_uid TEXT NOT NULL, track_number TEXT NOT NULL, entry TEXT NOT NULL, locale TEXT NOT NULL,
+            internal_signature TEXT NOT NULL, customer_id TEXT NOT NULL, delivery_service TEXT NOT NULL,
+            shardkey TEXT NOT NULL, sm_id INTEGER NOT NULL, date_created TIMESTAMPTZ NOT NULL,
+            oof_shard TEXT NOT NULL, payload_hash TEXT NOT NULL, audit_diff TEXT NOT NULL,
+            delivery_name TEXT NOT NULL, delivery_phone TEXT NOT NULL, delivery_zip TEXT NOT NULL,
+            delivery_city TEXT NOT NULL, delivery_address TEXT NOT NULL, delivery_region TEXT NOT NULL,
+            delivery_email TEXT NOT NULL,
+            request_id TEXT NOT NULL, currency TEXT NOT NULL, provider TEXT NOT NULL, amount INTEGER NOT NULL,
+            payment_dt BIGINT NOT NULL, bank TEXT NOT NULL, delivery_cost INTEGER NOT NULL,
+            goods_total INTEGER NOT NULL, custom_fee INTEGER NOT NULL
+        ) ON COMMIT DROP;
+        CREATE TEMP TABLE import_items (
+            order_uid TEXT NOT NULL, line_no INTEGER NOT NULL, chrt_id BIGINT NOT NULL, track_number TEXT NOT NULL,
+            price INTEGER NOT NULL, rid TEXT NOT NULL, name TEXT NOT NULL, sale INTEGER NOT NULL, size TEXT NOT NULL,
+            total_price INTEGER NOT NULL, nm_id BIGINT NOT NULL, brand TEXT NOT NULL, status_id INTEGER NOT NULL,
+            quantity INTEGER NOT NULL
+        ) ON COMMIT DROP`
+
+var (
+	importOrderColumns = []string{
+		"order_uid", "track_number", "entry", "locale", "internal_signature", "customer_id", "delivery_service",
+		"shardkey", "sm_id", "date_created", "oof_shard", "payload_hash", "audit_diff",
+		"delivery_name", "delivery_phone", "delivery_zip", "delivery_city", "delivery_address", "delivery_region",
+		"delivery_email",
+		"request_id", "currency", "provider", "amount", "payment_dt", "bank", "delivery_cost", "goods_total",
+		"custom_fee",
+	}
+	importItemColumns = []string{
+		"order_uid", "line_no", "chrt_id", "track_number", "price", "rid", "name", "sale", "size", "total_price",
+		"nm_id", "brand", "status_id", "quantity",
+	}
+)
+
+// skipExistingImportQuery убирает из загрузки заказы, которые уже есть в базе, поэтому
+// остальные запросы загрузки работают только с новыми заказами
+const skipExistingImportQuery = `
+        DELETE FROM import_orders io USING orders o WHERE o.order_uid = io.order_uid`
+
+const insertImportedOrdersQuery = `
+        INSERT INTO orders (
+            order_uid, track_number, entry, locale, internal_signature,
+            customer_id, delivery_service_id, shardkey, sm_id, date_created, oof_shard, version, payload_hash
+        )
+        SELECT io.order_uid, io.track_number, io.entry, io.locale, io.internal_signature,
+            io.customer_id, ds.service_id, io.shardkey, io.sm_id, io.date_created, io.oof_shard, 1, io.payload_hash
+        FROM import_orders io
+        JOIN delivery_services ds ON ds.name = io.delivery_service`
+
+type importStep struct {
+	op    string
+	query string
+}
+
+// importDictionarySteps создают недостающие значения справочников одним запросом на справочник
+var importDictionarySteps = []importStep{
+	{"create delivery services", `
+        INSERT INTO delivery_services (name)
+        SELECT DISTINCT delivery_service FROM import_orders
+        ON CONFLICT (name) DO NOTHING`},
+	{"create payment providers", `
+        INSERT INTO payment_providers (name)
+        SELECT DISTINCT provider FROM import_orders
+        ON CONFLICT (name) DO NOTHING`},
+	{"create brands", `
+        INSERT INTO brands (name)
+        SELECT DISTINCT ii.brand FROM import_items ii JOIN import_orders io ON io.order_uid = ii.order_uid
+        ON CONFLICT (name) DO NOTHING`},
+}
+
+// importRelationSteps переносят доставку, оплату и снимки позиций сохранённых заказов
+var importRelationSteps = []importStep{
+	{"insert deliveries", `
+        INSERT INTO delivery (order_uid, name, phone, zip, city, address, region, email)
+        SELECT order_uid, delivery_name, delivery_phone, delivery_zip, delivery_city, delivery_address,
+            delivery_region, delivery_email
+        FROM import_orders`},
+	{"insert payments", `
+        INSERT INTO payment (
+            transaction, request_id, currency_id, provider_id, amount,
+            payment_dt, bank, delivery_cost, goods_total, custom_fee
+        )
+        SELECT io.order_uid, io.request_id, io.currency, pp.provider_id, io.amount,
+            io.payment_dt, io.bank, io.delivery_cost, io.goods_total, io.custom_fee
+        FROM import_orders io
+        JOIN payment_providers pp ON pp.name = io.provider`},
+	{"insert item snapshots", `
+        INSERT INTO order_item_snapshots (
+            order_uid, line_no, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id,
+            brand_id, status_id, quantity
+        )
+        SELECT ii.order_uid, ii.line_no, ii.chrt_id, ii.track_number, ii.price, ii.rid, ii.name, ii.sale, ii.size,
+            ii.total_price, ii.nm_id, b.brand_id, ii.status_id, ii.quantity
+        FROM import_items ii
+        JOIN import_orders io ON io.order_uid = ii.order_uid
+        JOIN brands b ON b.name = ii.brand`},
+}
+
+const insertImportAuditQuery = `
+        INSERT INTO order_audit (order_uid, actor, operation, diff)
+        SELECT order_uid, $1, $2, audit_diff::jsonb FROM import_orders`
+
+// BulkImport сохраняет новые заказы одной транзакцией: заказы копируются командой COPY во временные
+// таблицы и переносятся в основные set-based запросами. Заказы, которые уже есть в базе или повторяются
+// в orders, пропускаются. Заказы должны быть провалидированы.
+func (s *Store) BulkImport(ctx context.Context, orders []*domain.Order) (domain.ImportResult, error) {
+	startTime := time.Now()
+	var result domain.ImportResult
+
+	orderRows := make([][]any, 0, len(orders))
+	var itemRows [][]any
+	seen := make(map[string]struct{}, len(orders))
+	for _, order := range orders {
+		if _, ok := seen[order.OrderUID]; ok {
+			result.Skipped++
+			continue
+		}
+		seen[order.OrderUID] = struct{}{}
+
+		payloadHash, err := order.PayloadHash()
+		if err != nil {
+			return domain.ImportResult{}, fmt.Errorf("failed to hash order %s: %w", order.OrderUID, err)
+		}
+		order.Version = 1
+		defaultQuantities(order)
+		diff, err := auditDiff(nil, order)
+		if err != nil {
+			return domain.ImportResult{}, err
+		}
+		orderRows = append(orderRows, []any{
+			order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
+			order.CustomerID, order.DeliveryService, order.ShardKey, order.SMID, order.DateCreated,
+			order.OOFShard, payloadHash, diff,
+			order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip, order.Delivery.City,
+			order.Delivery.Address, order.Delivery.Region, order.Delivery.Email,
+			order.Payment.RequestID, order.Payment.Currency, order.Payment.Provider, order.Payment.Amount,
+			order.Payment.PaymentDT, order.Payment.Bank, order.Payment.DeliveryCost, order.Payment.GoodsTotal,
+			order.Payment.CustomFee,
+		})
+		for i, item := range order.Items {
+			itemRows = append(itemRows, []any{
+				order.OrderUID, i, item.ChrtID, item.TrackNumber, item.Price, item.RID, item.Name, item.Sale,
+				item.Size, item.TotalPrice, item.NMID, item.Brand, item.Status, item.Quantity,
+			})
+		}
+	}
+
+	if len(orderRows) == 0 {
+		return result, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return domain.ImportResult{}, fmt.Errorf("failed to begin transaction: %w", classifyError(err))
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, createImportTablesQuery); err != nil {
+		return domain.ImportResult{}, fmt.Errorf("failed to create import tables: %w", classifyError(err))
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"import_orders"}, importOrderColumns,
+		pgx.CopyFromRows(orderRows)); err != nil {
+		return domain.ImportResult{}, fmt.Errorf("failed to copy orders: %w", classifyError(err))
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"import_items"}, importItemColumns,
+		pgx.CopyFromRows(itemRows)); err != nil {
+		return domain.ImportResult{}, fmt.Errorf("failed to copy items: %w", classifyError(err))
+	}
+
+	tag, err := tx.Exec(ctx, skipExistingImportQuery)
+	if err != nil {
+		return domain.ImportResult{}, fmt.Errorf("failed to skip existing orders: %w", classifyError(err))
+	}
+	result.Skipped += int(tag.RowsAffected())
+
+	if err := execImportSteps(ctx, tx, importDictionarySteps); err != nil {
+		return domain.ImportResult{}, err
+	}
+	if tag, err = tx.Exec(ctx, insertImportedOrdersQuery); err != nil {
+		return domain.ImportResult{}, fmt.Errorf("failed to insert orders: %w", classifyError(err))
+	}
+	result.Inserted = int(tag.RowsAffected())
+	if err := execImportSteps(ctx, tx, importRelationSteps); err != nil {
+		return domain.ImportResult{}, err
+	}
+	if _, err := tx.Exec(ctx, insertImportAuditQuery, domain.ActorFromContext(ctx), domain.AuditCreate); err != nil {
+		return domain.ImportResult{}, fmt.Errorf("failed to insert audit entries: %w", classifyError(err))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return domain.ImportResult{}, fmt.Errorf("failed to commit transaction: %w", classifyError(err))
+	}
+
+	s.log.Info("Orders imported",
+		"orders", len(orders),
+		"inserted", result.Inserted,
+		"skipped", result.Skipped,
+		"items", len(itemRows),
+		"processing_time_ms", time.Since(startTime).Milliseconds(),
+	)
+	return result, nil
+}
+
+func execImportSteps(ctx context.Context, tx pgx.Tx, steps []importStep) error {
+	for _, step := range steps {
+		if _, err := tx.Exec(ctx, step.query); err != nil {
+			return fmt.Errorf("failed to %s: %w", step.op, classifyError(err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"wb_l0/internal/domain"
+	"wb_l0/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_BulkImport(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	store := &Store{db: mock, log: logger.NewTestLogger()}
+
+	newOrders := func() []*domain.Order {
+		first, second := domain.CreateTestOrder(1), domain.CreateTestOrder(2)
+		duplicate := domain.CreateTestOrder(1)
+		return []*domain.Order{&first, &second, &duplicate}
+	}
+	expectStaging := func() {
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TEMP TABLE import_orders.*CREATE TEMP TABLE import_items`).
+			WillReturnResult(pgxmock.NewResult("CREATE TABLE", 0))
+		mock.ExpectCopyFrom(pgx.Identifier{"import_orders"}, importOrderColumns).WillReturnResult(2)
+		mock.ExpectCopyFrom(pgx.Identifier{"import_items"}, importItemColumns).WillReturnResult(2)
+	}
+
+	t.Run("stages with copy and merges new orders", func(t *testing.T) {
+		expectStaging()
+		mock.ExpectExec(`DELETE FROM import_orders io USING orders`).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mock.ExpectExec(`INSERT INTO delivery_services.*SELECT DISTINCT`).
+			WillReturnResult(pgxmock.NewResult("INSERT", 0))
+		mock.ExpectExec(`INSERT INTO payment_providers.*SELECT DISTINCT`).
+			WillReturnResult(pgxmock.NewResult("INSERT", 0))
+		mock.ExpectExec(`INSERT INTO brands.*SELECT DISTINCT`).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec(`INSERT INTO orders.*FROM import_orders`).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec(`INSERT INTO delivery .*FROM import_orders`).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec(`INSERT INTO payment .*FROM import_orders`).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec(`INSERT INTO order_item_snapshots.*FROM import_items`).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec(`INSERT INTO order_audit.*FROM import_orders`).
+			WithArgs("cli:import", domain.AuditCreate).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+
+		result, err := store.BulkImport(domain.WithActor(context.Background(), "cli:import"), newOrders())
+
+		require.NoError(t, err)
+		// Повтор внутри загрузки и заказ, уже сохранённый в базе
+		assert.Equal(t, domain.ImportResult{Inserted: 1, Skipped: 2}, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failed merge rolls back the chunk", func(t *testing.T) {
+		expectStaging()
+		mock.ExpectExec(`DELETE FROM import_orders io USING orders`).
+			WillReturnResult(pgxmock.NewResult("DELETE", 0))
+		mock.ExpectExec(`INSERT INTO delivery_services.*SELECT DISTINCT`).
+			WillReturnError(errors.New("check violation"))
+		mock.ExpectRollback()
+
+		result, err := store.BulkImport(context.Background(), newOrders())
+
+		assert.ErrorContains(t, err, "failed to create delivery services: check violation")
+		assert.Zero(t, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
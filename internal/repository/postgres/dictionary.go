@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"wb_l0/pkg/prometheus"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// dictionary - справочник, на значения которого по имени ссылаются заказы и позиции
+type dictionary struct {
+	name     string // имя в метриках и текстах ошибок
+	table    string
+	idColumn string
+}
+
+var (
+	deliveryServices = dictionary{name: "delivery service", table: "delivery_services", idColumn: "service_id"}
+	paymentProviders = dictionary{name: "payment provider", table: "payment_providers", idColumn: "provider_id"}
+	brands           = dictionary{name: "brand", table: "brands", idColumn: "brand_id"}
+
+	dictionaries = []dictionary{deliveryServices, paymentProviders, brands}
+)
+
+// upsertQuery создаёт значение справочника или обновляет существующее, чтобы RETURNING всегда вернул id
+func (d dictionary) upsertQuery() string {
+	return fmt.Sprintf(`INSERT INTO %s (name) VALUES ($1) ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+        RETURNING %s`, d.table, d.idColumn)
+}
+
+type dictEntry struct {
+	dict dictionary
+	name string
+	id   int
+}
+
+// dictCache - id значений справочников, известные процессу. Значения справочников не удаляются,
+// поэтому кэш не инвалидируется. Нулевое значение готово к работе.
+type dictCache struct {
+	mu  sync.RWMutex
+	ids map[dictionary]map[string]int
+}
+
+func (c *dictCache) get(d dictionary, name string) (int, bool) {
+	c.mu.RLock()
+	id, ok := c.ids[d][name]
+	c.mu.RUnlock()
+
+	result := "miss"
+	if ok {
+		result = "hit"
+	}
+	prometheus.DictionaryCacheRequests.WithLabelValues(d.name, result).Inc()
+	return id, ok
+}
+
+// add запоминает id. Вызывается только после фиксации транзакции, создавшей значения.
+func (c *dictCache) add(entries ...dictEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ids == nil {
+		c.ids = make(map[dictionary]map[string]int, len(dictionaries))
+	}
+	for _, e := range entries {
+		if c.ids[e.dict] == nil {
+			c.ids[e.dict] = make(map[string]int)
+		}
+		c.ids[e.dict][e.name] = e.id
+	}
+	for _, d := range dictionaries {
+		prometheus.DictionaryCacheEntries.WithLabelValues(d.name).Set(float64(len(c.ids[d])))
+	}
+}
+
+// ref возвращает SQL-выражение id значения name для параметра $param и аргумент запроса.
+// Известный id передаётся параметром, справочник не читается. Иначе в пакет добавляется создание
+// значения с запоминанием id в b.learned, а запрос находит id подзапросом по имени.
+func (c *dictCache) ref(b *writeBatch, d dictionary, name string, param int) (string, any) {
+	if id, ok := c.get(d, name); ok {
+		return fmt.Sprintf("$%d", param), id
+	}
+
+	key := dictEntry{dict: d, name: name}
+	if _, ok := b.pending[key]; !ok {
+		if b.pending == nil {
+			b.pending = make(map[dictEntry]struct{})
+		}
+		b.pending[key] = struct{}{}
+		b.queueRow("create "+d.name, d.upsertQuery(), func(row pgx.Row) error {
+			entry := key
+			if err := row.Scan(&entry.id); err != nil {
+				return err
+			}
+			b.learned = append(b.learned, entry)
+			return nil
+		}, name)
+	}
+	return fmt.Sprintf("(SELECT %s FROM %s WHERE name = $%d)", d.idColumn, d.table, param), name
+}
+
+// LoadDictionaries заполняет кэш id всеми значениями справочников одним пакетом запросов
+func (s *Store) LoadDictionaries(ctx context.Context) error {
+	var batch pgx.Batch
+	for _, d := range dictionaries {
+		batch.Queue(fmt.Sprintf(`SELECT name, %s FROM %s`, d.idColumn, d.table))
+	}
+	br := s.db.SendBatch(ctx, &batch)
+
+	var entries []dictEntry
+	for _, d := range dictionaries {
+		rows, err := br.Query()
+		if err != nil {
+			return closeBatch(br, fmt.Errorf("failed to query %s: %w", d.table, classifyError(err)))
+		}
+		for rows.Next() {
+			entry := dictEntry{dict: d}
+			if err := rows.Scan(&entry.name, &entry.id); err != nil {
+				rows.Close()
+				return closeBatch(br, fmt.Errorf("failed to scan %s: %w", d.table, err))
+			}
+			entries = append(entries, entry)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return closeBatch(br, fmt.Errorf("failed to read %s: %w", d.table, classifyError(err)))
+		}
+	}
+	if err := closeBatch(br, nil); err != nil {
+		return err
+	}
+
+	s.dicts.add(entries...)
+	s.log.Info("Dictionary cache loaded", "entries", len(entries))
+	return nil
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"wb_l0/pkg/logger"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDictCache_Ref(t *testing.T) {
+	var cache dictCache
+	cache.add(dictEntry{dict: brands, name: "known", id: 7})
+
+	t.Run("known id is passed as parameter", func(t *testing.T) {
+		var batch writeBatch
+
+		expr, arg := cache.ref(&batch, brands, "known", 10)
+
+		assert.Equal(t, "$10", expr)
+		assert.Equal(t, 7, arg)
+		assert.Empty(t, batch.ops)
+	})
+
+	t.Run("unknown name is created once per batch", func(t *testing.T) {
+		var batch writeBatch
+
+		expr, arg := cache.ref(&batch, brands, "new", 10)
+		cache.ref(&batch, brands, "new", 10)
+
+		assert.Equal(t, "(SELECT brand_id FROM brands WHERE name = $10)", expr)
+		assert.Equal(t, "new", arg)
+		require.Len(t, batch.ops, 1)
+		assert.Equal(t, "create brand", batch.ops[0].name)
+	})
+
+	t.Run("dictionaries do not share names", func(t *testing.T) {
+		_, ok := cache.get(deliveryServices, "known")
+
+		assert.False(t, ok)
+	})
+}
+
+func TestStore_LoadDictionaries(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	t.Run("fills cache from all dictionaries", func(t *testing.T) {
+		store := &Store{db: mock, log: logger.NewTestLogger()}
+
+		batch := mock.ExpectBatch()
+		batch.ExpectQuery(`SELECT name, service_id FROM delivery_services`).
+			WillReturnRows(pgxmock.NewRows([]string{"name", "service_id"}).AddRow("wbexpress", 1))
+		batch.ExpectQuery(`SELECT name, provider_id FROM payment_providers`).
+			WillReturnRows(pgxmock.NewRows([]string{"name", "provider_id"}).AddRow("wbpay", 1).AddRow("sbp", 2))
+		batch.ExpectQuery(`SELECT name, brand_id FROM brands`).
+			WillReturnRows(pgxmock.NewRows([]string{"name", "brand_id"}))
+
+		err := store.LoadDictionaries(context.Background())
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		id, ok := store.dicts.get(paymentProviders, "sbp")
+		assert.True(t, ok)
+		assert.Equal(t, 2, id)
+	})
+
+	t.Run("query error leaves cache empty", func(t *testing.T) {
+		store := &Store{db: mock, log: logger.NewTestLogger()}
+
+		batch := mock.ExpectBatch()
+		batch.ExpectQuery(`SELECT name, service_id FROM delivery_services`).
+			WillReturnRows(pgxmock.NewRows([]string{"name", "service_id"}).AddRow("wbexpress", 1))
+		batch.ExpectQuery(`SELECT name, provider_id FROM payment_providers`).
+			WillReturnError(errors.New("connection reset"))
+		batch.ExpectExec(`SELECT name, brand_id FROM brands`).
+			WillReturnResult(pgxmock.NewResult("SELECT", 0))
+
+		err := store.LoadDictionaries(context.Background())
+
+		assert.ErrorContains(t, err, "failed to query payment_providers")
+		_, ok := store.dicts.get(deliveryServices, "wbexpress")
+		assert.False(t, ok)
+	})
+}
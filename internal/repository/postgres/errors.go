@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"wb_l0/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	pgUniqueViolation      = "23505"
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+	pgLockNotAvailable     = "55P03"
+	pgAdminShutdown        = "57P01"
+	pgCrashShutdown        = "57P02"
+	pgCannotConnectNow     = "57P03"
+
+	pgClassDataException       = "22"
+	pgClassIntegrityConstraint = "23"
+	pgClassConnectionException = "08"
+	pgClassInsufficientRes     = "53"
+)
+
+// classifyError сопоставляет ошибку базы данных с доменной ошибкой
+// (ErrValidation, ErrConflict, ErrTransient). Неизвестные ошибки возвращаются как есть.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == pgUniqueViolation:
+			return fmt.Errorf("%w: %w", domain.ErrConflict, err)
+		case strings.HasPrefix(pgErr.Code, pgClassIntegrityConstraint),
+			strings.HasPrefix(pgErr.Code, pgClassDataException):
+			return fmt.Errorf("%w: %w", domain.ErrValidation, err)
+		case pgErr.Code == pgSerializationFailure, pgErr.Code == pgDeadlockDetected,
+			pgErr.Code == pgLockNotAvailable, pgErr.Code == pgAdminShutdown,
+			pgErr.Code == pgCrashShutdown, pgErr.Code == pgCannotConnectNow,
+			strings.HasPrefix(pgErr.Code, pgClassConnectionException),
+			strings.HasPrefix(pgErr.Code, pgClassInsufficientRes):
+			return fmt.Errorf("%w: %w", domain.ErrTransient, err)
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr) || pgconn.SafeToRetry(err) {
+		return fmt.Errorf("%w: %w", domain.ErrTransient, err)
+	}
+	return err
+}
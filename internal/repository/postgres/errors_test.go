@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"wb_l0/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"unique violation", &pgconn.PgError{Code: "23505"}, domain.ErrConflict},
+		{"check violation", &pgconn.PgError{Code: "23514"}, domain.ErrValidation},
+		{"foreign key violation", &pgconn.PgError{Code: "23503"}, domain.ErrValidation},
+		{"invalid text representation", &pgconn.PgError{Code: "22P02"}, domain.ErrValidation},
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, domain.ErrTransient},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, domain.ErrTransient},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, domain.ErrTransient},
+		{"too many connections", &pgconn.PgError{Code: "53300"}, domain.ErrTransient},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, domain.ErrTransient},
+		{"bad connection", fmt.Errorf("exec: %w", driver.ErrBadConn), domain.ErrTransient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyError(tt.err)
+
+			assert.True(t, errors.Is(err, tt.want), "got %v", err)
+			assert.True(t, errors.Is(err, tt.err))
+		})
+	}
+
+	t.Run("context cancellation is not transient", func(t *testing.T) {
+		err := classifyError(context.Canceled)
+
+		assert.Equal(t, context.Canceled, err)
+		assert.False(t, errors.Is(err, domain.ErrTransient))
+	})
+
+	t.Run("unknown error is returned as is", func(t *testing.T) {
+		original := errors.New("syntax error")
+
+		assert.Equal(t, original, classifyError(original))
+		assert.Nil(t, classifyError(nil))
+	})
+}
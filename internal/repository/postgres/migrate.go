@@ -0,0 +1,251 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationLockID - ключ advisory lock, под которым миграции применяет только один процесс
+const migrationLockID int64 = 0x77624f5344
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migrationHooks - шаги миграций на Go по имени миграции для данных, которые нельзя вычислить в SQL.
+// Хук выполняется после SQL применения в той же транзакции.
+var migrationHooks = map[string]func(s *Store, ctx context.Context, tx pgx.Tx) error{
+	"order_payload_hash": (*Store).backfillPayloadHashes,
+}
+
+// Migration - версия схемы: SQL применения и отката из файлов NNNN_name.up.sql и NNNN_name.down.sql
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus - миграция и время её применения, nil для неприменённой
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// loadMigrations читает миграции из fsys, упорядоченные по версии. У каждой версии должны быть оба файла.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files with different names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func embeddedMigrations() ([]Migration, error) {
+	sub, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return loadMigrations(sub)
+}
+
+// MigrateUp применяет все неприменённые миграции и возвращает их. Каждая миграция выполняется
+// в отдельной транзакции под advisory lock, при ошибке применение останавливается.
+func (s *Store) MigrateUp(ctx context.Context) ([]Migration, error) {
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		done, err := s.migrationStep(ctx, func(tx pgx.Tx, applied map[int]time.Time) (bool, error) {
+			if _, ok := applied[m.Version]; ok {
+				return false, nil
+			}
+			s.log.Info("Applying migration", "version", m.Version, "name", m.Name)
+			if err := runMigration(ctx, tx, m.Up,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name); err != nil {
+				return false, fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+			}
+			if hook, ok := migrationHooks[m.Name]; ok {
+				if err := hook(s, ctx, tx); err != nil {
+					return false, fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			return applied, err
+		}
+		if done {
+			applied = append(applied, m)
+		}
+	}
+	return applied, nil
+}
+
+// MigrateDown откатывает steps последних применённых миграций и возвращает их
+func (s *Store) MigrateDown(ctx context.Context, steps int) ([]Migration, error) {
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		m := migrations[i]
+		done, err := s.migrationStep(ctx, func(tx pgx.Tx, applied map[int]time.Time) (bool, error) {
+			if _, ok := applied[m.Version]; !ok {
+				return false, nil
+			}
+			s.log.Info("Reverting migration", "version", m.Version, "name", m.Name)
+			if err := runMigration(ctx, tx, m.Down,
+				`DELETE FROM schema_migrations WHERE version = $1`, m.Version); err != nil {
+				return false, fmt.Errorf("rollback of migration %d_%s failed: %w", m.Version, m.Name, err)
+			}
+			return true, nil
+		})
+		if err != nil {
+			return reverted, err
+		}
+		if done {
+			reverted = append(reverted, m)
+		}
+	}
+	return reverted, nil
+}
+
+// MigrationStatus возвращает все встроенные миграции с отметкой о применении
+func (s *Store) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	_, err = s.migrationStep(ctx, func(_ pgx.Tx, applied map[int]time.Time) (bool, error) {
+		for _, m := range migrations {
+			status := MigrationStatus{Version: m.Version, Name: m.Name}
+			if appliedAt, ok := applied[m.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return false, nil
+	})
+	return statuses, err
+}
+
+// migrationStep выполняет fn в транзакции под advisory lock, который снимается при её завершении.
+// Перед fn создаётся schema_migrations и читается набор применённых версий.
+// Транзакция фиксируется, только если fn вернула true.
+func (s *Store) migrationStep(ctx context.Context,
+	fn func(tx pgx.Tx, applied map[int]time.Time) (bool, error)) (bool, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return false, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version INTEGER PRIMARY KEY,
+            name VARCHAR(255) NOT NULL,
+            applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+        )`); err != nil {
+		return false, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	applied, err := appliedMigrations(ctx, tx)
+	if err != nil {
+		return false, err
+	}
+
+	done, err := fn(tx, applied)
+	if err != nil || !done {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return true, nil
+}
+
+func appliedMigrations(ctx context.Context, tx pgx.Tx) (map[int]time.Time, error) {
+	rows, err := tx.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// runMigration выполняет SQL миграции и запись в schema_migrations в транзакции шага
+func runMigration(ctx context.Context, tx pgx.Tx, script, record string, args ...any) error {
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, record, args...); err != nil {
+		return fmt.Errorf("failed to update schema_migrations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+	"wb_l0/internal/domain"
+	"wb_l0/pkg/logger"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("ordered by version", func(t *testing.T) {
+		migrations, err := loadMigrations(fstest.MapFS{
+			"0010_add_index.up.sql":   {Data: []byte("CREATE INDEX")},
+			"0010_add_index.down.sql": {Data: []byte("DROP INDEX")},
+			"0002_seed.up.sql":        {Data: []byte("INSERT")},
+			"0002_seed.down.sql":      {Data: []byte("DELETE")},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, migrations, 2)
+		assert.Equal(t, Migration{Version: 2, Name: "seed", Up: "INSERT", Down: "DELETE"}, migrations[0])
+		assert.Equal(t, 10, migrations[1].Version)
+	})
+
+	t.Run("missing down file", func(t *testing.T) {
+		_, err := loadMigrations(fstest.MapFS{"0001_init.up.sql": {Data: []byte("CREATE TABLE")}})
+
+		assert.ErrorContains(t, err, "must have both up and down files")
+	})
+
+	t.Run("unexpected file name", func(t *testing.T) {
+		_, err := loadMigrations(fstest.MapFS{"init.sql": {Data: []byte("CREATE TABLE")}})
+
+		assert.ErrorContains(t, err, "unexpected migration file")
+	})
+
+	t.Run("embedded migrations are valid", func(t *testing.T) {
+		migrations, err := embeddedMigrations()
+
+		require.NoError(t, err)
+		require.NotEmpty(t, migrations)
+		assert.Equal(t, 1, migrations[0].Version)
+		assert.Contains(t, migrations[0].Up, "CREATE TABLE IF NOT EXISTS orders")
+		assert.NotContains(t, migrations[0].Up, "EXCEPTION WHEN others")
+		// Изменения после исходной схемы - отдельные версии, иначе существующие базы их не получат
+		assert.NotContains(t, migrations[0].Up, "payload_hash")
+		assert.NotContains(t, migrations[0].Up, "order_audit")
+	})
+}
+
+// expectMigrationStep ожидает начало шага миграции: транзакцию, блокировку и чтение применённых версий
+func expectMigrationStep(mock pgxmock.PgxPoolIface, applied ...int) {
+	rows := pgxmock.NewRows([]string{"version", "applied_at"})
+	for _, version := range applied {
+		rows.AddRow(version, time.Now())
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WithArgs(migrationLockID).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).
+		WillReturnResult(pgxmock.NewResult("CREATE TABLE", 0))
+	mock.ExpectQuery(`SELECT version, applied_at FROM schema_migrations`).WillReturnRows(rows)
+}
+
+func TestStore_MigrateUp(t *testing.T) {
+	migrations, err := embeddedMigrations()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(migrations), 2)
+
+	t.Run("applies pending migrations one transaction each", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+		store := &Store{db: mock, log: logger.NewTestLogger()}
+
+		expectMigrationStep(mock, 1)
+		mock.ExpectRollback()
+		var done []int
+		for _, m := range migrations[1:] {
+			done = append(done, m.Version)
+			expectMigrationStep(mock, done[:len(done)-1]...)
+			mock.ExpectExec(`.+`).WillReturnResult(pgxmock.NewResult("", 0))
+			mock.ExpectExec(`INSERT INTO schema_migrations`).WithArgs(m.Version, m.Name).
+				WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			if m.Name == "order_payload_hash" {
+				expectOrdersWithoutHash(mock)
+			}
+			mock.ExpectCommit()
+			mock.ExpectRollback()
+		}
+
+		applied, err := store.MigrateUp(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, migrations[1:], applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failed migration stops and rolls back", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+		store := &Store{db: mock, log: logger.NewTestLogger()}
+
+		expectMigrationStep(mock)
+		mock.ExpectExec(`.+`).WillReturnError(errors.New("syntax error"))
+		mock.ExpectRollback()
+
+		applied, err := store.MigrateUp(context.Background())
+
+		assert.ErrorContains(t, err, "migration 1_init_tables failed: syntax error")
+		assert.Empty(t, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func expectOrdersWithoutHash(mock pgxmock.PgxPoolIface, orderUIDs ...string) {
+	rows := pgxmock.NewRows([]string{"order_uid"})
+	for _, uid := range orderUIDs {
+		rows.AddRow(uid)
+	}
+	mock.ExpectQuery(`SELECT order_uid FROM orders WHERE payload_hash = ''`).WillReturnRows(rows)
+}
+
+func TestStore_BackfillPayloadHashes(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	store := &Store{db: mock, log: logger.NewTestLogger()}
+
+	order := domain.CreateTestOrder(1)
+	hash, err := order.PayloadHash()
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	expectOrdersWithoutHash(mock, order.OrderUID)
+	mock.ExpectQuery(`SELECT.*FROM orders.*ANY\(\$1\)`).
+		WithArgs([]string{order.OrderUID}).
+		WillReturnRows(pgxmock.NewRows(orderColumns).AddRow(storedOrderRow(&order)...))
+	expectRelations(mock.ExpectBatch(), &order)
+	mock.ExpectExec(`UPDATE orders o SET payload_hash = v.hash`).
+		WithArgs([]string{order.OrderUID}, []string{hash}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectRollback()
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+	err = store.backfillPayloadHashes(context.Background(), tx)
+	require.NoError(t, tx.Rollback(context.Background()))
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_MigrateDown(t *testing.T) {
+	migrations, err := embeddedMigrations()
+	require.NoError(t, err)
+	last := migrations[len(migrations)-1]
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	store := &Store{db: mock, log: logger.NewTestLogger()}
+
+	versions := make([]int, 0, len(migrations))
+	for _, m := range migrations {
+		versions = append(versions, m.Version)
+	}
+	expectMigrationStep(mock, versions...)
+	mock.ExpectExec(`.+`).WillReturnResult(pgxmock.NewResult("", 0))
+	mock.ExpectExec(`DELETE FROM schema_migrations WHERE version = \$1`).WithArgs(last.Version).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	reverted, err := store.MigrateDown(context.Background(), 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, []Migration{last}, reverted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS items;
+DROP TABLE IF EXISTS payment;
+DROP TABLE IF EXISTS delivery;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS currencies;
+DROP TABLE IF EXISTS item_statuses;
+DROP TABLE IF EXISTS brands;
+DROP TABLE IF EXISTS delivery_services;
+DROP TABLE IF EXISTS payment_providers;
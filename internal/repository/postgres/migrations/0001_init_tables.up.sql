@@ -1,5 +1,3 @@
-DO $$
-BEGIN
 CREATE TABLE IF NOT EXISTS payment_providers (
     provider_id SERIAL PRIMARY KEY,
     name VARCHAR(50) UNIQUE NOT NULL CHECK (name ~ '^[A-Za-z0-9 ]+$')
@@ -88,7 +86,3 @@ CREATE TABLE IF NOT EXISTS order_items (
 );
 
 CREATE INDEX IF NOT EXISTS idx_order_items_order_uid ON order_items(order_uid);
-
-EXCEPTION WHEN others THEN
-  RAISE NOTICE 'Ошибка при создании таблиц: %', SQLERRM;
-END $$;
\ No newline at end of file
@@ -0,0 +1,5 @@
+DELETE FROM currencies WHERE currency_id IN ('USD', 'EUR', 'RUB', 'CNY');
+DELETE FROM delivery_services WHERE name IN ('meest', 'pochta', 'sdek');
+DELETE FROM payment_providers WHERE name IN ('wbpay', 'mir', 'ukassa');
+DELETE FROM brands WHERE name IN ('Vivienne Sabo', 'Nike', 'Guess', 'Uniqlo');
+DELETE FROM item_statuses WHERE status_id IN (200, 202, 300, 400);
@@ -1,5 +1,3 @@
-DO $$
-BEGIN
 INSERT INTO item_statuses (status_id, description) VALUES
     (200, 'Created'),
     (202, 'Approved'),
@@ -33,7 +31,3 @@ INSERT INTO currencies (currency_id, name, symbol) VALUES
     ('CNY', 'Chinese Yuan', '¥')
     ON CONFLICT (currency_id) DO UPDATE
         SET name = EXCLUDED.name, symbol = EXCLUDED.symbol;
-
-EXCEPTION WHEN others THEN
-  RAISE NOTICE 'Ошибка при заполнении справочников: %', SQLERRM;
-END $$;
\ No newline at end of file
@@ -0,0 +1,40 @@
+-- Откат возвращает общую таблицу items: позиция с одним chrt_id берётся из самого нового заказа,
+-- строки заказа с одной позицией складываются в quantity
+CREATE TABLE IF NOT EXISTS items (
+    id SERIAL PRIMARY KEY,
+    chrt_id BIGINT NOT NULL UNIQUE CHECK (chrt_id > 0),
+    track_number VARCHAR(255) NOT NULL,
+    price INTEGER NOT NULL CHECK (price > 0),
+    rid VARCHAR(255) NOT NULL CHECK (rid ~ '^[a-f0-9]{20}$'),
+    name VARCHAR(255) NOT NULL,
+    sale INTEGER NOT NULL CHECK (sale BETWEEN 0 AND 100),
+    size VARCHAR(50) NOT NULL,
+    total_price INTEGER NOT NULL CHECK (total_price >= 0),
+    nm_id BIGINT NOT NULL CHECK (nm_id > 0),
+    brand_id INTEGER REFERENCES brands(brand_id),
+    status_id INTEGER REFERENCES item_statuses(status_id)
+    );
+
+CREATE TABLE IF NOT EXISTS order_items (
+    order_uid VARCHAR(255) REFERENCES orders(order_uid) ON DELETE CASCADE,
+    item_id BIGINT REFERENCES items(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    PRIMARY KEY (order_uid, item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_order_uid ON order_items(order_uid);
+
+INSERT INTO items (chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand_id, status_id)
+SELECT DISTINCT ON (s.chrt_id) s.chrt_id, s.track_number, s.price, s.rid, s.name, s.sale, s.size,
+    s.total_price, s.nm_id, s.brand_id, s.status_id
+FROM order_item_snapshots s
+JOIN orders o ON o.order_uid = s.order_uid
+ORDER BY s.chrt_id, o.date_created DESC;
+
+INSERT INTO order_items (order_uid, item_id, quantity)
+SELECT s.order_uid, i.id, SUM(s.quantity)
+FROM order_item_snapshots s
+JOIN items i ON i.chrt_id = s.chrt_id
+GROUP BY s.order_uid, i.id;
+
+DROP TABLE order_item_snapshots;
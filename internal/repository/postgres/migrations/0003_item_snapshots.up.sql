@@ -0,0 +1,33 @@
+-- Позиции хранятся снимками строк заказа: повторный chrt_id в новом заказе больше не меняет цену,
+-- скидку и статус позиции в старых заказах. Общая таблица items перезаписывалась каждым заказом,
+-- поэтому снимки существующих заказов получают последний сохранённый вариант позиции.
+CREATE TABLE order_item_snapshots (
+    order_uid VARCHAR(20) NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+    line_no INTEGER NOT NULL CHECK (line_no >= 0),
+    chrt_id BIGINT NOT NULL CHECK (chrt_id > 0),
+    track_number VARCHAR(255) NOT NULL,
+    price INTEGER NOT NULL CHECK (price > 0),
+    rid VARCHAR(255) NOT NULL CHECK (rid ~ '^[a-f0-9]{20}$'),
+    name VARCHAR(255) NOT NULL,
+    sale INTEGER NOT NULL CHECK (sale BETWEEN 0 AND 100),
+    size VARCHAR(50) NOT NULL,
+    total_price INTEGER NOT NULL CHECK (total_price >= 0),
+    nm_id BIGINT NOT NULL CHECK (nm_id > 0),
+    brand_id INTEGER REFERENCES brands(brand_id),
+    status_id INTEGER REFERENCES item_statuses(status_id),
+    quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    PRIMARY KEY (order_uid, line_no)
+    );
+
+INSERT INTO order_item_snapshots (
+    order_uid, line_no, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id,
+    brand_id, status_id, quantity
+)
+SELECT oi.order_uid, ROW_NUMBER() OVER (PARTITION BY oi.order_uid ORDER BY i.chrt_id) - 1, i.chrt_id,
+    i.track_number, i.price, i.rid, i.name, i.sale, i.size, i.total_price, i.nm_id,
+    i.brand_id, i.status_id, oi.quantity
+FROM order_items oi
+JOIN items i ON i.id = oi.item_id;
+
+DROP TABLE order_items;
+DROP TABLE items;
@@ -0,0 +1,2 @@
+-- Версия заказа для оптимистичной блокировки обновлений, существующие заказы получают версию 1
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1 CHECK (version > 0);
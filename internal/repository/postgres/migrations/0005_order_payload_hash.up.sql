@@ -0,0 +1,3 @@
+-- Хеш данных заказа от источника для различения повторов и конфликтующих дубликатов.
+-- Хеши существующих заказов вычисляются в Go после этого скрипта в той же транзакции.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS payload_hash CHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS item_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    order_uid VARCHAR(20) NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+    chrt_id BIGINT NOT NULL,
+    from_status INTEGER NOT NULL REFERENCES item_statuses(status_id),
+    to_status INTEGER NOT NULL REFERENCES item_statuses(status_id),
+    actor VARCHAR(255) NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_item_status_history_order ON item_status_history(order_uid, id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS payment_refunds;
+DROP TABLE IF EXISTS order_cancellations;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS order_cancellations (
+    order_uid VARCHAR(20) PRIMARY KEY REFERENCES orders(order_uid) ON DELETE CASCADE,
+    reason VARCHAR(255) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    cancelled_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS payment_refunds (
+    id BIGSERIAL PRIMARY KEY,
+    transaction VARCHAR(255) NOT NULL REFERENCES payment(transaction) ON DELETE CASCADE,
+    chrt_id BIGINT,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    reason VARCHAR(255) NOT NULL,
+    request_id VARCHAR(255),
+    actor VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (transaction, request_id)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_transaction ON payment_refunds(transaction, id);
@@ -0,0 +1,21 @@
+-- Журнал аудита не ссылается на orders, чтобы пережить удаление заказа
+CREATE TABLE IF NOT EXISTS order_audit (
+    id BIGSERIAL PRIMARY KEY,
+    order_uid VARCHAR(20) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    operation VARCHAR(32) NOT NULL,
+    diff JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_order_audit_order ON order_audit(order_uid, id);
+
+CREATE OR REPLACE FUNCTION order_audit_append_only() RETURNS trigger AS $fn$
+BEGIN
+  RAISE EXCEPTION 'order_audit is append-only';
+END
+$fn$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER order_audit_append_only
+    BEFORE UPDATE OR DELETE ON order_audit
+    FOR EACH ROW EXECUTE FUNCTION order_audit_append_only();
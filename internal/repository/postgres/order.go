@@ -3,11 +3,69 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 	"wb_l0/internal/domain"
+	"wb_l0/pkg/prometheus"
+
+	"github.com/jackc/pgx/v5"
 )
 
+const selectOrderQuery = `
+        SELECT 
+            o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature,
+            o.customer_id, ds.name as delivery_service, o.shardkey, o.sm_id, 
+            o.date_created, o.oof_shard, o.version,
+            d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+            p.transaction, p.request_id, c.currency_id, pp.name as provider_name,
+            p.amount, p.payment_dt, p.bank, p.delivery_cost, p.goods_total, p.custom_fee,
+            oc.reason, oc.actor, oc.cancelled_at
+        FROM orders o
+        JOIN delivery d ON o.order_uid = d.order_uid
+        JOIN payment p ON o.order_uid = p.transaction
+        JOIN delivery_services ds ON o.delivery_service_id = ds.service_id
+        JOIN payment_providers pp ON p.provider_id = pp.provider_id
+        JOIN currencies c ON p.currency_id = c.currency_id
+        LEFT JOIN order_cancellations oc ON o.order_uid = oc.order_uid
+        `
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanOrder читает строку selectOrderQuery, без позиций заказа
+func scanOrder(row rowScanner) (*domain.Order, error) {
+	var order domain.Order
+	var cancelReason, cancelActor sql.NullString
+	var cancelledAt sql.NullTime
+	err := row.Scan(
+		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature,
+		&order.CustomerID, &order.DeliveryService, &order.ShardKey, &order.SMID,
+		&order.DateCreated, &order.OOFShard, &order.Version,
+		&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip, &order.Delivery.City,
+		&order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email,
+		&order.Payment.Transaction, &order.Payment.RequestID, &order.Payment.Currency, &order.Payment.Provider,
+		&order.Payment.Amount, &order.Payment.PaymentDT, &order.Payment.Bank,
+		&order.Payment.DeliveryCost, &order.Payment.GoodsTotal, &order.Payment.CustomFee,
+		&cancelReason, &cancelActor, &cancelledAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if cancelledAt.Valid {
+		order.Cancellation = &domain.Cancellation{
+			Reason:      cancelReason.String,
+			Actor:       cancelActor.String,
+			CancelledAt: cancelledAt.Time,
+		}
+	}
+	return &order, nil
+}
+
+// SaveOrder сохраняет новый заказ за два round-trip: проверка существующего заказа
+// и один пакет со всеми вставками, который выполняется в неявной транзакции
 func (s *Store) SaveOrder(ctx context.Context, order *domain.Order) error {
 	startTime := time.Now()
 	s.log.Info("Database operation started",
@@ -16,66 +74,66 @@ func (s *Store) SaveOrder(ctx context.Context, order *domain.Order) error {
 		"items_count", len(order.Items),
 	)
 
-	exists, err := s.checkOrderExists(ctx, order.OrderUID)
+	payloadHash, err := order.PayloadHash()
+	if err != nil {
+		return fmt.Errorf("failed to hash order: %w", err)
+	}
+	storedHash, exists, err := s.getPayloadHash(ctx, order.OrderUID)
 	if err != nil {
 		s.log.Error("Failed to check order existence",
 			"order_uid", order.OrderUID,
 			"error", err.Error(),
 		)
-		return fmt.Errorf("failed to check order existence: %w", err)
+		return fmt.Errorf("failed to check order existence: %w", classifyError(err))
 	}
 	if exists {
-		s.log.Warn("Order already exists - skipping processing",
-			"order_uid", order.OrderUID,
-			"action", "skip_duplicate",
-		)
-		return nil
+		return s.duplicateError(ctx, order, payloadHash, storedHash)
 	}
+	order.Version = max(order.Version, 1)
+	defaultQuantities(order)
 
-	tx, err := s.db.BeginTx(ctx, nil)
+	batch, err := s.saveOrderBatch(ctx, order, payloadHash)
 	if err != nil {
-		s.log.Error("Failed to begin transaction",
-			"order_uid", order.OrderUID,
-			"error", err.Error(),
-			"operation", "begin_transaction",
-		)
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return err
 	}
-	defer tx.Rollback()
-
-	deliveryServiceID, err := s.getOrCreateDeliveryServiceID(ctx, tx, order)
-	if err != nil {
-		s.log.Error("Failed to create delivery service",
-			"delivery_service", order.DeliveryService,
+	if err := batch.exec(ctx, s.db); err != nil {
+		s.log.Error("Failed to save order",
 			"order_uid", order.OrderUID,
 			"error", err.Error(),
 		)
-		return fmt.Errorf("failed to create delivery service: %w", err)
+		return err
 	}
+	s.dicts.add(batch.learned...)
+	s.recent.mark(order.OrderUID)
 
-	_, err = tx.ExecContext(ctx, `
+	s.log.Info("Order saved successfully",
+		"order_uid", order.OrderUID,
+		"total_processing_time_ms", time.Since(startTime).Milliseconds(),
+		"status", "completed",
+	)
+	return nil
+}
+
+// saveOrderBatch собирает пакет вставки нового заказа. Id справочных значений из кэша передаются
+// параметрами, остальные значения создаются в том же пакете, а их id подставляются подзапросами,
+// поэтому пакету не нужны результаты предыдущих запросов.
+func (s *Store) saveOrderBatch(ctx context.Context, order *domain.Order, payloadHash string) (*writeBatch, error) {
+	batch := &writeBatch{}
+	serviceID, serviceArg := s.dicts.ref(batch, deliveryServices, order.DeliveryService, 7)
+	batch.queue("insert order", fmt.Sprintf(`
         INSERT INTO orders (
             order_uid, track_number, entry, locale, internal_signature,
-            customer_id, delivery_service_id, shardkey, sm_id, date_created, oof_shard
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-        ON CONFLICT (order_uid) DO NOTHING`,
+            customer_id, delivery_service_id, shardkey, sm_id, date_created, oof_shard, version, payload_hash
+        ) VALUES (
+            $1, $2, $3, $4, $5, $6, %s,
+            $8, $9, $10, $11, $12, $13
+        )
+        ON CONFLICT (order_uid) DO NOTHING`, serviceID),
 		order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
-		order.CustomerID, deliveryServiceID, order.ShardKey, order.SMID, order.DateCreated, order.OOFShard,
-	)
-	if err != nil {
-		s.log.Error("Failed to insert order",
-			"order_uid", order.OrderUID,
-			"error", err.Error(),
-			"table", "orders",
-		)
-		return fmt.Errorf("failed to insert order: %w", err)
-	}
-	s.log.Debug("Order inserted successfully",
-		"order_uid", order.OrderUID,
-		"table", "orders",
+		order.CustomerID, serviceArg, order.ShardKey, order.SMID, order.DateCreated, order.OOFShard,
+		order.Version, payloadHash,
 	)
-
-	_, err = tx.ExecContext(ctx, `
+	batch.queue("insert delivery", `
         INSERT INTO delivery (
             order_uid, name, phone, zip, city, address, region, email
         ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
@@ -83,124 +141,63 @@ func (s *Store) SaveOrder(ctx context.Context, order *domain.Order) error {
 		order.OrderUID, order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip,
 		order.Delivery.City, order.Delivery.Address, order.Delivery.Region, order.Delivery.Email,
 	)
-	if err != nil {
-		s.log.Error("Failed to insert delivery",
-			"order_uid", order.OrderUID,
-			"error", err.Error(),
-			"table", "delivery",
-		)
-		return fmt.Errorf("failed to insert delivery: %w", err)
-	}
-
-	paymentProviderID, err := s.getOrCreatePaymentProviderID(ctx, tx, order)
-	if err != nil {
-		s.log.Error("Failed to create payment provider",
-			"payment_provider", order.Payment.Provider,
-			"order_uid", order.OrderUID,
-			"error", err.Error(),
-		)
-		return fmt.Errorf("failed to create delivery service: %w", err)
-	}
-
-	_, err = tx.ExecContext(ctx, `
+	providerID, providerArg := s.dicts.ref(batch, paymentProviders, order.Payment.Provider, 4)
+	batch.queue("insert payment", fmt.Sprintf(`
         INSERT INTO payment (
             transaction, request_id, currency_id, provider_id, amount, 
             payment_dt, bank, delivery_cost, goods_total, custom_fee
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-        ON CONFLICT (transaction) DO NOTHING`,
+        ) VALUES (
+            $1, $2, $3, %s, $5,
+            $6, $7, $8, $9, $10
+        )
+        ON CONFLICT (transaction) DO NOTHING`, providerID),
 		order.Payment.Transaction, order.Payment.RequestID, order.Payment.Currency,
-		paymentProviderID, order.Payment.Amount, order.Payment.PaymentDT,
+		providerArg, order.Payment.Amount, order.Payment.PaymentDT,
 		order.Payment.Bank, order.Payment.DeliveryCost, order.Payment.GoodsTotal,
 		order.Payment.CustomFee,
 	)
-	if err != nil {
-		s.log.Error("Failed to insert payment",
-			"order_uid", order.OrderUID,
-			"error", err.Error(),
-			"table", "payment",
-		)
-		return fmt.Errorf("failed to insert payment: %w", err)
+	s.queueItems(batch, order)
+	if err := queueAudit(batch, order.OrderUID, domain.ActorFromContext(ctx), domain.AuditCreate,
+		nil, order); err != nil {
+		return nil, err
 	}
+	return batch, nil
+}
 
+// queueItems добавляет в пакет снимки позиций заказа. Снимок не меняется при сохранении других заказов
+// с той же позицией, номер строки сохраняет порядок позиций в заказе.
+func (s *Store) queueItems(batch *writeBatch, order *domain.Order) {
 	for i, item := range order.Items {
-		brandID, err := s.getOrCreateBrandID(ctx, tx, item)
-		if err != nil {
-			s.log.Error("Failed to create brand",
-				"brand", order.Items[i].Brand,
-				"order_uid", order.OrderUID,
-				"error", err.Error(),
-			)
-			return fmt.Errorf("failed to create brand: %w", err)
-		}
-
-		var itemID int
-		err = tx.QueryRowContext(ctx, `
-            INSERT INTO items (
-                chrt_id, track_number, price, rid, 
-                name, sale, size, total_price, nm_id, brand_id, status_id
-            ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-            ON CONFLICT (chrt_id) DO UPDATE SET
-                track_number = EXCLUDED.track_number,
-                price = EXCLUDED.price,
-                rid = EXCLUDED.rid,
-                name = EXCLUDED.name,
-                sale = EXCLUDED.sale,
-                size = EXCLUDED.size,
-                total_price = EXCLUDED.total_price,
-                nm_id = EXCLUDED.nm_id,
-                brand_id = EXCLUDED.brand_id,
-                status_id = EXCLUDED.status_id
-                RETURNING id`,
-			item.ChrtID, item.TrackNumber, item.Price, item.RID,
-			item.Name, item.Sale, item.Size, item.TotalPrice, item.NMID, brandID, item.Status,
-		).Scan(&itemID)
-		if err != nil {
-			s.log.Error("Failed to insert item",
-				"order_uid", order.OrderUID,
-				"chrt_id", item.ChrtID,
-				"error", err.Error(),
-				"table", "items",
-			)
-			return fmt.Errorf("failed to insert/update item %d: %w", item.ChrtID, err)
-		}
-
-		_, err = tx.ExecContext(ctx, `
-            INSERT INTO order_items (order_uid, item_id, quantity)
-            VALUES ($1, $2, $3)
-            ON CONFLICT (order_uid, item_id) DO UPDATE SET
-                quantity = order_items.quantity + 1`,
-			order.OrderUID, itemID, 1,
+		brandID, brandArg := s.dicts.ref(batch, brands, item.Brand, 12)
+		batch.queue(fmt.Sprintf("insert item %d", item.ChrtID), fmt.Sprintf(`
+            INSERT INTO order_item_snapshots (
+                order_uid, line_no, chrt_id, track_number, price, rid,
+                name, sale, size, total_price, nm_id, brand_id, status_id, quantity
+            ) VALUES (
+                $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, %s, $13, $14
+            )`, brandID),
+			order.OrderUID, i, item.ChrtID, item.TrackNumber, item.Price, item.RID,
+			item.Name, item.Sale, item.Size, item.TotalPrice, item.NMID, brandArg, item.Status, item.Quantity,
 		)
-		if err != nil {
-			s.log.Error("Failed to create order-item link",
-				"order_uid", order.OrderUID,
-				"item_id", itemID,
-				"chrt_id", item.ChrtID,
-				"error", err.Error(),
-				"table", "order_items",
-			)
-			return fmt.Errorf("failed to create order-item link %d: %w", item.ChrtID, err)
-		}
 	}
+}
 
-	if err := tx.Commit(); err != nil {
-		s.log.Error("Failed to commit transaction",
-			"order_uid", order.OrderUID,
-			"error", err.Error(),
-			"operation", "commit",
-		)
-		return fmt.Errorf("failed to commit transaction: %w", err)
+// defaultQuantities проставляет одну единицу позициям без quantity, чтобы снимки и аудит
+// хранили реальное количество
+func defaultQuantities(order *domain.Order) {
+	for i := range order.Items {
+		order.Items[i].Quantity = max(order.Items[i].Quantity, 1)
 	}
-
-	s.log.Info("Order saved successfully",
-		"order_uid", order.OrderUID,
-		"total_processing_time_ms", time.Since(startTime).Milliseconds(),
-		"status", "completed",
-	)
-	return nil
 }
 
+// GetOrderByUID читает заказ с позициями, историей статусов и возвратами одним пакетом запросов
 func (s *Store) GetOrderByUID(ctx context.Context, orderUID string) (*domain.Order, error) {
+	return routeRead(ctx, s, []string{orderUID}, func(db pool) (*domain.Order, error) {
+		return s.getOrderByUID(ctx, db, orderUID)
+	})
+}
+
+func (s *Store) getOrderByUID(ctx context.Context, db pool, orderUID string) (*domain.Order, error) {
 	startTime := time.Now()
 
 	s.log.Info("Database query started",
@@ -208,51 +205,21 @@ func (s *Store) GetOrderByUID(ctx context.Context, orderUID string) (*domain.Ord
 		"order_uid", orderUID,
 		"query_type", "read",
 	)
-	query := `
-        SELECT 
-            o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature,
-            o.customer_id, ds.name as delivery_service, o.shardkey, o.sm_id, 
-            o.date_created, o.oof_shard,
-            d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
-            p.transaction, p.request_id, c.currency_id, pp.name as provider_name,
-            p.amount, p.payment_dt, p.bank, p.delivery_cost, p.goods_total, p.custom_fee
-        FROM orders o
-        JOIN delivery d ON o.order_uid = d.order_uid
-        JOIN payment p ON o.order_uid = p.transaction
-        JOIN delivery_services ds ON o.delivery_service_id = ds.service_id
-        JOIN payment_providers pp ON p.provider_id = pp.provider_id
-        JOIN currencies c ON p.currency_id = c.currency_id
-        WHERE o.order_uid = $1
-    `
-
-	var order domain.Order
-	var paymentProvider, currency string
-
-	s.log.Debug("Executing SQL query",
-		"order_uid", orderUID,
-		"query", "GetOrder_main",
-		"tables", []string{"orders", "delivery", "payment", "delivery_services", "payment_providers", "currencies"},
-	)
 
-	err := s.db.QueryRowContext(ctx, query, orderUID).Scan(
-		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature,
-		&order.CustomerID, &order.DeliveryService, &order.ShardKey, &order.SMID,
-		&order.DateCreated, &order.OOFShard,
-		&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip, &order.Delivery.City,
-		&order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email,
-		&order.Payment.Transaction, &order.Payment.RequestID, &currency, &paymentProvider,
-		&order.Payment.Amount, &order.Payment.PaymentDT, &order.Payment.Bank,
-		&order.Payment.DeliveryCost, &order.Payment.GoodsTotal, &order.Payment.CustomFee,
-	)
+	var batch pgx.Batch
+	batch.Queue(selectOrderQuery+"WHERE o.order_uid = $1", orderUID)
+	queueRelations(&batch, []string{orderUID})
+	br := db.SendBatch(ctx, &batch)
 
+	order, err := scanOrder(br.QueryRow())
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, pgx.ErrNoRows) {
 			s.log.Warn("Order not found",
 				"order_uid", orderUID,
 				"error", "not_found",
 				"query_time_ms", time.Since(startTime).Milliseconds(),
 			)
-			return nil, domain.ErrRecordNotFound
+			return nil, closeBatch(br, domain.ErrRecordNotFound)
 		}
 		s.log.Error("Failed to execute query",
 			"order_uid", orderUID,
@@ -260,227 +227,407 @@ func (s *Store) GetOrderByUID(ctx context.Context, orderUID string) (*domain.Ord
 			"error_type", "database_query",
 			"query_time_ms", time.Since(startTime).Milliseconds(),
 		)
-		return nil, fmt.Errorf("failed to get order: %w", err)
+		return nil, closeBatch(br, fmt.Errorf("failed to get order: %w", classifyError(err)))
 	}
 
-	order.Payment.Provider = paymentProvider
-	order.Payment.Currency = currency
-
-	s.log.Debug("Main order data retrieved",
-		"order_uid", orderUID,
-		"customer_id", order.CustomerID,
-		"delivery_service", order.DeliveryService,
-		"amount", order.Payment.Amount,
-		"query_time_ms", time.Since(startTime).Milliseconds(),
-	)
-	itemsStartTime := time.Now()
-	items, err := s.getOrderItems(ctx, orderUID)
-	if err != nil {
-		s.log.Error("Failed to get order items",
+	if err := closeBatch(br, readRelations(br, map[string]*domain.Order{orderUID: order})); err != nil {
+		s.log.Error("Failed to get order relations",
 			"order_uid", orderUID,
 			"error", err.Error(),
-			"error_type", "items_query",
-			"items_query_time_ms", time.Since(itemsStartTime).Milliseconds(),
+			"query_time_ms", time.Since(startTime).Milliseconds(),
 		)
-		return nil, fmt.Errorf("failed to get order items: %w", err)
+		return nil, err
 	}
-	order.Items = items
+
 	s.log.Info("Order retrieved successfully",
 		"order_uid", orderUID,
-		"items_count", len(items),
+		"items_count", len(order.Items),
 		"total_query_time_ms", time.Since(startTime).Milliseconds(),
-		"main_query_time_ms", time.Since(startTime).Milliseconds()-time.Since(itemsStartTime).Milliseconds(),
-		"items_query_time_ms", time.Since(itemsStartTime).Milliseconds(),
 		"status", "success",
 	)
-	return &order, nil
+	return order, nil
 }
 
-func (s *Store) getOrderItems(ctx context.Context, orderUID string) ([]domain.Item, error) {
-	itemsStartTime := time.Now()
-
-	s.log.Debug("Fetching order items",
-		"order_uid", orderUID,
-		"operation", "getOrderItems",
-	)
-	query := `
+const orderItemsQuery = `
         SELECT 
-            i.chrt_id, i.track_number, i.price, i.rid, i.name, i.sale, i.size,
-            i.total_price, i.nm_id, b.name as brand_name, i.status_id,
-            oi.quantity
-        FROM order_items oi
-        JOIN items i ON oi.item_id = i.id
-        JOIN brands b ON i.brand_id = b.brand_id
-        WHERE oi.order_uid = $1
+            oi.order_uid, oi.chrt_id, oi.track_number, oi.price, oi.rid, oi.name, oi.sale, oi.size,
+            oi.total_price, oi.nm_id, b.name as brand_name, oi.status_id, oi.quantity
+        FROM order_item_snapshots oi
+        JOIN brands b ON oi.brand_id = b.brand_id
+        WHERE oi.order_uid = ANY($1)
+        ORDER BY oi.order_uid, oi.line_no
     `
 
-	rows, err := s.db.QueryContext(ctx, query, orderUID)
-	if err != nil {
-		s.log.Error("Failed to query order items",
-			"order_uid", orderUID,
-			"error", err.Error(),
-			"query", "getOrderItems",
-		)
-		return nil, fmt.Errorf("failed to query order items: %w", err)
-	}
+// scanOrderItems читает результат orderItemsQuery: позиции, сгруппированные по заказам
+func scanOrderItems(rows pgx.Rows) (map[string][]domain.Item, error) {
 	defer rows.Close()
 
-	var items []domain.Item
-	var itemsProcessed int
-
+	items := make(map[string][]domain.Item)
 	for rows.Next() {
+		var orderUID string
 		var item domain.Item
-		var brand string
-		var quantity int
-
 		err := rows.Scan(
-			&item.ChrtID, &item.TrackNumber, &item.Price, &item.RID, &item.Name,
-			&item.Sale, &item.Size, &item.TotalPrice, &item.NMID, &brand, &item.Status,
-			&quantity,
+			&orderUID, &item.ChrtID, &item.TrackNumber, &item.Price, &item.RID, &item.Name,
+			&item.Sale, &item.Size, &item.TotalPrice, &item.NMID, &item.Brand, &item.Status, &item.Quantity,
 		)
 		if err != nil {
-			s.log.Error("Failed to scan item row",
-				"order_uid", orderUID,
-				"error", err.Error(),
-				"operation", "scan_row",
-			)
-			continue // Пропускаем проблемную строку, но продолжаем обработку
+			return nil, fmt.Errorf("failed to scan item row: %w", err)
 		}
-
-		item.Brand = brand
-		items = append(items, item)
-		itemsProcessed++
+		items[orderUID] = append(items[orderUID], item)
 	}
-
 	if err := rows.Err(); err != nil {
-		s.log.Error("Error iterating items",
-			"order_uid", orderUID,
-			"error", err.Error(),
-			"operation", "rows_iteration",
-		)
-		return nil, fmt.Errorf("error iterating items: %w", err)
+		return nil, fmt.Errorf("error iterating items: %w", classifyError(err))
 	}
-
-	s.log.Debug("Order items retrieved",
-		"order_uid", orderUID,
-		"items_count", itemsProcessed,
-		"query_time_ms", time.Since(itemsStartTime).Milliseconds(),
-	)
-
 	return items, nil
 }
 
-func (s *Store) DeleteOrder(ctx context.Context, orderUID string) error {
-	tx, err := s.db.BeginTx(ctx, nil)
+// queueRelations добавляет в пакет чтение позиций, истории статусов и возвратов заказов
+func queueRelations(batch *pgx.Batch, orderUIDs []string) {
+	batch.Queue(orderItemsQuery, orderUIDs)
+	batch.Queue(statusHistoryQuery, orderUIDs)
+	batch.Queue(refundsQuery, orderUIDs)
+}
+
+// readRelations читает результаты queueRelations в том же порядке и дополняет ими заказы
+func readRelations(br pgx.BatchResults, byUID map[string]*domain.Order) error {
+	rows, err := br.Query()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return fmt.Errorf("failed to query order items: %w", classifyError(err))
+	}
+	items, err := scanOrderItems(rows)
+	if err != nil {
+		return fmt.Errorf("failed to get order items: %w", err)
 	}
-	defer tx.Rollback()
 
-	var exists bool
-	err = tx.QueryRowContext(ctx, `
-        SELECT EXISTS(SELECT 1 FROM orders WHERE order_uid = $1)
-    `, orderUID).Scan(&exists)
+	if rows, err = br.Query(); err != nil {
+		return fmt.Errorf("failed to query status history: %w", classifyError(err))
+	}
+	history, err := scanStatusHistory(rows)
+	if err != nil {
+		return fmt.Errorf("failed to get status history: %w", err)
+	}
 
+	if rows, err = br.Query(); err != nil {
+		return fmt.Errorf("failed to query refunds: %w", classifyError(err))
+	}
+	refunds, err := scanRefunds(rows)
 	if err != nil {
-		return fmt.Errorf("failed to check order existence: %w", err)
+		return fmt.Errorf("failed to get refunds: %w", err)
 	}
 
-	if !exists {
-		return fmt.Errorf("order with UID %s not found", orderUID)
+	for uid, order := range byUID {
+		order.Items = items[uid]
+		order.Timeline = history[uid]
+		order.Refunds = refunds[uid]
 	}
+	return nil
+}
 
-	_, err = tx.ExecContext(ctx, `
-        DELETE FROM orders WHERE order_uid = $1
-    `, orderUID)
+// loadRelations дополняет заказы позициями, историей статусов и возвратами за один round-trip
+func loadRelations(ctx context.Context, db querier, byUID map[string]*domain.Order, orderUIDs []string) error {
+	var batch pgx.Batch
+	queueRelations(&batch, orderUIDs)
+	br := db.SendBatch(ctx, &batch)
+	return closeBatch(br, readRelations(br, byUID))
+}
+
+// GetOrdersByUIDs возвращает найденные заказы из списка за два запроса.
+// Отсутствующие UID просто не попадают в результат.
+func (s *Store) GetOrdersByUIDs(ctx context.Context, orderUIDs []string) ([]*domain.Order, error) {
+	return routeRead(ctx, s, orderUIDs, func(db pool) ([]*domain.Order, error) {
+		return s.getOrdersByUIDs(ctx, db, orderUIDs)
+	})
+}
 
+func (s *Store) getOrdersByUIDs(ctx context.Context, db querier, orderUIDs []string) ([]*domain.Order, error) {
+	startTime := time.Now()
+
+	s.log.Info("Database query started",
+		"operation", "GetOrdersByUIDs",
+		"requested", len(orderUIDs),
+		"query_type", "read",
+	)
+	if len(orderUIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := db.Query(ctx, selectOrderQuery+"WHERE o.order_uid = ANY($1)", orderUIDs)
 	if err != nil {
-		return fmt.Errorf("failed to delete order: %w", err)
+		s.log.Error("Failed to execute batch query",
+			"error", err.Error(),
+			"error_type", "database_query",
+			"query_time_ms", time.Since(startTime).Milliseconds(),
+		)
+		return nil, fmt.Errorf("failed to get orders: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	var orders []*domain.Order
+	byUID := make(map[string]*domain.Order, len(orderUIDs))
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, order)
+		byUID[order.OrderUID] = order
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating orders: %w", classifyError(err))
+	}
+	if len(orders) == 0 {
+		return nil, nil
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	found := make([]string, 0, len(orders))
+	for _, order := range orders {
+		found = append(found, order.OrderUID)
+	}
+	if err := loadRelations(ctx, db, byUID, found); err != nil {
+		return nil, err
 	}
 
-	return nil
+	s.log.Info("Orders retrieved successfully",
+		"requested", len(orderUIDs),
+		"found", len(orders),
+		"total_query_time_ms", time.Since(startTime).Milliseconds(),
+		"status", "success",
+	)
+	return orders, nil
 }
 
-func (s *Store) getOrCreateDeliveryServiceID(ctx context.Context, tx *sql.Tx, order *domain.Order) (int, error) {
-	var deliveryServiceID int
-	err := tx.QueryRowContext(ctx, `
-            SELECT service_id FROM delivery_services WHERE name = $1
-        `, order.DeliveryService).Scan(&deliveryServiceID)
+// ListOrders возвращает страницу заказов по фильтру, от новых к старым
+func (s *Store) ListOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error) {
+	return routeRead(ctx, s, nil, func(db pool) ([]*domain.Order, error) {
+		return s.listOrders(ctx, db, filter)
+	})
+}
+
+func (s *Store) listOrders(ctx context.Context, db pool, filter domain.OrderFilter) ([]*domain.Order, error) {
+	startTime := time.Now()
 
+	query, args := buildListQuery(filter)
+	rows, err := db.Query(ctx, query, args...)
 	if err != nil {
-		s.log.Debug("Delivery service not found, creating new",
-			"delivery_service", order.DeliveryService,
-			"order_uid", order.OrderUID,
+		s.log.Error("Failed to execute list query",
+			"error", err.Error(),
+			"error_type", "database_query",
+			"query_time_ms", time.Since(startTime).Milliseconds(),
 		)
-		err = tx.QueryRowContext(ctx, `
-                INSERT INTO delivery_services (name) VALUES ($1)
-                ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
-                RETURNING service_id
-            `, order.DeliveryService).Scan(&deliveryServiceID)
+		return nil, fmt.Errorf("failed to list orders: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	var orders []*domain.Order
+	byUID := make(map[string]*domain.Order)
+	uids := make([]string, 0, filter.Limit)
+	for rows.Next() {
+		order, err := scanOrder(rows)
 		if err != nil {
-			return 0, fmt.Errorf("failed to get/create DeliveryService: %w", err)
+			return nil, fmt.Errorf("failed to scan order: %w", err)
 		}
+		orders = append(orders, order)
+		byUID[order.OrderUID] = order
+		uids = append(uids, order.OrderUID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating orders: %w", classifyError(err))
+	}
+	if len(orders) == 0 {
+		return nil, nil
+	}
+
+	if err := loadRelations(ctx, db, byUID, uids); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Orders listed successfully",
+		"found", len(orders),
+		"total_query_time_ms", time.Since(startTime).Milliseconds(),
+	)
+	return orders, nil
+}
+
+// buildListQuery добавляет к selectOrderQuery условия фильтра и keyset-пагинацию
+func buildListQuery(filter domain.OrderFilter) (string, []any) {
+	var conditions []string
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.CustomerID != "" {
+		conditions = append(conditions, "o.customer_id = "+arg(filter.CustomerID))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		conditions = append(conditions, "o.date_created >= "+arg(filter.CreatedFrom))
+	}
+	if !filter.CreatedTo.IsZero() {
+		conditions = append(conditions, "o.date_created < "+arg(filter.CreatedTo))
+	}
+	if filter.After != nil {
+		conditions = append(conditions, fmt.Sprintf("(o.date_created, o.order_uid) < (%s, %s)",
+			arg(filter.After.DateCreated), arg(filter.After.OrderUID)))
+	}
+
+	query := selectOrderQuery
+	if len(conditions) > 0 {
+		query += "WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY o.date_created DESC, o.order_uid DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT " + arg(filter.Limit)
 	}
-	return deliveryServiceID, nil
+	return query, args
 }
 
-func (s *Store) getOrCreatePaymentProviderID(ctx context.Context, tx *sql.Tx, order *domain.Order) (int, error) {
-	var paymentProviderID int
-	err := tx.QueryRowContext(ctx, `
-            SELECT provider_id FROM payment_providers WHERE name = $1
-        `, order.Payment.Provider).Scan(&paymentProviderID)
+// DeleteOrder удаляет заказ. В журнал аудита попадают поля заказа без позиций, прочитанные под блокировкой.
+func (s *Store) DeleteOrder(ctx context.Context, orderUID string) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", classifyError(err))
+	}
+	defer tx.Rollback(ctx)
 
+	before, err := scanOrder(tx.QueryRow(ctx, selectOrderQuery+"WHERE o.order_uid = $1 FOR UPDATE OF o",
+		orderUID))
 	if err != nil {
-		err = tx.QueryRowContext(ctx, `
-                INSERT INTO payment_providers (name) VALUES ($1)
-                ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
-                RETURNING provider_id
-            `, order.Payment.Provider).Scan(&paymentProviderID)
-		if err != nil {
-			return 0, fmt.Errorf("failed to get/create PaymentProvider: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("order with UID %s: %w", orderUID, domain.ErrRecordNotFound)
 		}
+		return fmt.Errorf("failed to check order existence: %w", classifyError(err))
+	}
+
+	_, err = tx.Exec(ctx, `
+        DELETE FROM orders WHERE order_uid = $1
+    `, orderUID)
+
+	if err != nil {
+		return fmt.Errorf("failed to delete order: %w", classifyError(err))
 	}
-	return paymentProviderID, nil
+
+	if err := insertAudit(ctx, tx, orderUID, domain.ActorFromContext(ctx), domain.AuditDelete,
+		before, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", classifyError(err))
+	}
+	s.recent.mark(orderUID)
+	return nil
 }
 
-func (s *Store) getOrCreateBrandID(ctx context.Context, tx *sql.Tx, item domain.Item) (int, error) {
-	var brandID int
+// getPayloadHash возвращает хеш данных сохранённого заказа и признак его существования
+func (s *Store) getPayloadHash(ctx context.Context, orderUID string) (string, bool, error) {
+	var hash string
+	err := s.db.QueryRow(ctx, `
+        SELECT payload_hash FROM orders WHERE order_uid = $1
+    `, orderUID).Scan(&hash)
 
-	err := tx.QueryRowContext(ctx, `
-            SELECT brand_id FROM brands WHERE name = $1
-        `, item.Brand).Scan(&brandID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return hash, true, nil
+}
+
+// backfillChunk - сколько заказов читается за раз при заполнении payload_hash
+const backfillChunk = 500
 
+// backfillPayloadHashes вычисляет payload_hash заказов, сохранённых до его появления, чтобы повтор
+// такого заказа распознавался как повтор, а не как конфликт. Выполняется в транзакции миграции.
+func (s *Store) backfillPayloadHashes(ctx context.Context, tx pgx.Tx) error {
+	rows, err := tx.Query(ctx, `SELECT order_uid FROM orders WHERE payload_hash = ''`)
 	if err != nil {
-		err = tx.QueryRowContext(ctx, `
-                INSERT INTO brands (name) VALUES ($1)
-                ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
-                RETURNING brand_id
-            `, item.Brand).Scan(&brandID)
+		return fmt.Errorf("failed to query orders without payload hash: %w", err)
+	}
+	orderUIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed to scan orders without payload hash: %w", err)
+	}
+
+	for start := 0; start < len(orderUIDs); start += backfillChunk {
+		orders, err := s.getOrdersByUIDs(ctx, tx, orderUIDs[start:min(start+backfillChunk, len(orderUIDs))])
 		if err != nil {
-			return 0, fmt.Errorf("failed to get/create brand: %w", err)
+			return err
+		}
+		uids := make([]string, 0, len(orders))
+		hashes := make([]string, 0, len(orders))
+		for _, order := range orders {
+			hash, err := order.PayloadHash()
+			if err != nil {
+				return fmt.Errorf("failed to hash order %s: %w", order.OrderUID, err)
+			}
+			uids = append(uids, order.OrderUID)
+			hashes = append(hashes, hash)
+		}
+		if _, err := tx.Exec(ctx, `
+            UPDATE orders o SET payload_hash = v.hash
+            FROM unnest($1::text[], $2::text[]) AS v(order_uid, hash)
+            WHERE o.order_uid = v.order_uid
+        `, uids, hashes); err != nil {
+			return fmt.Errorf("failed to store payload hashes: %w", err)
 		}
 	}
-	return brandID, nil
+
+	s.log.Info("Payload hashes backfilled", "orders", len(orderUIDs))
+	return nil
 }
 
-func (s *Store) checkOrderExists(ctx context.Context, orderUID string) (bool, error) {
-	var exists bool
-	err := s.db.QueryRowContext(ctx, `
-        SELECT EXISTS(SELECT 1 FROM orders WHERE order_uid = $1)
-    `, orderUID).Scan(&exists)
+// duplicateError сравнивает повторный заказ с сохранённым: совпадающие данные - повтор того же
+// сообщения (domain.ErrAlreadyExists), иначе возвращается domain.ContentConflictError с расхождениями.
+// Результат учитывается в order_duplicates_total независимо от того, стоит ли перед хранилищем кэш.
+func (s *Store) duplicateError(ctx context.Context, order *domain.Order, payloadHash, storedHash string) error {
+	if payloadHash == storedHash {
+		prometheus.OrderDuplicates.WithLabelValues("replay").Inc()
+		s.log.Info("Order replay detected", "order_uid", order.OrderUID)
+		return fmt.Errorf("order %s: %w", order.OrderUID, domain.ErrAlreadyExists)
+	}
 
+	// Сохранённый заказ сравнивается с тем, что видит primary, а не отстающая реплика
+	stored, err := s.getOrderByUID(ctx, s.db, order.OrderUID)
 	if err != nil {
-		return false, err
+		return fmt.Errorf("failed to get stored order: %w", err)
 	}
-
-	return exists, nil
+	diff, err := stored.PayloadDiff(order)
+	if err != nil {
+		return fmt.Errorf("failed to diff orders: %w", err)
+	}
+	if len(diff) == 0 {
+		// Хеш не совпал, а данные совпадают: заказ сохранён без хеша или хеш устарел.
+		// Это повтор, хеш перезаписывается, чтобы следующий повтор не читал заказ целиком.
+		if _, err := s.db.Exec(ctx, `UPDATE orders SET payload_hash = $2 WHERE order_uid = $1`,
+			order.OrderUID, payloadHash); err != nil {
+			s.log.Warn("Failed to store payload hash",
+				"order_uid", order.OrderUID,
+				"error", err.Error(),
+			)
+		}
+		prometheus.OrderDuplicates.WithLabelValues("replay").Inc()
+		s.log.Info("Order replay detected", "order_uid", order.OrderUID, "stored_hash", storedHash)
+		return fmt.Errorf("order %s: %w", order.OrderUID, domain.ErrAlreadyExists)
+	}
+	conflict := &domain.ContentConflictError{OrderUID: order.OrderUID, Diff: diff}
+	prometheus.OrderDuplicates.WithLabelValues("conflict").Inc()
+	s.log.Warn("Order content conflict",
+		"order_uid", order.OrderUID,
+		"fields", conflict.Fields(),
+	)
+	return conflict
 }
 
 func (s *Store) GetLastOrdersUIDs(ctx context.Context, limit int) ([]string, error) {
+	return routeRead(ctx, s, nil, func(db pool) ([]string, error) {
+		return s.getLastOrdersUIDs(ctx, db, limit)
+	})
+}
+
+func (s *Store) getLastOrdersUIDs(ctx context.Context, db pool, limit int) ([]string, error) {
 	startTime := time.Now()
 
 	s.log.Info("Getting last orders UIDs from database",
@@ -496,14 +643,14 @@ func (s *Store) GetLastOrdersUIDs(ctx context.Context, limit int) ([]string, err
         LIMIT $1
     `
 
-	rows, err := s.db.QueryContext(ctx, query, limit)
+	rows, err := db.Query(ctx, query, limit)
 	if err != nil {
 		s.log.Error("Failed to execute query for last orders",
 			"error", err.Error(),
 			"error_type", "database_query",
 			"query_time_ms", time.Since(startTime).Milliseconds(),
 		)
-		return nil, fmt.Errorf("failed to get last orders: %w", err)
+		return nil, fmt.Errorf("failed to get last orders: %w", classifyError(err))
 	}
 	defer rows.Close()
 
@@ -525,7 +672,7 @@ func (s *Store) GetLastOrdersUIDs(ctx context.Context, limit int) ([]string, err
 			"error", err.Error(),
 			"query_time_ms", time.Since(startTime).Milliseconds(),
 		)
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+		return nil, fmt.Errorf("error iterating rows: %w", classifyError(err))
 	}
 
 	s.log.Info("Successfully retrieved last orders UIDs",
@@ -2,71 +2,66 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"testing"
 	"time"
 	"wb_l0/internal/domain"
 	"wb_l0/pkg/logger"
+	"wb_l0/pkg/prometheus"
 
-	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestStore_GetOrderByUID(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer db.Close()
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
 
 	log := logger.NewTestLogger()
-	store := &Store{db: db, log: log}
-
-	t.Run("successful get order", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{
-			"order_uid", "track_number", "entry", "locale", "internal_signature",
-			"customer_id", "delivery_service", "shardkey", "sm_id", "date_created", "oof_shard",
-			"name", "phone", "zip", "city", "address", "region", "email",
-			"transaction", "request_id", "currency_id", "provider_name",
-			"amount", "payment_dt", "bank", "delivery_cost", "goods_total", "custom_fee",
-		}).AddRow(
-			"00000000000000000000", "TRACK001", "WB", "en", "signature",
-			"customer123", "delivery-service", "shard1", 1, time.Now(), "oof1",
-			"John Doe", "+1234567890", "123456", "Moscow", "Street 1", "Moscow", "john@test.com",
-			"trans123", "req123", "USD", "provider1",
-			1000, time.Now().Unix(), "bank123", 100, 900, 0,
-		)
-
-		mock.ExpectQuery(`SELECT.*FROM orders`).
-			WithArgs("test-uid").
-			WillReturnRows(rows)
+	store := &Store{db: mock, log: log}
 
-		itemRows := sqlmock.NewRows([]string{
-			"chrt_id", "track_number", "price", "rid", "name", "sale", "size",
-			"total_price", "nm_id", "brand_name", "status_id", "quantity",
-		}).AddRow(
-			1, "TRACK001", 500, "rid001", "Test Item", 0, "M",
-			500, 123, "Test Brand", 200, 1,
-		)
+	t.Run("order and relations in one batch", func(t *testing.T) {
+		row := orderRow("00000000000000000000")
+		row[len(row)-3], row[len(row)-2], row[len(row)-1] = "customer request", "http:ops", time.Now()
 
-		mock.ExpectQuery(`SELECT.*FROM order_items`).
+		batch := mock.ExpectBatch()
+		batch.ExpectQuery(`SELECT.*FROM orders`).
 			WithArgs("test-uid").
-			WillReturnRows(itemRows)
+			WillReturnRows(pgxmock.NewRows(orderColumns).AddRow(row...))
+		batch.ExpectQuery(`SELECT.*FROM order_item_snapshots`).
+			WithArgs([]string{"test-uid"}).
+			WillReturnRows(pgxmock.NewRows(itemColumns).
+				AddRow("test-uid", 1, "TRACK001", 500, "rid001", "Test Item", 0, "M", 500, 123, "Test Brand", 200, 3))
+		batch.ExpectQuery(`SELECT.*FROM item_status_history`).
+			WithArgs([]string{"test-uid"}).
+			WillReturnRows(pgxmock.NewRows(historyColumns).
+				AddRow("test-uid", 1, 200, 202, "http:ops", time.Now()))
+		batch.ExpectQuery(`SELECT.*FROM payment_refunds`).
+			WithArgs([]string{"test-uid"}).
+			WillReturnRows(pgxmock.NewRows(refundColumns).
+				AddRow(int64(1), "test-uid", 0, 1000, "customer request", "", "http:ops", time.Now()))
 
 		order, err := store.GetOrderByUID(context.Background(), "test-uid")
 
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, "00000000000000000000", order.OrderUID)
-		assert.Len(t, order.Items, 1)
+		require.Len(t, order.Items, 1)
+		assert.Equal(t, 3, order.Items[0].Quantity)
+		require.Len(t, order.Timeline, 1)
+		assert.Equal(t, 202, order.Timeline[0].To)
+		require.NotNil(t, order.Cancellation)
+		assert.Equal(t, "customer request", order.Cancellation.Reason)
+		assert.Equal(t, 1000, order.RefundedAmount())
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("order not found", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT.*FROM orders`).
-			WithArgs("not-found").
-			WillReturnError(sql.ErrNoRows)
+		expectMissingOrderBatch(mock, "not-found")
 
 		order, err := store.GetOrderByUID(context.Background(), "not-found")
 
@@ -77,13 +72,216 @@ func TestStore_GetOrderByUID(t *testing.T) {
 	})
 }
 
+// expectMissingOrderBatch ожидает пакет GetOrderByUID для отсутствующего заказа. Непрочитанные
+// запросы связанных записей mock выполняет через Exec при закрытии пакета.
+func expectMissingOrderBatch(mock pgxmock.PgxPoolIface, uid string) {
+	batch := mock.ExpectBatch()
+	batch.ExpectQuery(`SELECT.*FROM orders`).WithArgs(uid).WillReturnRows(pgxmock.NewRows(orderColumns))
+	for _, query := range []string{`SELECT.*FROM order_item_snapshots`, `SELECT.*FROM item_status_history`,
+		`SELECT.*FROM payment_refunds`} {
+		batch.ExpectExec(query).WithArgs([]string{uid}).WillReturnResult(pgxmock.NewResult("SELECT", 0))
+	}
+}
+
+var historyColumns = []string{"order_uid", "chrt_id", "from_status", "to_status", "actor", "changed_at"}
+
+var refundColumns = []string{"id", "transaction", "chrt_id", "amount", "reason", "request_id", "actor", "created_at"}
+
+// anyArgs возвращает n произвольных аргументов: pgxmock сверяет их число у каждого запроса
+func anyArgs(n int) []any {
+	args := make([]any, n)
+	for i := range args {
+		args[i] = pgxmock.AnyArg()
+	}
+	return args
+}
+
+var orderColumns = []string{
+	"order_uid", "track_number", "entry", "locale", "internal_signature",
+	"customer_id", "delivery_service", "shardkey", "sm_id", "date_created", "oof_shard", "version",
+	"name", "phone", "zip", "city", "address", "region", "email",
+	"transaction", "request_id", "currency_id", "provider_name",
+	"amount", "payment_dt", "bank", "delivery_cost", "goods_total", "custom_fee",
+	"reason", "actor", "cancelled_at",
+}
+
+func orderRow(uid string) []any {
+	return []any{
+		uid, "TRACK001", "WB", "en", "signature",
+		"customer123", "delivery-service", "shard1", 1, time.Now(), "oof1", 1,
+		"John Doe", "+1234567890", "123456", "Moscow", "Street 1", "Moscow", "john@test.com",
+		uid, "req123", "USD", "provider1",
+		1000, time.Now().Unix(), "bank123", 100, 900, 0,
+		nil, nil, nil,
+	}
+}
+
+func TestStore_ListOrders(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := &Store{db: mock, log: logger.NewTestLogger()}
+	cursorTime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("filter, cursor and limit", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT.*FROM orders.*WHERE o.customer_id = \$1 AND \(o.date_created, o.order_uid\) < \(\$2, \$3\) ORDER BY o.date_created DESC, o.order_uid DESC LIMIT \$4`).
+			WithArgs("customer123", cursorTime, "00000000000000000009", 2).
+			WillReturnRows(pgxmock.NewRows(orderColumns).
+				AddRow(orderRow("00000000000000000008")...).
+				AddRow(orderRow("00000000000000000007")...))
+		batch := mock.ExpectBatch()
+		batch.ExpectQuery(`SELECT.*FROM order_item_snapshots.*ANY\(\$1\)`).
+			WithArgs([]string{"00000000000000000008", "00000000000000000007"}).
+			WillReturnRows(pgxmock.NewRows(itemColumns).
+				AddRow("00000000000000000007", 1, "TRACK001", 500, "rid001", "Item 1", 0, "M", 500, 123, "Brand", 200, 1))
+		batch.ExpectQuery(`SELECT.*FROM item_status_history`).
+			WithArgs([]string{"00000000000000000008", "00000000000000000007"}).
+			WillReturnRows(pgxmock.NewRows(historyColumns))
+		batch.ExpectQuery(`SELECT.*FROM payment_refunds`).
+			WithArgs([]string{"00000000000000000008", "00000000000000000007"}).
+			WillReturnRows(pgxmock.NewRows(refundColumns))
+
+		orders, err := store.ListOrders(context.Background(), domain.OrderFilter{
+			CustomerID: "customer123",
+			After:      &domain.OrderCursor{DateCreated: cursorTime, OrderUID: "00000000000000000009"},
+			Limit:      2,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, orders, 2)
+		assert.Equal(t, "00000000000000000008", orders[0].OrderUID)
+		assert.Empty(t, orders[0].Items)
+		assert.Len(t, orders[1].Items, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("empty page", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT.*FROM orders.*ORDER BY`).
+			WithArgs(10).
+			WillReturnRows(pgxmock.NewRows(orderColumns))
+
+		orders, err := store.ListOrders(context.Background(), domain.OrderFilter{Limit: 10})
+
+		assert.NoError(t, err)
+		assert.Empty(t, orders)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStore_GetOrdersByUIDs(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	log := logger.NewTestLogger()
+	store := &Store{db: mock, log: log}
+
+	t.Run("orders with relations in two round trips", func(t *testing.T) {
+		uids := []string{"00000000000000000001", "00000000000000000002", "00000000000000000003"}
+
+		mock.ExpectQuery(`SELECT.*FROM orders.*ANY\(\$1\)`).
+			WithArgs(uids).
+			WillReturnRows(pgxmock.NewRows(orderColumns).
+				AddRow(orderRow("00000000000000000001")...).
+				AddRow(orderRow("00000000000000000003")...))
+
+		batch := mock.ExpectBatch()
+		batch.ExpectQuery(`SELECT.*FROM order_item_snapshots.*ANY\(\$1\)`).
+			WithArgs([]string{"00000000000000000001", "00000000000000000003"}).
+			WillReturnRows(pgxmock.NewRows(itemColumns).
+				AddRow("00000000000000000001", 1, "TRACK001", 500, "rid001", "Item 1", 0, "M", 500, 123, "Brand", 200, 1).
+				AddRow("00000000000000000003", 2, "TRACK001", 400, "rid002", "Item 2", 0, "L", 400, 124, "Brand", 200, 1).
+				AddRow("00000000000000000003", 3, "TRACK001", 300, "rid003", "Item 3", 0, "S", 300, 125, "Brand", 200, 1))
+		batch.ExpectQuery(`SELECT.*FROM item_status_history`).
+			WithArgs([]string{"00000000000000000001", "00000000000000000003"}).
+			WillReturnRows(pgxmock.NewRows(historyColumns).
+				AddRow("00000000000000000003", 2, 200, 400, "http:ops", time.Now()))
+		batch.ExpectQuery(`SELECT.*FROM payment_refunds`).
+			WithArgs([]string{"00000000000000000001", "00000000000000000003"}).
+			WillReturnRows(pgxmock.NewRows(refundColumns).
+				AddRow(int64(1), "00000000000000000003", 2, 400, "damaged", "", "http:ops", time.Now()))
+
+		orders, err := store.GetOrdersByUIDs(context.Background(), uids)
+
+		require.NoError(t, err)
+		require.Len(t, orders, 2)
+		assert.Equal(t, "00000000000000000001", orders[0].OrderUID)
+		assert.Len(t, orders[0].Items, 1)
+		assert.Equal(t, "00000000000000000003", orders[1].OrderUID)
+		assert.Len(t, orders[1].Items, 2)
+		assert.Empty(t, orders[0].Timeline)
+		assert.Len(t, orders[1].Timeline, 1)
+		assert.Nil(t, orders[0].Cancellation)
+		assert.Equal(t, 400, orders[1].ItemRefundedAmount(2))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("nothing found skips items query", func(t *testing.T) {
+		uids := []string{"00000000000000000004"}
+
+		mock.ExpectQuery(`SELECT.*FROM orders.*ANY\(\$1\)`).
+			WithArgs(uids).
+			WillReturnRows(pgxmock.NewRows(orderColumns))
+
+		orders, err := store.GetOrdersByUIDs(context.Background(), uids)
+
+		assert.NoError(t, err)
+		assert.Empty(t, orders)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		uids := []string{"00000000000000000005"}
+
+		mock.ExpectQuery(`SELECT.*FROM orders.*ANY\(\$1\)`).
+			WithArgs(uids).
+			WillReturnError(errors.New("database error"))
+
+		orders, err := store.GetOrdersByUIDs(context.Background(), uids)
+
+		assert.Error(t, err)
+		assert.Nil(t, orders)
+		assert.Contains(t, err.Error(), "failed to get orders")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// storedOrderRow возвращает строку selectOrderQuery для заказа order, сохранённого с версией 1
+func storedOrderRow(order *domain.Order) []any {
+	return []any{
+		order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
+		order.CustomerID, order.DeliveryService, order.ShardKey, order.SMID, order.DateCreated, order.OOFShard, 1,
+		order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip, order.Delivery.City,
+		order.Delivery.Address, order.Delivery.Region, order.Delivery.Email,
+		order.Payment.Transaction, order.Payment.RequestID, order.Payment.Currency, order.Payment.Provider,
+		order.Payment.Amount, order.Payment.PaymentDT, order.Payment.Bank,
+		order.Payment.DeliveryCost, order.Payment.GoodsTotal, order.Payment.CustomFee,
+		nil, nil, nil,
+	}
+}
+
+// expectRelations ожидает в пакете связанные записи заказа order: его позиции без истории и возвратов
+func expectRelations(batch *pgxmock.ExpectedBatch, order *domain.Order) {
+	uids := []string{order.OrderUID}
+	items := pgxmock.NewRows(itemColumns)
+	for _, item := range order.Items {
+		items.AddRow(order.OrderUID, item.ChrtID, item.TrackNumber, item.Price, item.RID, item.Name, item.Sale,
+			item.Size, item.TotalPrice, item.NMID, item.Brand, item.Status, max(item.Quantity, 1))
+	}
+
+	batch.ExpectQuery(`SELECT.*FROM order_item_snapshots`).WithArgs(uids).WillReturnRows(items)
+	batch.ExpectQuery(`SELECT.*FROM item_status_history`).WithArgs(uids).WillReturnRows(pgxmock.NewRows(historyColumns))
+	batch.ExpectQuery(`SELECT.*FROM payment_refunds`).WithArgs(uids).WillReturnRows(pgxmock.NewRows(refundColumns))
+}
+
 func TestStore_SaveOrder(t *testing.T) {
-	db, mock, err := sqlmock.New()
+	mock, err := pgxmock.NewPool()
 	require.NoError(t, err)
-	defer db.Close()
+	defer mock.Close()
 
 	log := logger.NewTestLogger()
-	store := &Store{db: db, log: log}
+	store := &Store{db: mock, log: log}
 
 	createTestOrder := func() *domain.Order {
 		return &domain.Order{
@@ -137,224 +335,284 @@ func TestStore_SaveOrder(t *testing.T) {
 		}
 	}
 
-	t.Run("successful save new order", func(t *testing.T) {
-		order := createTestOrder()
-
-		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM orders WHERE order_uid = \$1\)`).
+	noOrder := func() {
+		mock.ExpectQuery(`SELECT payload_hash FROM orders WHERE order_uid = \$1`).
 			WithArgs("00000000000000000000").
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			WillReturnRows(pgxmock.NewRows([]string{"payload_hash"}))
+	}
 
-		mock.ExpectBegin()
+	t.Run("successful save new order", func(t *testing.T) {
+		order := createTestOrder()
 
-		mock.ExpectQuery(`SELECT service_id FROM delivery_services WHERE name = \$1`).
+		noOrder()
+		batch := mock.ExpectBatch()
+		batch.ExpectQuery(`INSERT INTO delivery_services`).
 			WithArgs("test-service").
-			WillReturnRows(sqlmock.NewRows([]string{"service_id"}).AddRow(1))
-
-		mock.ExpectExec(`INSERT INTO orders`).
+			WillReturnRows(pgxmock.NewRows([]string{"service_id"}).AddRow(1))
+		batch.ExpectExec(`INSERT INTO orders .*\(SELECT service_id FROM delivery_services WHERE name = \$7\)`).
 			WithArgs(
 				"00000000000000000000", "TRACK001", "WB", "en", "signature123",
-				"customer123", 1, "shard1", 1, sqlmock.AnyArg(), "oof1",
+				"customer123", "test-service", "shard1", 1, pgxmock.AnyArg(), "oof1", 1, pgxmock.AnyArg(),
 			).
-			WillReturnResult(sqlmock.NewResult(1, 1))
-
-		mock.ExpectExec(`INSERT INTO delivery`).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		batch.ExpectExec(`INSERT INTO delivery`).
 			WithArgs(
 				"00000000000000000000", "John Doe", "+1234567890", "123456", "Moscow",
 				"Street 1", "Moscow", "john@test.com",
 			).
-			WillReturnResult(sqlmock.NewResult(1, 1))
-
-		mock.ExpectQuery(`SELECT provider_id FROM payment_providers WHERE name = \$1`).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		batch.ExpectQuery(`INSERT INTO payment_providers`).
 			WithArgs("test-provider").
-			WillReturnError(sql.ErrNoRows)
-
-		mock.ExpectQuery(`INSERT INTO payment_providers`).
-			WithArgs("test-provider").
-			WillReturnRows(sqlmock.NewRows([]string{"provider_id"}).AddRow(1))
-
-		mock.ExpectExec(`INSERT INTO payment`).
+			WillReturnRows(pgxmock.NewRows([]string{"provider_id"}).AddRow(2))
+		batch.ExpectExec(`INSERT INTO payment `).
 			WithArgs(
-				"00000000000000000000", "req123", "USD", 1, 1000, sqlmock.AnyArg(),
+				"00000000000000000000", "req123", "USD", "test-provider", 1000, pgxmock.AnyArg(),
 				"sberbank", 100, 900, 0,
 			).
-			WillReturnResult(sqlmock.NewResult(1, 1))
-
-		mock.ExpectQuery(`SELECT brand_id FROM brands WHERE name = \$1`).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		batch.ExpectQuery(`INSERT INTO brands`).
 			WithArgs("Test Brand").
-			WillReturnRows(sqlmock.NewRows([]string{"brand_id"}).AddRow(1))
-
-		mock.ExpectQuery(`INSERT INTO items`).
+			WillReturnRows(pgxmock.NewRows([]string{"brand_id"}).AddRow(3))
+		batch.ExpectExec(`INSERT INTO order_item_snapshots .*\(SELECT brand_id FROM brands WHERE name = \$12\)`).
 			WithArgs(
-				1, "TRACK001", 500, "rid001", "Test Item", 0, "M",
-				500, 123, 1, 200,
+				"00000000000000000000", 0, 1, "TRACK001", 500, "rid001", "Test Item", 0, "M",
+				500, 123, "Test Brand", 200, 1,
 			).
-			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		batch.ExpectExec(`INSERT INTO order_audit`).
+			WithArgs("00000000000000000000", "kafka", domain.AuditCreate, pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
-		mock.ExpectExec(`INSERT INTO order_items`).
-			WithArgs("00000000000000000000", 1, 1).
-			WillReturnResult(sqlmock.NewResult(1, 1))
-
-		mock.ExpectCommit()
-
-		err := store.SaveOrder(context.Background(), order)
+		err := store.SaveOrder(domain.WithActor(context.Background(), "kafka"), order)
 
 		assert.NoError(t, err)
+		assert.Equal(t, 1, order.Version)
 		assert.NoError(t, mock.ExpectationsWereMet())
+		id, ok := store.dicts.get(brands, "Test Brand")
+		assert.True(t, ok)
+		assert.Equal(t, 3, id)
 	})
 
-	t.Run("order already exists - should skip", func(t *testing.T) {
+	// Следующие сохранения берут id справочников из кэша, заполненного успешным сохранением
+	t.Run("cached dictionary ids skip lookups", func(t *testing.T) {
 		order := createTestOrder()
 
-		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM orders WHERE order_uid = \$1\)`).
-			WithArgs("00000000000000000000").
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		noOrder()
+		batch := mock.ExpectBatch()
+		batch.ExpectExec(`INSERT INTO orders .*\$6, \$7, \$8`).
+			WithArgs(
+				"00000000000000000000", "TRACK001", "WB", "en", "signature123",
+				"customer123", 1, "shard1", 1, pgxmock.AnyArg(), "oof1", 1, pgxmock.AnyArg(),
+			).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		batch.ExpectExec(`INSERT INTO delivery `).WithArgs(anyArgs(8)...).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		batch.ExpectExec(`INSERT INTO payment .*\$3, \$4, \$5`).
+			WithArgs(
+				"00000000000000000000", "req123", "USD", 2, 1000, pgxmock.AnyArg(),
+				"sberbank", 100, 900, 0,
+			).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		batch.ExpectExec(`INSERT INTO order_item_snapshots .*\$11, \$12, \$13`).
+			WithArgs("00000000000000000000", 0, 1, "TRACK001", 500, "rid001", "Test Item", 0, "M", 500, 123, 3, 200, 1).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		batch.ExpectExec(`INSERT INTO order_audit`).WithArgs(anyArgs(4)...).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 		err := store.SaveOrder(context.Background(), order)
 
-		assert.NoError(t, err) // Дубликаты игнорируются без ошибки
+		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("failed to check order existence", func(t *testing.T) {
+	t.Run("order already exists", func(t *testing.T) {
 		order := createTestOrder()
 
-		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM orders WHERE order_uid = \$1\)`).
+		hash, err := order.PayloadHash()
+		require.NoError(t, err)
+		mock.ExpectQuery(`SELECT payload_hash FROM orders WHERE order_uid = \$1`).
 			WithArgs("00000000000000000000").
-			WillReturnError(errors.New("database error"))
+			WillReturnRows(pgxmock.NewRows([]string{"payload_hash"}).AddRow(hash))
+		replays := testutil.ToFloat64(prometheus.OrderDuplicates.WithLabelValues("replay"))
 
-		err := store.SaveOrder(context.Background(), order)
+		err = store.SaveOrder(context.Background(), order)
 
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to check order existence")
+		assert.ErrorIs(t, err, domain.ErrAlreadyExists) // Что делать с дубликатом, решает usecase
+		assert.Equal(t, replays+1, testutil.ToFloat64(prometheus.OrderDuplicates.WithLabelValues("replay")))
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("failed to begin transaction", func(t *testing.T) {
+	t.Run("order saved without payload hash is a replay", func(t *testing.T) {
 		order := createTestOrder()
+		hash, err := order.PayloadHash()
+		require.NoError(t, err)
 
-		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM orders WHERE order_uid = \$1\)`).
+		mock.ExpectQuery(`SELECT payload_hash FROM orders WHERE order_uid = \$1`).
 			WithArgs("00000000000000000000").
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
-
-		mock.ExpectBegin().WillReturnError(errors.New("tx begin error"))
+			WillReturnRows(pgxmock.NewRows([]string{"payload_hash"}).AddRow(""))
+		batch := mock.ExpectBatch()
+		batch.ExpectQuery(`SELECT.*FROM orders`).
+			WithArgs("00000000000000000000").
+			WillReturnRows(pgxmock.NewRows(orderColumns).AddRow(storedOrderRow(order)...))
+		expectRelations(batch, order)
+		mock.ExpectExec(`UPDATE orders SET payload_hash = \$2 WHERE order_uid = \$1`).
+			WithArgs("00000000000000000000", hash).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
-		err := store.SaveOrder(context.Background(), order)
+		err = store.SaveOrder(context.Background(), order)
 
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to begin transaction")
+		assert.ErrorIs(t, err, domain.ErrAlreadyExists)
+		assert.NotErrorIs(t, err, domain.ErrContentConflict)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("failed to create delivery service", func(t *testing.T) {
+	t.Run("order with different content is a conflict", func(t *testing.T) {
 		order := createTestOrder()
+		order.Items[0].Price = 700
 
-		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM orders WHERE order_uid = \$1\)`).
+		mock.ExpectQuery(`SELECT payload_hash FROM orders WHERE order_uid = \$1`).
 			WithArgs("00000000000000000000").
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
-
-		mock.ExpectBegin()
-
-		mock.ExpectQuery(`SELECT service_id FROM delivery_services WHERE name = \$1`).
-			WithArgs("test-service").
-			WillReturnError(sql.ErrNoRows)
-		mock.ExpectQuery(`INSERT INTO delivery_services`).
-			WithArgs("test-service").
-			WillReturnError(errors.New("delivery service creation failed"))
-
-		mock.ExpectRollback()
+			WillReturnRows(pgxmock.NewRows([]string{"payload_hash"}).AddRow("stored"))
+		batch := mock.ExpectBatch()
+		batch.ExpectQuery(`SELECT.*FROM orders`).
+			WithArgs("00000000000000000000").
+			WillReturnRows(pgxmock.NewRows(orderColumns).AddRow(orderRow("00000000000000000000")...))
+		batch.ExpectQuery(`SELECT.*FROM order_item_snapshots`).
+			WithArgs([]string{"00000000000000000000"}).
+			WillReturnRows(pgxmock.NewRows(itemColumns).
+				AddRow("00000000000000000000", 1, "TRACK001", 500, "rid001", "Test Item", 0, "M", 500, 123,
+					"Test Brand", 200, 1))
+		batch.ExpectQuery(`SELECT.*FROM item_status_history`).
+			WithArgs([]string{"00000000000000000000"}).
+			WillReturnRows(pgxmock.NewRows(historyColumns))
+		batch.ExpectQuery(`SELECT.*FROM payment_refunds`).
+			WithArgs([]string{"00000000000000000000"}).
+			WillReturnRows(pgxmock.NewRows(refundColumns))
+		conflicts := testutil.ToFloat64(prometheus.OrderDuplicates.WithLabelValues("conflict"))
 
 		err := store.SaveOrder(context.Background(), order)
 
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to create delivery service")
+		assert.Equal(t, conflicts+1, testutil.ToFloat64(prometheus.OrderDuplicates.WithLabelValues("conflict")))
+		var conflict *domain.ContentConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.ErrorIs(t, err, domain.ErrContentConflict)
+		assert.Equal(t, domain.AuditChange{Before: float64(500), After: float64(700)}, conflict.Diff["items.0.price"])
+		assert.NotContains(t, conflict.Diff, "version")
+		assert.NotContains(t, conflict.Diff, "cancellation.reason")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("failed to insert order", func(t *testing.T) {
+	t.Run("failed to check order existence", func(t *testing.T) {
 		order := createTestOrder()
 
-		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM orders WHERE order_uid = \$1\)`).
+		mock.ExpectQuery(`SELECT payload_hash FROM orders WHERE order_uid = \$1`).
 			WithArgs("00000000000000000000").
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
-
-		mock.ExpectBegin()
-
-		mock.ExpectQuery(`SELECT service_id FROM delivery_services WHERE name = \$1`).
-			WithArgs("test-service").
-			WillReturnRows(sqlmock.NewRows([]string{"service_id"}).AddRow(1))
-
-		mock.ExpectExec(`INSERT INTO orders`).
-			WillReturnError(errors.New("order insert failed"))
-
-		mock.ExpectRollback()
+			WillReturnError(errors.New("database error"))
 
 		err := store.SaveOrder(context.Background(), order)
 
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to insert order")
+		assert.Contains(t, err.Error(), "failed to check order existence")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("failed to commit transaction", func(t *testing.T) {
+	// expectFailedSave ожидает пакет SaveOrder с id справочников из кэша, в котором запрос failing
+	// завершается ошибкой err. Остальные запросы пакета mock выполняет при закрытии результатов.
+	expectFailedSave := func(failing string, err error) {
+		batch := mock.ExpectBatch()
+		for _, q := range []struct {
+			query string
+			args  int
+		}{
+			{`INSERT INTO orders`, 13}, {`INSERT INTO delivery `, 8}, {`INSERT INTO payment `, 10},
+			{`INSERT INTO order_item_snapshots`, 14}, {`INSERT INTO order_audit`, 4},
+		} {
+			exec := batch.ExpectExec(q.query).WithArgs(anyArgs(q.args)...)
+			if q.query == failing {
+				exec.WillReturnError(err)
+			} else {
+				exec.WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			}
+		}
+	}
+
+	t.Run("failed to create delivery service", func(t *testing.T) {
 		order := createTestOrder()
+		order.DeliveryService = "new-service"
 
-		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM orders WHERE order_uid = \$1\)`).
-			WithArgs("00000000000000000000").
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		noOrder()
+		batch := mock.ExpectBatch()
+		batch.ExpectQuery(`INSERT INTO delivery_services`).
+			WithArgs("new-service").
+			WillReturnError(errors.New("delivery service creation failed"))
+		for _, q := range []struct {
+			query string
+			args  int
+		}{
+			{`INSERT INTO orders`, 13}, {`INSERT INTO delivery `, 8}, {`INSERT INTO payment `, 10},
+			{`INSERT INTO order_item_snapshots`, 14}, {`INSERT INTO order_audit`, 4},
+		} {
+			batch.ExpectExec(q.query).WithArgs(anyArgs(q.args)...).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		}
 
-		mock.ExpectBegin()
+		err := store.SaveOrder(context.Background(), order)
 
-		mock.ExpectQuery(`SELECT service_id FROM delivery_services WHERE name = \$1`).
-			WithArgs("test-service").
-			WillReturnRows(sqlmock.NewRows([]string{"service_id"}).AddRow(1))
+		assert.ErrorContains(t, err, "failed to create delivery service: delivery service creation failed")
+		assert.NoError(t, mock.ExpectationsWereMet())
+		_, ok := store.dicts.get(deliveryServices, "new-service")
+		assert.False(t, ok)
+	})
 
-		mock.ExpectExec(`INSERT INTO orders`).WillReturnResult(sqlmock.NewResult(1, 1))
+	t.Run("failed to insert order", func(t *testing.T) {
+		order := createTestOrder()
 
-		mock.ExpectExec(`INSERT INTO delivery`).WillReturnResult(sqlmock.NewResult(1, 1))
+		noOrder()
+		expectFailedSave(`INSERT INTO orders`, errors.New("order insert failed"))
 
-		mock.ExpectQuery(`SELECT provider_id FROM payment_providers WHERE name = \$1`).
-			WithArgs("test-provider").
-			WillReturnRows(sqlmock.NewRows([]string{"provider_id"}).AddRow(1))
-		mock.ExpectExec(`INSERT INTO payment`).WillReturnResult(sqlmock.NewResult(1, 1))
+		err := store.SaveOrder(context.Background(), order)
 
-		mock.ExpectQuery(`SELECT brand_id FROM brands WHERE name = \$1`).
-			WithArgs("Test Brand").
-			WillReturnRows(sqlmock.NewRows([]string{"brand_id"}).AddRow(1))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to insert order: order insert failed")
+	})
 
-		mock.ExpectQuery(`INSERT INTO items`).
-			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
-		mock.ExpectExec(`INSERT INTO order_items`).WillReturnResult(sqlmock.NewResult(1, 1))
+	t.Run("check violation is reported as validation error", func(t *testing.T) {
+		order := createTestOrder()
 
-		mock.ExpectCommit().WillReturnError(errors.New("commit failed"))
+		noOrder()
+		expectFailedSave(`INSERT INTO orders`, &pgconn.PgError{Code: "23514", ConstraintName: "valid_order_uid"})
 
 		err := store.SaveOrder(context.Background(), order)
 
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to commit transaction")
-		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.True(t, errors.Is(err, domain.ErrValidation))
+		assert.False(t, errors.Is(err, domain.ErrTransient))
 	})
 }
 
 func TestStore_DeleteOrder(t *testing.T) {
-	db, mock, err := sqlmock.New()
+	mock, err := pgxmock.NewPool()
 	require.NoError(t, err)
-	defer db.Close()
+	defer mock.Close()
 
 	log := logger.NewTestLogger()
-	store := &Store{db: db, log: log}
+	store := &Store{db: mock, log: log}
 
 	t.Run("successful delete order", func(t *testing.T) {
 		orderUID := "00000000000000000000"
 
 		mock.ExpectBegin()
 
-		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM orders WHERE order_uid = \$1\)`).
+		mock.ExpectQuery(`SELECT.*FROM orders.*FOR UPDATE OF o`).
 			WithArgs(orderUID).
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			WillReturnRows(pgxmock.NewRows(orderColumns).AddRow(orderRow(orderUID)...))
 
 		mock.ExpectExec(`DELETE FROM orders WHERE order_uid = \$1`).
 			WithArgs(orderUID).
-			WillReturnResult(sqlmock.NewResult(0, 1)) // 1 affected row
+			WillReturnResult(pgxmock.NewResult("DELETE", 1)) // 1 affected row
+
+		mock.ExpectExec(`INSERT INTO order_audit`).
+			WithArgs(orderUID, domain.ActorSystem, domain.AuditDelete, pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 		mock.ExpectCommit()
 
@@ -369,16 +627,16 @@ func TestStore_DeleteOrder(t *testing.T) {
 
 		mock.ExpectBegin()
 
-		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM orders WHERE order_uid = \$1\)`).
+		mock.ExpectQuery(`SELECT.*FROM orders.*FOR UPDATE OF o`).
 			WithArgs(orderUID).
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			WillReturnError(pgx.ErrNoRows)
 
 		mock.ExpectRollback()
 
 		err := store.DeleteOrder(context.Background(), orderUID)
 
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "order with UID "+orderUID+" not found")
+		assert.True(t, errors.Is(err, domain.ErrRecordNotFound))
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
@@ -399,7 +657,7 @@ func TestStore_DeleteOrder(t *testing.T) {
 
 		mock.ExpectBegin()
 
-		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM orders WHERE order_uid = \$1\)`).
+		mock.ExpectQuery(`SELECT.*FROM orders.*FOR UPDATE OF o`).
 			WithArgs(orderUID).
 			WillReturnError(errors.New("database error"))
 
@@ -417,9 +675,9 @@ func TestStore_DeleteOrder(t *testing.T) {
 
 		mock.ExpectBegin()
 
-		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM orders WHERE order_uid = \$1\)`).
+		mock.ExpectQuery(`SELECT.*FROM orders.*FOR UPDATE OF o`).
 			WithArgs(orderUID).
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			WillReturnRows(pgxmock.NewRows(orderColumns).AddRow(orderRow(orderUID)...))
 
 		mock.ExpectExec(`DELETE FROM orders WHERE order_uid = \$1`).
 			WithArgs(orderUID).
@@ -439,13 +697,17 @@ func TestStore_DeleteOrder(t *testing.T) {
 
 		mock.ExpectBegin()
 
-		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM orders WHERE order_uid = \$1\)`).
+		mock.ExpectQuery(`SELECT.*FROM orders.*FOR UPDATE OF o`).
 			WithArgs(orderUID).
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			WillReturnRows(pgxmock.NewRows(orderColumns).AddRow(orderRow(orderUID)...))
 
 		mock.ExpectExec(`DELETE FROM orders WHERE order_uid = \$1`).
 			WithArgs(orderUID).
-			WillReturnResult(sqlmock.NewResult(0, 1))
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+		mock.ExpectExec(`INSERT INTO order_audit`).
+			WithArgs(orderUID, domain.ActorSystem, domain.AuditDelete, pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 		mock.ExpectCommit().WillReturnError(errors.New("commit error"))
 
@@ -461,13 +723,17 @@ func TestStore_DeleteOrder(t *testing.T) {
 
 		mock.ExpectBegin()
 
-		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM orders WHERE order_uid = \$1\)`).
+		mock.ExpectQuery(`SELECT.*FROM orders.*FOR UPDATE OF o`).
 			WithArgs(orderUID).
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			WillReturnRows(pgxmock.NewRows(orderColumns).AddRow(orderRow(orderUID)...))
 
 		mock.ExpectExec(`DELETE FROM orders WHERE order_uid = \$1`).
 			WithArgs(orderUID).
-			WillReturnResult(sqlmock.NewResult(0, 1))
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+		mock.ExpectExec(`INSERT INTO order_audit`).
+			WithArgs(orderUID, domain.ActorSystem, domain.AuditDelete, pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 		mock.ExpectCommit()
 
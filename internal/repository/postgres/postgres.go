@@ -2,19 +2,39 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"log/slog"
 	"time"
 	"wb_l0/configs"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// querier - запросы, общие для пула и транзакции: чтения заказов выполняются и там, и там
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// pool - методы pgxpool.Pool, которыми пользуется Store. В тестах его заменяет pgxmock.
+type pool interface {
+	querier
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Ping(ctx context.Context) error
+	Close()
+}
+
 type Store struct {
-	db  *sql.DB
-	log *slog.Logger
+	db pool
+	// replicas обслуживают чтения заказов, nil - реплики не настроены
+	replicas *replicaSet
+	recent   recentWrites
+	dicts    dictCache
+	log      *slog.Logger
 }
 
 func NewStore(ctx context.Context, cfg *configs.Config, log *slog.Logger) (*Store, error) {
@@ -34,12 +54,14 @@ func (s *Store) Connect(ctx context.Context, cfg configs.Config) error {
 		"database", cfg.DB.Name,
 		"user", cfg.DB.User,
 		"timeout", cfg.DB.ConnectTimeout,
-		"retries", cfg.DB.Retries)
+		"retries", cfg.DB.Retries,
+		"max_conns", cfg.DB.MaxConns,
+		"min_conns", cfg.DB.MinConns)
 	if err := ctx.Err(); err != nil {
 		return fmt.Errorf("context cancelled before connection: %w", err)
 	}
 
-	connConfig, err := pgx.ParseConfig(fmt.Sprintf(
+	poolConfig, err := pgxpool.ParseConfig(fmt.Sprintf(
 		"postgres://%s:%s@%s:%s/%s?sslmode=disable&connect_timeout=%d",
 		cfg.DB.User,
 		cfg.DB.Password,
@@ -51,8 +73,12 @@ func (s *Store) Connect(ctx context.Context, cfg configs.Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to parse connection config: %w", err)
 	}
+	poolConfig.MaxConns = cfg.DB.MaxConns
+	poolConfig.MinConns = cfg.DB.MinConns
+	poolConfig.MaxConnLifetime = cfg.DB.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.DB.MaxConnIdleTime
 
-	var db *sql.DB
+	var db *pgxpool.Pool
 
 	retries := cfg.DB.Retries
 	retryDelay := cfg.DB.ConnectTimeout
@@ -62,7 +88,7 @@ func (s *Store) Connect(ctx context.Context, cfg configs.Config) error {
 			return fmt.Errorf("%w: context cancelled during retries", err)
 		}
 
-		db, err = openConnection(ctx, cfg.DB.ConnectTimeout, connConfig)
+		db, err = openPool(ctx, cfg.DB.ConnectTimeout, poolConfig)
 		if err == nil {
 			break
 		}
@@ -79,28 +105,35 @@ func (s *Store) Connect(ctx context.Context, cfg configs.Config) error {
 		return fmt.Errorf("failed to connect to database after %d retries: %w", retries, err)
 	}
 
-	db.SetMaxOpenConns(10)
-	db.SetConnMaxLifetime(5 * time.Minute)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxIdleTime(5 * time.Minute)
-
 	s.db = db
 
+	if len(cfg.DB.ReplicaDSNs) > 0 {
+		replicas, err := openReplicas(ctx, cfg.DB, s.log)
+		if err != nil {
+			db.Close()
+			return err
+		}
+		replicas.start(cfg.DB.ReplicaCheckInterval)
+		s.replicas = replicas
+		s.recent.window = cfg.DB.ReadYourWrites
+		s.log.Info("Database replicas configured",
+			"replicas", len(replicas.replicas),
+			"read_your_writes", cfg.DB.ReadYourWrites)
+	}
+
 	return nil
 }
 
-func openConnection(ctx context.Context, timeout time.Duration, config *pgx.ConnConfig) (*sql.DB, error) {
+func openPool(ctx context.Context, timeout time.Duration, config *pgxpool.Config) (*pgxpool.Pool, error) {
 	pingCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	connStr := stdlib.RegisterConnConfig(config)
-	db, err := sql.Open("pgx", connStr)
+	db, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
-
-		return nil, fmt.Errorf("failed to open connection: %w", err)
+		return nil, fmt.Errorf("failed to create pool: %w", err)
 	}
 
-	if err := db.PingContext(pingCtx); err != nil {
+	if err := db.Ping(pingCtx); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("ping failed: %w", err)
 	}
@@ -108,23 +141,33 @@ func openConnection(ctx context.Context, timeout time.Duration, config *pgx.Conn
 	return db, nil
 }
 
+// Ping проверяет доступность базы
+func (s *Store) Ping(ctx context.Context) error {
+	if s.db == nil {
+		return fmt.Errorf("not connected")
+	}
+	return s.db.Ping(ctx)
+}
+
 func (s *Store) Disconnect(ctx context.Context) error {
 	if s.db == nil {
 		return nil
 	}
 
-	done := make(chan error, 1)
+	// Close ждёт возврата всех соединений в пул, поэтому ограничен контекстом
+	done := make(chan struct{})
 	go func() {
-		done <- s.db.Close()
+		if s.replicas != nil {
+			s.replicas.close()
+		}
+		s.db.Close()
+		close(done)
 	}()
 
 	select {
 	case <-ctx.Done():
 		return fmt.Errorf("shutdown timed out: %w", ctx.Err())
-	case err := <-done:
-		if err != nil {
-			return fmt.Errorf("failed to close connection: %w", err)
-		}
+	case <-done:
 		return nil
 	}
 }
@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"wb_l0/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const refundsQuery = `
+        SELECT id, transaction, COALESCE(chrt_id, 0), amount, reason, COALESCE(request_id, ''), actor, created_at
+        FROM payment_refunds
+        WHERE transaction = ANY($1)
+        ORDER BY id`
+
+// SaveRefund сохраняет возврат. Возврат по заказу целиком (ChrtID == 0) также отменяет заказ, такой возврат
+// может быть нулевым, если всё уже возвращено по позициям. Суммы возвратов по заказу и по позиции проверяются
+// под блокировкой строки оплаты, поэтому параллельные возвраты не превысят ни сумму оплаты, ни стоимость позиции.
+func (s *Store) SaveRefund(ctx context.Context, refund *domain.Refund) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", classifyError(err))
+	}
+	defer tx.Rollback(ctx)
+
+	var paid int
+	var cancelled bool
+	err = tx.QueryRow(ctx, `
+        SELECT p.amount, EXISTS(SELECT 1 FROM order_cancellations WHERE order_uid = $1)
+        FROM payment p
+        WHERE p.transaction = $1
+        FOR UPDATE OF p`, refund.OrderUID).Scan(&paid, &cancelled)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("order with UID %s: %w", refund.OrderUID, domain.ErrRecordNotFound)
+		}
+		return fmt.Errorf("failed to lock payment: %w", classifyError(err))
+	}
+	if cancelled {
+		return fmt.Errorf("order %s is already cancelled: %w", refund.OrderUID, domain.ErrConflict)
+	}
+
+	var refunded int
+	err = tx.QueryRow(ctx, `
+        SELECT COALESCE(SUM(amount), 0) FROM payment_refunds WHERE transaction = $1`,
+		refund.OrderUID).Scan(&refunded)
+	if err != nil {
+		return fmt.Errorf("failed to sum refunds: %w", classifyError(err))
+	}
+	if refunded+refund.Amount > paid {
+		return fmt.Errorf("%w: paid %d, refunded %d, requested %d",
+			domain.ErrRefundExceeded, paid, refunded, refund.Amount)
+	}
+	if refund.ChrtID != 0 {
+		if err := checkItemRefund(ctx, tx, refund); err != nil {
+			return err
+		}
+	}
+
+	operation, after := domain.AuditRefund, make(map[string]any)
+	if refund.ChrtID == 0 {
+		operation = domain.AuditCancel
+		after["cancellation"] = map[string]string{"reason": refund.Reason, "actor": refund.Actor}
+		_, err = tx.Exec(ctx, `
+            INSERT INTO order_cancellations (order_uid, reason, actor) VALUES ($1, $2, $3)`,
+			refund.OrderUID, refund.Reason, refund.Actor)
+		if err != nil {
+			return fmt.Errorf("failed to insert cancellation: %w", classifyError(err))
+		}
+	}
+
+	if refund.Amount > 0 {
+		err = tx.QueryRow(ctx, `
+            INSERT INTO payment_refunds (transaction, chrt_id, amount, reason, request_id, actor)
+            VALUES ($1, NULLIF($2, 0), $3, $4, NULLIF($5, ''), $6)
+            ON CONFLICT (transaction, request_id) DO NOTHING
+            RETURNING id, created_at`,
+			refund.OrderUID, refund.ChrtID, refund.Amount, refund.Reason, refund.RequestID, refund.Actor,
+		).Scan(&refund.ID, &refund.CreatedAt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("refund with request_id %q already exists: %w", refund.RequestID,
+					domain.ErrConflict)
+			}
+			return fmt.Errorf("failed to insert refund: %w", classifyError(err))
+		}
+		after["refunds."+strconv.FormatInt(refund.ID, 10)] = refund
+	}
+
+	if err := insertAudit(ctx, tx, refund.OrderUID, refund.Actor, operation, nil, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", classifyError(err))
+	}
+	s.recent.mark(refund.OrderUID)
+	return nil
+}
+
+// checkItemRefund проверяет, что возвраты по позиции не превысят её стоимость. Стоимость складывается по всем
+// строкам заказа с этим chrt_id. Вызывается под блокировкой строки оплаты, которую берёт каждый возврат.
+func checkItemRefund(ctx context.Context, tx pgx.Tx, refund *domain.Refund) error {
+	var itemPaid, lines int
+	err := tx.QueryRow(ctx, `
+        SELECT COALESCE(SUM(total_price), 0), COUNT(*) FROM order_item_snapshots
+        WHERE order_uid = $1 AND chrt_id = $2`, refund.OrderUID, refund.ChrtID).Scan(&itemPaid, &lines)
+	if err != nil {
+		return fmt.Errorf("failed to get item price: %w", classifyError(err))
+	}
+	if lines == 0 {
+		return fmt.Errorf("item %d of order %s: %w", refund.ChrtID, refund.OrderUID, domain.ErrRecordNotFound)
+	}
+
+	var itemRefunded int
+	err = tx.QueryRow(ctx, `
+        SELECT COALESCE(SUM(amount), 0) FROM payment_refunds WHERE transaction = $1 AND chrt_id = $2`,
+		refund.OrderUID, refund.ChrtID).Scan(&itemRefunded)
+	if err != nil {
+		return fmt.Errorf("failed to sum item refunds: %w", classifyError(err))
+	}
+	if itemRefunded+refund.Amount > itemPaid {
+		return fmt.Errorf("%w: item %d paid %d, refunded %d, requested %d",
+			domain.ErrRefundExceeded, refund.ChrtID, itemPaid, itemRefunded, refund.Amount)
+	}
+	return nil
+}
+
+// scanRefunds читает результат refundsQuery: возвраты по заказам в порядке создания
+func scanRefunds(rows pgx.Rows) (map[string][]domain.Refund, error) {
+	defer rows.Close()
+
+	refunds := make(map[string][]domain.Refund)
+	for rows.Next() {
+		var refund domain.Refund
+		if err := rows.Scan(&refund.ID, &refund.OrderUID, &refund.ChrtID, &refund.Amount, &refund.Reason,
+			&refund.RequestID, &refund.Actor, &refund.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan refund: %w", err)
+		}
+		refunds[refund.OrderUID] = append(refunds[refund.OrderUID], refund)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating refunds: %w", classifyError(err))
+	}
+	return refunds, nil
+}
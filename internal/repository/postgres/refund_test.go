@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wb_l0/internal/domain"
+	"wb_l0/pkg/logger"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_SaveRefund(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	store := &Store{db: mock, log: logger.NewTestLogger()}
+
+	const uid = "b563feb7b2b84b6test1"
+	lockPayment := func(paid int, cancelled bool) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT p.amount, EXISTS.*FOR UPDATE OF p`).
+			WithArgs(uid).
+			WillReturnRows(pgxmock.NewRows([]string{"amount", "exists"}).AddRow(paid, cancelled))
+	}
+	refunded := func(amount int) {
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(amount\), 0\) FROM payment_refunds`).
+			WithArgs(uid).
+			WillReturnRows(pgxmock.NewRows([]string{"sum"}).AddRow(amount))
+	}
+	// price - стоимость позиции по всем lines строкам заказа с этим chrt_id
+	itemLines := func(chrtID, price, lines, refunded int) {
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(total_price\), 0\), COUNT\(\*\) FROM order_item_snapshots`).
+			WithArgs(uid, chrtID).
+			WillReturnRows(pgxmock.NewRows([]string{"sum", "count"}).AddRow(price, lines))
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(amount\), 0\) FROM payment_refunds WHERE .* AND chrt_id = \$2`).
+			WithArgs(uid, chrtID).
+			WillReturnRows(pgxmock.NewRows([]string{"sum"}).AddRow(refunded))
+	}
+	item := func(chrtID, price, refunded int) {
+		itemLines(chrtID, price, 1, refunded)
+	}
+
+	t.Run("item refund", func(t *testing.T) {
+		createdAt := time.Now()
+		lockPayment(1000, false)
+		refunded(100)
+		item(42, 500, 100)
+		mock.ExpectQuery(`INSERT INTO payment_refunds`).
+			WithArgs(uid, 42, 200, "damaged", "req-1", "http:ops").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(7, createdAt))
+		mock.ExpectExec(`INSERT INTO order_audit`).
+			WithArgs(uid, "http:ops", domain.AuditRefund, pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		refund := &domain.Refund{OrderUID: uid, ChrtID: 42, Amount: 200, Reason: "damaged", RequestID: "req-1",
+			Actor: "http:ops"}
+		err := store.SaveRefund(context.Background(), refund)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), refund.ID)
+		assert.Equal(t, createdAt, refund.CreatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cancellation without remaining amount", func(t *testing.T) {
+		lockPayment(1000, false)
+		refunded(1000)
+		mock.ExpectExec(`INSERT INTO order_cancellations`).
+			WithArgs(uid, "fraud", "kafka").
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec(`INSERT INTO order_audit`).
+			WithArgs(uid, "kafka", domain.AuditCancel,
+				`{"cancellation.actor":{"before":null,"after":"kafka"},`+
+					`"cancellation.reason":{"before":null,"after":"fraud"}}`).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		err := store.SaveRefund(context.Background(), &domain.Refund{OrderUID: uid, Reason: "fraud", Actor: "kafka"})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("concurrent refunds exceed payment", func(t *testing.T) {
+		lockPayment(1000, false)
+		refunded(900)
+		mock.ExpectRollback()
+
+		err := store.SaveRefund(context.Background(), &domain.Refund{OrderUID: uid, ChrtID: 42, Amount: 200,
+			Reason: "damaged"})
+
+		assert.True(t, errors.Is(err, domain.ErrRefundExceeded), "got %v", err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("concurrent refunds exceed item price", func(t *testing.T) {
+		lockPayment(1000, false)
+		refunded(400)
+		item(42, 500, 400)
+		mock.ExpectRollback()
+
+		err := store.SaveRefund(context.Background(), &domain.Refund{OrderUID: uid, ChrtID: 42, Amount: 200,
+			Reason: "damaged"})
+
+		assert.True(t, errors.Is(err, domain.ErrRefundExceeded), "got %v", err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("item split across lines is refunded up to their total", func(t *testing.T) {
+		lockPayment(1000, false)
+		refunded(0)
+		itemLines(42, 700, 2, 0)
+		mock.ExpectQuery(`INSERT INTO payment_refunds`).
+			WithArgs(uid, 42, 600, "damaged", "req-2", "http:ops").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(8, time.Now()))
+		mock.ExpectExec(`INSERT INTO order_audit`).
+			WithArgs(uid, "http:ops", domain.AuditRefund, pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		err := store.SaveRefund(context.Background(), &domain.Refund{OrderUID: uid, ChrtID: 42, Amount: 600,
+			Reason: "damaged", RequestID: "req-2", Actor: "http:ops"})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("item missing in order", func(t *testing.T) {
+		lockPayment(1000, false)
+		refunded(0)
+		mock.ExpectQuery(`FROM order_item_snapshots`).
+			WithArgs(uid, 7).
+			WillReturnRows(pgxmock.NewRows([]string{"sum", "count"}).AddRow(0, 0))
+		mock.ExpectRollback()
+
+		err := store.SaveRefund(context.Background(), &domain.Refund{OrderUID: uid, ChrtID: 7, Amount: 200,
+			Reason: "damaged"})
+
+		assert.True(t, errors.Is(err, domain.ErrRecordNotFound), "got %v", err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already cancelled", func(t *testing.T) {
+		lockPayment(1000, true)
+		mock.ExpectRollback()
+
+		err := store.SaveRefund(context.Background(), &domain.Refund{OrderUID: uid, Amount: 1, Reason: "fraud"})
+
+		assert.True(t, errors.Is(err, domain.ErrConflict), "got %v", err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("duplicate request id", func(t *testing.T) {
+		lockPayment(1000, false)
+		refunded(0)
+		item(42, 500, 0)
+		mock.ExpectQuery(`INSERT INTO payment_refunds.*ON CONFLICT`).
+			WithArgs(anyArgs(6)...).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}))
+		mock.ExpectRollback()
+
+		err := store.SaveRefund(context.Background(), &domain.Refund{OrderUID: uid, ChrtID: 42, Amount: 200,
+			Reason: "damaged", RequestID: "req-1"})
+
+		assert.True(t, errors.Is(err, domain.ErrConflict), "got %v", err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+	"wb_l0/configs"
+	"wb_l0/internal/domain"
+	"wb_l0/pkg/prometheus"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type replica struct {
+	name    string // host:port без учётных данных, для логов и метрик
+	db      pool
+	healthy atomic.Bool
+}
+
+// replicaSet - реплики для чтения, выбираемые по кругу среди прошедших последнюю проверку
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	timeout  time.Duration
+	log      *slog.Logger
+
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+// openReplicas создаёт пулы реплик из cfg.ReplicaDSNs и проверяет их. Пул подключается лениво, поэтому
+// недоступная при запуске реплика не мешает старту: она получит запросы после успешной проверки.
+func openReplicas(ctx context.Context, cfg configs.DBConfig, log *slog.Logger) (*replicaSet, error) {
+	rs := &replicaSet{timeout: cfg.ConnectTimeout, log: log}
+	for i, dsn := range cfg.ReplicaDSNs {
+		poolConfig, err := pgxpool.ParseConfig(dsn)
+		if err != nil {
+			rs.close()
+			return nil, fmt.Errorf("failed to parse replica %d config: %w", i+1, err)
+		}
+		poolConfig.MaxConns = cfg.MaxConns
+		poolConfig.MinConns = cfg.MinConns
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+
+		db, err := pgxpool.NewWithConfig(ctx, poolConfig)
+		if err != nil {
+			rs.close()
+			return nil, fmt.Errorf("failed to create replica %d pool: %w", i+1, err)
+		}
+		name := net.JoinHostPort(poolConfig.ConnConfig.Host, fmt.Sprint(poolConfig.ConnConfig.Port))
+		rs.replicas = append(rs.replicas, &replica{name: name, db: db})
+	}
+	rs.check(ctx)
+	return rs, nil
+}
+
+// pick возвращает следующую здоровую реплику или nil, если таких нет
+func (rs *replicaSet) pick() *replica {
+	n := uint64(len(rs.replicas))
+	start := rs.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if r := rs.replicas[(start+i)%n]; r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// check проверяет все реплики запросом Ping
+func (rs *replicaSet) check(ctx context.Context) {
+	for _, r := range rs.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, rs.timeout)
+		err := r.db.Ping(pingCtx)
+		cancel()
+		rs.setHealthy(r, err)
+	}
+}
+
+// setHealthy запоминает результат проверки реплики. err == nil означает, что реплика доступна.
+func (rs *replicaSet) setHealthy(r *replica, err error) {
+	healthy := err == nil
+	if r.healthy.Swap(healthy) != healthy {
+		if healthy {
+			rs.log.Info("Database replica is available", "replica", r.name)
+		} else {
+			rs.log.Warn("Database replica is unavailable, reads fall back to primary",
+				"replica", r.name,
+				"error", err.Error(),
+			)
+		}
+	}
+	up := 0.0
+	if healthy {
+		up = 1
+	}
+	prometheus.DatabaseReplicaUp.WithLabelValues(r.name).Set(up)
+}
+
+// start проверяет реплики каждые interval до close
+func (rs *replicaSet) start(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rs.stop, rs.done = cancel, make(chan struct{})
+	go func() {
+		defer close(rs.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				rs.check(ctx)
+			}
+		}
+	}()
+}
+
+// close останавливает проверки и закрывает пулы реплик
+func (rs *replicaSet) close() {
+	if rs.stop != nil {
+		rs.stop()
+		<-rs.done
+	}
+	for _, r := range rs.replicas {
+		r.db.Close()
+	}
+}
+
+// recentWrites - заказы, изменённые этим экземпляром, чтения которых ещё идут в primary,
+// чтобы клиент сразу видел свои изменения несмотря на отставание реплик
+type recentWrites struct {
+	window time.Duration
+
+	mu        sync.Mutex
+	until     map[string]time.Time
+	nextPrune time.Time
+}
+
+// mark направляет чтения заказа в primary на время window
+func (w *recentWrites) mark(orderUID string) {
+	if w.window <= 0 {
+		return
+	}
+	now := time.Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.until == nil {
+		w.until = make(map[string]time.Time)
+	}
+	// Истёкшие записи удаляются не чаще раза в window
+	if now.After(w.nextPrune) {
+		for uid, until := range w.until {
+			if now.After(until) {
+				delete(w.until, uid)
+			}
+		}
+		w.nextPrune = now.Add(w.window)
+	}
+	w.until[orderUID] = now.Add(w.window)
+}
+
+// contains сообщает, изменялся ли недавно хотя бы один из заказов
+func (w *recentWrites) contains(orderUIDs []string) bool {
+	if w.window <= 0 || len(orderUIDs) == 0 {
+		return false
+	}
+	now := time.Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, uid := range orderUIDs {
+		if until, ok := w.until[uid]; ok && now.Before(until) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeRead выполняет read на реплике. Чтения недавно изменённых заказов orderUIDs, чтения с
+// domain.WithPrimaryRead и все чтения без здоровых реплик идут в primary. Временная ошибка исключает
+// реплику до следующей проверки, а чтение повторяется в primary.
+func routeRead[T any](ctx context.Context, s *Store, orderUIDs []string, read func(db pool) (T, error)) (T, error) {
+	var r *replica
+	if s.replicas != nil && !domain.PrimaryReadFromContext(ctx) && !s.recent.contains(orderUIDs) {
+		r = s.replicas.pick()
+	}
+	if r == nil {
+		prometheus.DatabaseReads.WithLabelValues("primary").Inc()
+		return read(s.db)
+	}
+
+	prometheus.DatabaseReads.WithLabelValues("replica").Inc()
+	result, err := read(r.db)
+	if err != nil && errors.Is(err, domain.ErrTransient) {
+		s.replicas.setHealthy(r, err)
+		prometheus.DatabaseReads.WithLabelValues("primary").Inc()
+		return read(s.db)
+	}
+	return result, err
+}
@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"wb_l0/internal/domain"
+	"wb_l0/pkg/logger"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newReplicaStore возвращает Store с primary и n здоровыми репликами на pgxmock
+func newReplicaStore(t *testing.T, n int) (*Store, pgxmock.PgxPoolIface, []pgxmock.PgxPoolIface) {
+	t.Helper()
+	primary, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	t.Cleanup(primary.Close)
+
+	rs := &replicaSet{timeout: time.Second, log: logger.NewTestLogger()}
+	mocks := make([]pgxmock.PgxPoolIface, 0, n)
+	for i := 0; i < n; i++ {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		t.Cleanup(mock.Close)
+		r := &replica{name: fmt.Sprintf("replica-%d:5432", i+1), db: mock}
+		r.healthy.Store(true)
+		rs.replicas = append(rs.replicas, r)
+		mocks = append(mocks, mock)
+	}
+	store := &Store{db: primary, replicas: rs, recent: recentWrites{window: time.Minute}, log: logger.NewTestLogger()}
+	return store, primary, mocks
+}
+
+func expectLastOrders(mock pgxmock.PgxPoolIface, uid string) {
+	mock.ExpectQuery(`SELECT order_uid FROM orders`).WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"order_uid"}).AddRow(uid))
+}
+
+func TestStore_ReadRouting(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reads rotate between healthy replicas", func(t *testing.T) {
+		store, primary, replicas := newReplicaStore(t, 2)
+		expectLastOrders(replicas[1], "from-second")
+		expectLastOrders(replicas[0], "from-first")
+
+		first, err := store.GetLastOrdersUIDs(ctx, 1)
+		require.NoError(t, err)
+		second, err := store.GetLastOrdersUIDs(ctx, 1)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"from-second"}, first)
+		assert.Equal(t, []string{"from-first"}, second)
+		for _, mock := range append(replicas, primary) {
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("unhealthy replicas fall back to primary", func(t *testing.T) {
+		store, primary, replicas := newReplicaStore(t, 2)
+		store.replicas.setHealthy(store.replicas.replicas[0], errors.New("connection refused"))
+		expectLastOrders(replicas[1], "from-replica")
+		expectLastOrders(primary, "from-primary")
+
+		uids, err := store.GetLastOrdersUIDs(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"from-replica"}, uids)
+
+		store.replicas.setHealthy(store.replicas.replicas[1], errors.New("connection refused"))
+		uids, err = store.GetLastOrdersUIDs(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"from-primary"}, uids)
+		assert.NoError(t, primary.ExpectationsWereMet())
+		assert.NoError(t, replicas[1].ExpectationsWereMet())
+	})
+
+	t.Run("transient replica error is retried on primary", func(t *testing.T) {
+		store, primary, replicas := newReplicaStore(t, 1)
+		replicas[0].ExpectQuery(`SELECT order_uid FROM orders`).WithArgs(1).
+			WillReturnError(&pgconn.PgError{Code: "57P03", Message: "the database system is starting up"})
+		expectLastOrders(primary, "from-primary")
+
+		uids, err := store.GetLastOrdersUIDs(ctx, 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"from-primary"}, uids)
+		assert.False(t, store.replicas.replicas[0].healthy.Load())
+		assert.NoError(t, primary.ExpectationsWereMet())
+		assert.NoError(t, replicas[0].ExpectationsWereMet())
+	})
+
+	t.Run("other replica errors are returned", func(t *testing.T) {
+		store, primary, replicas := newReplicaStore(t, 1)
+		replicas[0].ExpectQuery(`SELECT order_uid FROM orders`).WithArgs(1).
+			WillReturnError(errors.New("permission denied"))
+
+		_, err := store.GetLastOrdersUIDs(ctx, 1)
+
+		assert.ErrorContains(t, err, "permission denied")
+		assert.True(t, store.replicas.replicas[0].healthy.Load())
+		assert.NoError(t, primary.ExpectationsWereMet())
+	})
+
+	t.Run("primary reads bypass replicas", func(t *testing.T) {
+		store, primary, replicas := newReplicaStore(t, 1)
+		expectLastOrders(primary, "from-primary")
+
+		uids, err := store.GetLastOrdersUIDs(domain.WithPrimaryRead(ctx), 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"from-primary"}, uids)
+		assert.NoError(t, primary.ExpectationsWereMet())
+		assert.NoError(t, replicas[0].ExpectationsWereMet())
+	})
+
+	t.Run("recently written orders are read from primary", func(t *testing.T) {
+		store, primary, replicas := newReplicaStore(t, 1)
+		store.recent.mark("written")
+		auditColumns := []string{"id", "order_uid", "actor", "operation", "diff", "created_at"}
+		primary.ExpectQuery(`FROM order_audit`).WithArgs("written").
+			WillReturnRows(pgxmock.NewRows(auditColumns))
+		replicas[0].ExpectQuery(`FROM order_audit`).WithArgs("other").
+			WillReturnRows(pgxmock.NewRows(auditColumns))
+
+		_, err := store.GetAuditLog(ctx, "written")
+		require.NoError(t, err)
+		_, err = store.GetAuditLog(ctx, "other")
+		require.NoError(t, err)
+
+		assert.NoError(t, primary.ExpectationsWereMet())
+		assert.NoError(t, replicas[0].ExpectationsWereMet())
+	})
+}
+
+func TestReplicaSet_Check(t *testing.T) {
+	store, _, replicas := newReplicaStore(t, 2)
+	replicas[0].ExpectPing().WillReturnError(errors.New("connection refused"))
+	replicas[1].ExpectPing()
+
+	store.replicas.check(context.Background())
+
+	assert.False(t, store.replicas.replicas[0].healthy.Load())
+	assert.True(t, store.replicas.replicas[1].healthy.Load())
+	assert.Same(t, store.replicas.replicas[1], store.replicas.pick())
+	for _, mock := range replicas {
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestRecentWrites(t *testing.T) {
+	t.Run("disabled window keeps nothing", func(t *testing.T) {
+		var w recentWrites
+		w.mark("uid")
+
+		assert.False(t, w.contains([]string{"uid"}))
+	})
+
+	t.Run("marks expire after window", func(t *testing.T) {
+		w := recentWrites{window: time.Minute}
+		w.mark("fresh")
+		w.until["stale"] = time.Now().Add(-time.Second)
+
+		assert.True(t, w.contains([]string{"other", "fresh"}))
+		assert.False(t, w.contains([]string{"stale"}))
+		assert.False(t, w.contains(nil))
+	})
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"wb_l0/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const statusHistoryQuery = `
+        SELECT order_uid, chrt_id, from_status, to_status, actor, changed_at
+        FROM item_status_history
+        WHERE order_uid = ANY($1)
+        ORDER BY id`
+
+// UpdateItemStatus переводит позицию заказа из change.From в change.To и записывает переход в историю.
+// Если статус позиции уже не change.From, возвращает domain.ErrConflict.
+func (s *Store) UpdateItemStatus(ctx context.Context, change *domain.ItemStatusChange) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", classifyError(err))
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+        UPDATE order_item_snapshots SET status_id = $4
+        WHERE order_uid = $1 AND chrt_id = $2 AND status_id = $3`,
+		change.OrderUID, change.ChrtID, change.From, change.To,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update item status: %w", classifyError(err))
+	}
+	if result.RowsAffected() == 0 {
+		var exists bool
+		err := tx.QueryRow(ctx, `
+            SELECT EXISTS(
+                SELECT 1 FROM order_item_snapshots WHERE order_uid = $1 AND chrt_id = $2
+            )`, change.OrderUID, change.ChrtID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check item existence: %w", classifyError(err))
+		}
+		if !exists {
+			return fmt.Errorf("item %d of order %s: %w", change.ChrtID, change.OrderUID, domain.ErrRecordNotFound)
+		}
+		return fmt.Errorf("item %d status changed concurrently: %w", change.ChrtID, domain.ErrConflict)
+	}
+
+	err = tx.QueryRow(ctx, `
+        INSERT INTO item_status_history (order_uid, chrt_id, from_status, to_status, actor)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING changed_at`,
+		change.OrderUID, change.ChrtID, change.From, change.To, change.Actor,
+	).Scan(&change.ChangedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert status history: %w", classifyError(err))
+	}
+
+	path := fmt.Sprintf("items[chrt_id=%d].status", change.ChrtID)
+	if err := insertAudit(ctx, tx, change.OrderUID, change.Actor, domain.AuditItemStatus,
+		map[string]int{path: change.From}, map[string]int{path: change.To}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", classifyError(err))
+	}
+	s.recent.mark(change.OrderUID)
+	return nil
+}
+
+// scanStatusHistory читает результат statusHistoryQuery: историю статусов позиций в порядке переходов
+func scanStatusHistory(rows pgx.Rows) (map[string][]domain.ItemStatusChange, error) {
+	defer rows.Close()
+
+	history := make(map[string][]domain.ItemStatusChange)
+	for rows.Next() {
+		var change domain.ItemStatusChange
+		if err := rows.Scan(&change.OrderUID, &change.ChrtID, &change.From, &change.To, &change.Actor,
+			&change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status history: %w", err)
+		}
+		history[change.OrderUID] = append(history[change.OrderUID], change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating status history: %w", classifyError(err))
+	}
+	return history, nil
+}
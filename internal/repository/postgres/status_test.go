@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wb_l0/internal/domain"
+	"wb_l0/pkg/logger"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_UpdateItemStatus(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	store := &Store{db: mock, log: logger.NewTestLogger()}
+
+	newChange := func() *domain.ItemStatusChange {
+		return &domain.ItemStatusChange{OrderUID: "b563feb7b2b84b6test", ChrtID: 9934930, From: 200, To: 202,
+			Actor: "http:ops"}
+	}
+
+	t.Run("status and history in one transaction", func(t *testing.T) {
+		changedAt := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE order_item_snapshots SET status_id = \$4`).
+			WithArgs("b563feb7b2b84b6test", 9934930, 200, 202).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectQuery(`INSERT INTO item_status_history`).
+			WithArgs("b563feb7b2b84b6test", 9934930, 200, 202, "http:ops").
+			WillReturnRows(pgxmock.NewRows([]string{"changed_at"}).AddRow(changedAt))
+		mock.ExpectExec(`INSERT INTO order_audit`).
+			WithArgs("b563feb7b2b84b6test", "http:ops", domain.AuditItemStatus,
+				`{"items[chrt_id=9934930].status":{"before":200,"after":202}}`).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		change := newChange()
+		err := store.UpdateItemStatus(context.Background(), change)
+
+		require.NoError(t, err)
+		assert.Equal(t, changedAt, change.ChangedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("status changed concurrently", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE order_item_snapshots`).WithArgs(anyArgs(4)...).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectQuery(`SELECT EXISTS`).
+			WithArgs("b563feb7b2b84b6test", 9934930).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+
+		err := store.UpdateItemStatus(context.Background(), newChange())
+
+		assert.True(t, errors.Is(err, domain.ErrConflict), "got %v", err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("item not in order", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE order_item_snapshots`).WithArgs(anyArgs(4)...).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectQuery(`SELECT EXISTS`).
+			WithArgs(anyArgs(2)...).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectRollback()
+
+		err := store.UpdateItemStatus(context.Background(), newChange())
+
+		assert.True(t, errors.Is(err, domain.ErrRecordNotFound), "got %v", err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"wb_l0/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UpdateOrder заменяет заказ целиком, если его текущая версия равна expectedVersion, и сохраняет его
+// с версией order.Version. Иначе возвращает domain.ErrConflict. История статусов, отмена и возвраты
+// заказа сохраняются. Статусы позиций, которые уже есть в заказе, меняются только через UpdateItemStatus,
+// поэтому в order они заменяются сохранёнными.
+func (s *Store) UpdateOrder(ctx context.Context, order *domain.Order, expectedVersion int) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", classifyError(err))
+	}
+	defer tx.Rollback(ctx)
+
+	// Строка оплаты блокируется вместе с заказом, как в SaveRefund, поэтому параллельный возврат
+	// не разойдётся с новой суммой оплаты. NO KEY UPDATE не мешает ссылкам на заказ из отмены.
+	before, err := scanOrder(tx.QueryRow(ctx, selectOrderQuery+"WHERE o.order_uid = $1 FOR NO KEY UPDATE OF o, p",
+		order.OrderUID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("order with UID %s: %w", order.OrderUID, domain.ErrRecordNotFound)
+		}
+		return fmt.Errorf("failed to lock order: %w", classifyError(err))
+	}
+	if before.Version != expectedVersion {
+		return fmt.Errorf("order %s has version %d, expected %d: %w", order.OrderUID, before.Version,
+			expectedVersion, domain.ErrConflict)
+	}
+	if err := checkRefundsCovered(ctx, tx, order); err != nil {
+		return err
+	}
+	rows, err := tx.Query(ctx, orderItemsQuery, []string{order.OrderUID})
+	if err != nil {
+		return fmt.Errorf("failed to query order items: %w", classifyError(err))
+	}
+	items, err := scanOrderItems(rows)
+	if err != nil {
+		return fmt.Errorf("failed to get order items: %w", err)
+	}
+	before.Items = items[order.OrderUID]
+	keepItemStatuses(order, before.Items)
+	payloadHash, err := order.PayloadHash()
+	if err != nil {
+		return fmt.Errorf("failed to hash order: %w", err)
+	}
+
+	var batch writeBatch
+	serviceID, serviceArg := s.dicts.ref(&batch, deliveryServices, order.DeliveryService, 7)
+	batch.queue("update order", fmt.Sprintf(`
+        UPDATE orders SET
+            track_number = $2, entry = $3, locale = $4, internal_signature = $5, customer_id = $6,
+            delivery_service_id = %s,
+            shardkey = $8, sm_id = $9, date_created = $10, oof_shard = $11,
+            version = $12, payload_hash = $13
+        WHERE order_uid = $1`, serviceID),
+		order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
+		order.CustomerID, serviceArg, order.ShardKey, order.SMID, order.DateCreated, order.OOFShard,
+		order.Version, payloadHash,
+	)
+	batch.queue("update delivery", `
+        UPDATE delivery SET name = $2, phone = $3, zip = $4, city = $5, address = $6, region = $7, email = $8
+        WHERE order_uid = $1`,
+		order.OrderUID, order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip,
+		order.Delivery.City, order.Delivery.Address, order.Delivery.Region, order.Delivery.Email,
+	)
+	providerID, providerArg := s.dicts.ref(&batch, paymentProviders, order.Payment.Provider, 4)
+	batch.queue("update payment", fmt.Sprintf(`
+        UPDATE payment SET
+            request_id = $2, currency_id = $3,
+            provider_id = %s,
+            amount = $5, payment_dt = $6, bank = $7,
+            delivery_cost = $8, goods_total = $9, custom_fee = $10
+        WHERE transaction = $1`, providerID),
+		order.OrderUID, order.Payment.RequestID, order.Payment.Currency, providerArg,
+		order.Payment.Amount, order.Payment.PaymentDT, order.Payment.Bank, order.Payment.DeliveryCost,
+		order.Payment.GoodsTotal, order.Payment.CustomFee,
+	)
+	// Снимки позиций заменяются целиком, как и остальные данные заказа
+	batch.queue("delete item snapshots", `DELETE FROM order_item_snapshots WHERE order_uid = $1`, order.OrderUID)
+	defaultQuantities(order)
+	s.queueItems(&batch, order)
+
+	// Отмена заказа обновлением не меняется, поэтому в diff не попадает
+	before.Cancellation = nil
+	if err := queueAudit(&batch, order.OrderUID, domain.ActorFromContext(ctx), domain.AuditUpdate,
+		before, order); err != nil {
+		return err
+	}
+	if err := batch.exec(ctx, tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", classifyError(err))
+	}
+	s.dicts.add(batch.learned...)
+	s.recent.mark(order.OrderUID)
+	return nil
+}
+
+// checkRefundsCovered проверяет, что оформленные возвраты не превышают ни новую сумму оплаты, ни новую
+// стоимость позиций, как SaveRefund и checkItemRefund. Вызывается под блокировкой строки оплаты.
+func checkRefundsCovered(ctx context.Context, tx pgx.Tx, order *domain.Order) error {
+	rows, err := tx.Query(ctx, `
+        SELECT COALESCE(chrt_id, 0), SUM(amount) FROM payment_refunds
+        WHERE transaction = $1
+        GROUP BY 1
+        ORDER BY 1`, order.OrderUID)
+	if err != nil {
+		return fmt.Errorf("failed to sum refunds: %w", classifyError(err))
+	}
+	refunded, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Refund, error) {
+		var refund domain.Refund
+		err := row.Scan(&refund.ChrtID, &refund.Amount)
+		return refund, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sum refunds: %w", classifyError(err))
+	}
+
+	total := 0
+	for _, refund := range refunded {
+		total += refund.Amount
+		if refund.ChrtID == 0 {
+			continue
+		}
+		if itemPaid, _ := order.ItemPaidAmount(refund.ChrtID); itemPaid < refund.Amount {
+			return fmt.Errorf("%w: item %d new price %d is below refunded %d",
+				domain.ErrRefundExceeded, refund.ChrtID, itemPaid, refund.Amount)
+		}
+	}
+	if order.Payment.Amount < total {
+		return fmt.Errorf("%w: new amount %d is below refunded %d",
+			domain.ErrRefundExceeded, order.Payment.Amount, total)
+	}
+	return nil
+}
+
+// keepItemStatuses заменяет статусы позиций order, которые уже есть в stored, сохранёнными статусами
+func keepItemStatuses(order *domain.Order, stored []domain.Item) {
+	statuses := make(map[int]int, len(stored))
+	for _, item := range stored {
+		statuses[item.ChrtID] = item.Status
+	}
+	for i := range order.Items {
+		if status, ok := statuses[order.Items[i].ChrtID]; ok {
+			order.Items[i].Status = status
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"wb_l0/internal/domain"
+	"wb_l0/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var itemColumns = []string{
+	"order_uid", "chrt_id", "track_number", "price", "rid", "name", "sale", "size",
+	"total_price", "nm_id", "brand_name", "status_id", "quantity",
+}
+
+func TestStore_UpdateOrder(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	store := &Store{db: mock, log: logger.NewTestLogger()}
+
+	const uid = "b563feb7b2b84b6test1"
+	newOrder := func() *domain.Order {
+		order := domain.CreateTestOrder(1)
+		order.OrderUID = uid
+		order.Payment.Transaction = uid
+		order.Version = 2
+		return &order
+	}
+
+	t.Run("replaces order, items and writes audit", func(t *testing.T) {
+		order := newOrder()
+		item := order.Items[0]
+		const storedStatus = domain.ItemStatusNotAvailable
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT.*FROM orders.*FOR NO KEY UPDATE OF o, p`).
+			WithArgs(uid).
+			WillReturnRows(pgxmock.NewRows(orderColumns).AddRow(orderRow(uid)...))
+		expectRefunds(mock, uid, domain.Refund{Amount: 200}, domain.Refund{ChrtID: item.ChrtID, Amount: 100})
+		mock.ExpectQuery(`SELECT.*FROM order_item_snapshots`).
+			WithArgs([]string{uid}).
+			WillReturnRows(pgxmock.NewRows(itemColumns).
+				AddRow(uid, item.ChrtID, item.TrackNumber, 100, item.RID, item.Name, 0, "0", 100, item.NMID,
+					item.Brand, storedStatus, 1))
+		batch := mock.ExpectBatch()
+		batch.ExpectQuery(`INSERT INTO delivery_services`).
+			WithArgs(order.DeliveryService).
+			WillReturnRows(pgxmock.NewRows([]string{"service_id"}).AddRow(1))
+		batch.ExpectExec(`UPDATE orders SET`).
+			WithArgs(uid, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
+				order.CustomerID, order.DeliveryService, order.ShardKey, order.SMID, pgxmock.AnyArg(),
+				order.OOFShard, 2, pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		batch.ExpectExec(`UPDATE delivery SET`).WithArgs(anyArgs(8)...).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		batch.ExpectQuery(`INSERT INTO payment_providers`).
+			WithArgs(order.Payment.Provider).
+			WillReturnRows(pgxmock.NewRows([]string{"provider_id"}).AddRow(2))
+		batch.ExpectExec(`UPDATE payment SET`).WithArgs(anyArgs(10)...).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		batch.ExpectExec(`DELETE FROM order_item_snapshots WHERE order_uid = \$1`).
+			WithArgs(uid).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		batch.ExpectQuery(`INSERT INTO brands`).
+			WithArgs(item.Brand).
+			WillReturnRows(pgxmock.NewRows([]string{"brand_id"}).AddRow(3))
+		// Позиция без quantity сохраняется одной единицей, статус позиции обновлением не меняется
+		batch.ExpectExec(`INSERT INTO order_item_snapshots`).
+			WithArgs(uid, 0, item.ChrtID, item.TrackNumber, item.Price, item.RID, item.Name, item.Sale, item.Size,
+				item.TotalPrice, item.NMID, item.Brand, storedStatus, 1).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		batch.ExpectExec(`INSERT INTO order_audit`).
+			WithArgs(uid, "http:ops", domain.AuditUpdate, pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		err := store.UpdateOrder(domain.WithActor(context.Background(), "http:ops"), order, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, storedStatus, order.Items[0].Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		// id созданных значений запоминаются только после фиксации
+		id, ok := store.dicts.get(paymentProviders, order.Payment.Provider)
+		assert.True(t, ok)
+		assert.Equal(t, 2, id)
+	})
+
+	t.Run("stale version is a conflict", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT.*FROM orders.*FOR NO KEY UPDATE OF o, p`).
+			WithArgs(uid).
+			WillReturnRows(pgxmock.NewRows(orderColumns).AddRow(orderRow(uid)...))
+		mock.ExpectRollback()
+
+		err := store.UpdateOrder(context.Background(), newOrder(), 3)
+
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("amount below refunded sum is rejected", func(t *testing.T) {
+		order := newOrder()
+		order.Payment.Amount = 200
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT.*FROM orders.*FOR NO KEY UPDATE OF o, p`).
+			WithArgs(uid).
+			WillReturnRows(pgxmock.NewRows(orderColumns).AddRow(orderRow(uid)...))
+		expectRefunds(mock, uid, domain.Refund{Amount: 300})
+		mock.ExpectRollback()
+
+		err := store.UpdateOrder(context.Background(), order, 1)
+
+		assert.ErrorIs(t, err, domain.ErrRefundExceeded)
+		assert.ErrorContains(t, err, "new amount 200 is below refunded 300")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("item price below its refunds is rejected", func(t *testing.T) {
+		order := newOrder()
+		chrtID := order.Items[0].ChrtID
+		order.Items[0].TotalPrice = 50
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT.*FROM orders.*FOR NO KEY UPDATE OF o, p`).
+			WithArgs(uid).
+			WillReturnRows(pgxmock.NewRows(orderColumns).AddRow(orderRow(uid)...))
+		expectRefunds(mock, uid, domain.Refund{ChrtID: chrtID, Amount: 100})
+		mock.ExpectRollback()
+
+		err := store.UpdateOrder(context.Background(), order, 1)
+
+		assert.ErrorIs(t, err, domain.ErrRefundExceeded)
+		assert.ErrorContains(t, err, "new price 50 is below refunded 100")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("removed item with refunds is rejected", func(t *testing.T) {
+		order := newOrder()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT.*FROM orders.*FOR NO KEY UPDATE OF o, p`).
+			WithArgs(uid).
+			WillReturnRows(pgxmock.NewRows(orderColumns).AddRow(orderRow(uid)...))
+		expectRefunds(mock, uid, domain.Refund{ChrtID: 7, Amount: 100})
+		mock.ExpectRollback()
+
+		err := store.UpdateOrder(context.Background(), order, 1)
+
+		assert.ErrorIs(t, err, domain.ErrRefundExceeded)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("order not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT.*FROM orders.*FOR NO KEY UPDATE OF o, p`).
+			WithArgs(uid).
+			WillReturnError(pgx.ErrNoRows)
+		mock.ExpectRollback()
+
+		err := store.UpdateOrder(context.Background(), newOrder(), 1)
+
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// expectRefunds ожидает запрос сумм возвратов по позициям, возврат без ChrtID относится к заказу целиком
+func expectRefunds(mock pgxmock.PgxPoolIface, uid string, refunds ...domain.Refund) {
+	rows := pgxmock.NewRows([]string{"chrt_id", "sum"})
+	for _, refund := range refunds {
+		rows.AddRow(refund.ChrtID, refund.Amount)
+	}
+	mock.ExpectQuery(`SELECT COALESCE\(chrt_id, 0\), SUM\(amount\) FROM payment_refunds`).
+		WithArgs(uid).
+		WillReturnRows(rows)
+}
@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"wb_l0/internal/domain"
+	"wb_l0/internal/webhook"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Store реализует webhook.Repository
+var _ webhook.Repository = (*Store)(nil)
+
+const subscriptionColumns = `id, url, secret, events, customer_id, active, created_at`
+
+const deliveryColumns = `id, subscription_id, event, order_uid, payload, status, attempts, last_status_code,
+        last_error, next_attempt_at, created_at, updated_at`
+
+func (s *Store) CreateSubscription(ctx context.Context, sub *webhook.Subscription) error {
+	err := s.db.QueryRow(ctx, `
+        INSERT INTO webhook_subscriptions (url, secret, events, customer_id, active)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at`,
+		sub.URL, sub.Secret, sub.Events, sub.CustomerID, sub.Active,
+	).Scan(&sub.ID, &sub.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", classifyError(err))
+	}
+	return nil
+}
+
+func (s *Store) ListSubscriptions(ctx context.Context) ([]webhook.Subscription, error) {
+	return s.querySubscriptions(ctx, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions ORDER BY id`)
+}
+
+func (s *Store) ActiveSubscriptions(ctx context.Context, event string) ([]webhook.Subscription, error) {
+	return s.querySubscriptions(ctx, `
+        SELECT `+subscriptionColumns+` FROM webhook_subscriptions
+        WHERE active AND $1 = ANY(events)
+        ORDER BY id`, event)
+}
+
+func (s *Store) querySubscriptions(ctx context.Context, query string, args ...any) ([]webhook.Subscription, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook subscriptions: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	var subscriptions []webhook.Subscription
+	for rows.Next() {
+		var sub webhook.Subscription
+		if err := rows.Scan(&sub.ID, &sub.URL, &sub.Secret, &sub.Events, &sub.CustomerID, &sub.Active,
+			&sub.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook subscriptions: %w", classifyError(err))
+	}
+	return subscriptions, nil
+}
+
+func (s *Store) DeleteSubscription(ctx context.Context, id int64) error {
+	result, err := s.db.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", classifyError(err))
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateDeliveries сохраняет доставки одним пакетом: сохраняются все или ни одной
+func (s *Store) CreateDeliveries(ctx context.Context, deliveries []webhook.Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	var batch writeBatch
+	for _, delivery := range deliveries {
+		batch.queue("insert webhook delivery", `
+            INSERT INTO webhook_deliveries (subscription_id, event, order_uid, payload, status, next_attempt_at)
+            VALUES ($1, $2, $3, $4, $5, $6)`,
+			delivery.SubscriptionID, delivery.Event, delivery.OrderUID, string(delivery.Payload),
+			string(delivery.Status), delivery.NextAttemptAt)
+	}
+	return batch.exec(ctx, s.db)
+}
+
+// ClaimDueDeliveries использует FOR UPDATE SKIP LOCKED, поэтому несколько экземпляров сервиса
+// не захватывают одну и ту же доставку
+func (s *Store) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]webhook.Task, error) {
+	rows, err := s.db.Query(ctx, `
+        UPDATE webhook_deliveries d
+        SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond', updated_at = NOW()
+        FROM webhook_subscriptions ws
+        WHERE ws.id = d.subscription_id AND d.id IN (
+            SELECT id FROM webhook_deliveries
+            WHERE status = 'pending' AND next_attempt_at <= NOW()
+            ORDER BY next_attempt_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING d.id, d.subscription_id, d.event, d.order_uid, d.payload, d.status, d.attempts,
+            d.last_status_code, d.last_error, d.next_attempt_at, d.created_at, d.updated_at, ws.url, ws.secret`,
+		limit, lease.Milliseconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	var tasks []webhook.Task
+	for rows.Next() {
+		var task webhook.Task
+		if err := scanDelivery(rows, &task.Delivery, &task.URL, &task.Secret); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", classifyError(err))
+	}
+	return tasks, nil
+}
+
+func (s *Store) UpdateDelivery(ctx context.Context, delivery *webhook.Delivery) error {
+	_, err := s.db.Exec(ctx, `
+        UPDATE webhook_deliveries
+        SET status = $2, attempts = $3, last_status_code = $4, last_error = $5, next_attempt_at = $6,
+            updated_at = NOW()
+        WHERE id = $1`,
+		delivery.ID, string(delivery.Status), delivery.Attempts, delivery.LastStatusCode, delivery.LastError,
+		delivery.NextAttemptAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", classifyError(err))
+	}
+	return nil
+}
+
+func (s *Store) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]webhook.Delivery, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT `+deliveryColumns+` FROM webhook_deliveries
+        WHERE subscription_id = $1
+        ORDER BY id DESC
+        LIMIT $2`, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	var deliveries []webhook.Delivery
+	for rows.Next() {
+		var delivery webhook.Delivery
+		if err := scanDelivery(rows, &delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", classifyError(err))
+	}
+	return deliveries, nil
+}
+
+func scanDelivery(row rowScanner, delivery *webhook.Delivery, extra ...any) error {
+	var status string
+	var payload []byte
+	dest := append([]any{
+		&delivery.ID, &delivery.SubscriptionID, &delivery.Event, &delivery.OrderUID, &payload, &status,
+		&delivery.Attempts, &delivery.LastStatusCode, &delivery.LastError, &delivery.NextAttemptAt,
+		&delivery.CreatedAt, &delivery.UpdatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrRecordNotFound
+		}
+		return fmt.Errorf("failed to scan webhook delivery: %w", err)
+	}
+	delivery.Status = webhook.DeliveryStatus(status)
+	delivery.Payload = payload
+	return nil
+}
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wb_l0/internal/domain"
+	"wb_l0/internal/webhook"
+	"wb_l0/pkg/logger"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_ActiveSubscriptions(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	store := &Store{db: mock, log: logger.NewTestLogger()}
+
+	mock.ExpectQuery(`SELECT .*events.* FROM webhook_subscriptions\s+WHERE active AND \$1 = ANY\(events\)`).
+		WithArgs(webhook.EventOrderCreated).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "url", "secret", "events", "customer_id", "active", "created_at"}).
+			AddRow(int64(1), "https://partner.example/hook", "secret", []string{webhook.EventOrderCreated}, "", true,
+				time.Now()))
+
+	subscriptions, err := store.ActiveSubscriptions(context.Background(), webhook.EventOrderCreated)
+
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, []string{webhook.EventOrderCreated}, subscriptions[0].Events)
+	assert.Equal(t, "secret", subscriptions[0].Secret)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_DeleteSubscription(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	store := &Store{db: mock, log: logger.NewTestLogger()}
+
+	mock.ExpectExec(`DELETE FROM webhook_subscriptions`).
+		WithArgs(int64(7)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	err = store.DeleteSubscription(context.Background(), 7)
+
+	assert.True(t, errors.Is(err, domain.ErrRecordNotFound))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_CreateDeliveries(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	store := &Store{db: mock, log: logger.NewTestLogger()}
+
+	next := time.Now()
+	deliveries := []webhook.Delivery{
+		{SubscriptionID: 1, Event: webhook.EventOrderCreated, OrderUID: "uid", Payload: []byte(`{}`),
+			Status: webhook.StatusPending, NextAttemptAt: next},
+		{SubscriptionID: 2, Event: webhook.EventOrderCreated, OrderUID: "uid", Payload: []byte(`{}`),
+			Status: webhook.StatusPending, NextAttemptAt: next},
+	}
+
+	batch := mock.ExpectBatch()
+	batch.ExpectExec(`INSERT INTO webhook_deliveries`).
+		WithArgs(int64(1), webhook.EventOrderCreated, "uid", `{}`, "pending", next).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	batch.ExpectExec(`INSERT INTO webhook_deliveries`).
+		WithArgs(int64(2), webhook.EventOrderCreated, "uid", `{}`, "pending", next).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	assert.NoError(t, store.CreateDeliveries(context.Background(), deliveries))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_ClaimDueDeliveries(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	store := &Store{db: mock, log: logger.NewTestLogger()}
+
+	now := time.Now()
+	mock.ExpectQuery(`UPDATE webhook_deliveries d .*FOR UPDATE SKIP LOCKED.*RETURNING`).
+		WithArgs(8, int64(15000)).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "subscription_id", "event", "order_uid", "payload", "status", "attempts",
+			"last_status_code", "last_error", "next_attempt_at", "created_at", "updated_at", "url", "secret",
+		}).AddRow(
+			5, 1, webhook.EventOrderCreated, "uid", []byte(`{"event":"order.created"}`), "pending", 2,
+			500, "unexpected status 500", now, now, now, "https://partner.example/hook", "secret",
+		))
+
+	tasks, err := store.ClaimDueDeliveries(context.Background(), 8, 15*time.Second)
+
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, int64(5), tasks[0].ID)
+	assert.Equal(t, webhook.StatusPending, tasks[0].Status)
+	assert.Equal(t, 2, tasks[0].Attempts)
+	assert.Equal(t, "https://partner.example/hook", tasks[0].URL)
+	assert.Equal(t, "secret", tasks[0].Secret)
+	assert.JSONEq(t, `{"event":"order.created"}`, string(tasks[0].Payload))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -47,31 +47,97 @@ func NewCache(ctx context.Context, cfg *configs.Config, prefix string, log *slog
 	}, nil
 }
 
+// Client возвращает клиент Redis для других подсистем, например общего rate limit
+func (r *RedisRepo) Client() *redis.Client {
+	return r.client
+}
+
+// Ping проверяет доступность Redis
+func (r *RedisRepo) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// cacheEntry - значение в Redis: заказ вместе с его хешем содержимого (ETag)
+type cacheEntry struct {
+	Hash  string        `json:"hash"`
+	Order *domain.Order `json:"order"`
+}
+
 func (r *RedisRepo) GetOrderByUID(ctx context.Context, orderUID string) (*domain.Order, error) {
+	order, _, err := r.GetOrderWithHash(ctx, orderUID)
+	return order, err
+}
+
+// GetOrderWithHash возвращает заказ и сохранённый рядом с ним хеш содержимого
+func (r *RedisRepo) GetOrderWithHash(ctx context.Context, orderUID string) (*domain.Order, string, error) {
 	order := &domain.Order{}
 	r.log.Debug("Getting order from Redis", "orderUID", orderUID)
 	key := r.prefix + orderUID
 	data, err := r.client.Get(ctx, key).Bytes()
 	if errors.Is(err, redis.Nil) {
 		r.log.Debug("Order not found", "orderUID", orderUID)
-		return order, domain.ErrRecordNotFound
+		return order, "", domain.ErrRecordNotFound
 	} else if err != nil {
 		r.log.Debug("error getting from redis", "orderUID", orderUID)
-		return order, err
+		return order, "", err
 	}
 
-	if err := json.Unmarshal(data, &order); err != nil {
+	entry, err := decodeEntry(data)
+	if err != nil {
 		r.log.Debug("error converting from redis", "orderUID", orderUID)
-		return order, err
+		return order, "", err
+	}
+	if entry == nil {
+		// Запись старого формата без хеша считаем промахом, она перезапишется
+		r.log.Debug("Order cached in legacy format", "orderUID", orderUID)
+		return order, "", domain.ErrRecordNotFound
+	}
+	return entry.Order, entry.Hash, nil
+}
+
+// GetOrdersByUIDs читает заказы одним MGET. В результат попадают только найденные заказы.
+func (r *RedisRepo) GetOrdersByUIDs(ctx context.Context, orderUIDs []string) (map[string]*domain.Order, error) {
+	orders := make(map[string]*domain.Order, len(orderUIDs))
+	if len(orderUIDs) == 0 {
+		return orders, nil
+	}
+	r.log.Debug("Getting orders from Redis", "count", len(orderUIDs))
+
+	keys := make([]string, len(orderUIDs))
+	for i, uid := range orderUIDs {
+		keys[i] = r.prefix + uid
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		r.log.Debug("error getting batch from redis", "error", err)
+		return orders, err
+	}
+
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		entry, err := decodeEntry([]byte(data))
+		if err != nil || entry == nil {
+			r.log.Debug("error converting from redis", "orderUID", orderUIDs[i])
+			continue
+		}
+		orders[orderUIDs[i]] = entry.Order
 	}
-	return order, nil
+	return orders, nil
 }
 
 func (r *RedisRepo) SaveOrder(ctx context.Context, order *domain.Order) error {
 	r.log.Debug("starting to set order in cache")
 
 	key := r.prefix + order.OrderUID
-	data, err := json.Marshal(order)
+	hash, err := order.ContentHash()
+	if err != nil {
+		r.log.Error("error while hashing order", "error", err, "orderUID", order.OrderUID)
+		return err
+	}
+	data, err := json.Marshal(cacheEntry{Hash: hash, Order: order})
 	if err != nil {
 		r.log.Error("error while setting to Redis", "error", err, "orderUID", order.OrderUID)
 		return err
@@ -139,7 +205,35 @@ func (r *RedisRepo) SaveOrder(ctx context.Context, order *domain.Order) error {
 	return nil
 }
 
+func (r *RedisRepo) DeleteOrder(ctx context.Context, orderUID string) error {
+	r.log.Debug("deleting order from cache", "orderUID", orderUID)
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, r.prefix+orderUID)
+		pipe.ZRem(ctx, r.prefix+"recent_orders", orderUID)
+		return nil
+	})
+	if err != nil {
+		r.log.Error("failed to delete order from cache", "error", err, "orderUID", orderUID)
+		return err
+	}
+	r.log.Debug("order removed from cache", "orderUID", orderUID)
+	return nil
+}
+
 func (r *RedisRepo) CountOrders(ctx context.Context) (int, error) {
 	res, err := r.client.ZCard(ctx, r.prefix+"recent_orders").Result()
 	return int(res), err
 }
+
+// decodeEntry возвращает nil без ошибки для записей старого формата (чистый JSON заказа)
+func decodeEntry(data []byte) (*cacheEntry, error) {
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	if entry.Order == nil || entry.Hash == "" {
+		return nil, nil
+	}
+	return &entry, nil
+}
@@ -0,0 +1,172 @@
+// Package stream рассылает краткие сводки о новых заказах подписчикам (SSE, WebSocket).
+package stream
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"wb_l0/internal/domain"
+	"wb_l0/pkg/prometheus"
+)
+
+var (
+	// ErrClosed - брокер остановлен, новые подписки не принимаются
+	ErrClosed = errors.New("stream is closed")
+	// ErrTooManySubscribers - достигнут лимит одновременных подписчиков
+	ErrTooManySubscribers = errors.New("too many stream subscribers")
+	// ErrSlowSubscriber - подписчик не успевал читать события и был отключён
+	ErrSlowSubscriber = errors.New("subscriber is too slow")
+)
+
+// Summary - сводка о заказе без персональных данных покупателя
+type Summary struct {
+	OrderUID        string    `json:"order_uid"`
+	TrackNumber     string    `json:"track_number"`
+	CustomerID      string    `json:"customer_id"`
+	DeliveryService string    `json:"delivery_service"`
+	Amount          int       `json:"amount"`
+	Currency        string    `json:"currency"`
+	ItemsCount      int       `json:"items_count"`
+	DateCreated     time.Time `json:"date_created"`
+}
+
+func NewSummary(order *domain.Order) Summary {
+	return Summary{
+		OrderUID:        order.OrderUID,
+		TrackNumber:     order.TrackNumber,
+		CustomerID:      order.CustomerID,
+		DeliveryService: order.DeliveryService,
+		Amount:          order.Payment.Amount,
+		Currency:        order.Payment.Currency,
+		ItemsCount:      len(order.Items),
+		DateCreated:     order.DateCreated,
+	}
+}
+
+// Filter - серверный фильтр подписки, пустые поля не проверяются
+type Filter struct {
+	CustomerID      string
+	DeliveryService string
+	MinAmount       int
+}
+
+func (f Filter) Match(summary Summary) bool {
+	if f.CustomerID != "" && f.CustomerID != summary.CustomerID {
+		return false
+	}
+	if f.DeliveryService != "" && f.DeliveryService != summary.DeliveryService {
+		return false
+	}
+	return summary.Amount >= f.MinAmount
+}
+
+// Subscription - подписка на поток сводок. Канал Done закрывается при отключении подписчика сервером,
+// причина доступна через Err.
+type Subscription struct {
+	events chan Summary
+	filter Filter
+	done   chan struct{}
+	once   sync.Once
+	err    error
+}
+
+func (s *Subscription) Events() <-chan Summary {
+	return s.events
+}
+
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err возвращает причину отключения после закрытия Done
+func (s *Subscription) Err() error {
+	<-s.done
+	return s.err
+}
+
+func (s *Subscription) close(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
+
+// Broker раздаёт сводки подписчикам. Публикация не блокируется: подписчик с заполненным буфером отключается.
+type Broker struct {
+	mu             sync.Mutex
+	subscribers    map[*Subscription]struct{}
+	bufferSize     int
+	maxSubscribers int
+	closed         bool
+}
+
+func NewBroker(bufferSize, maxSubscribers int) *Broker {
+	return &Broker{
+		subscribers:    make(map[*Subscription]struct{}),
+		bufferSize:     bufferSize,
+		maxSubscribers: maxSubscribers,
+	}
+}
+
+func (b *Broker) Subscribe(filter Filter) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+	if b.maxSubscribers > 0 && len(b.subscribers) >= b.maxSubscribers {
+		return nil, ErrTooManySubscribers
+	}
+
+	sub := &Subscription{
+		events: make(chan Summary, b.bufferSize),
+		filter: filter,
+		done:   make(chan struct{}),
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	delete(b.subscribers, sub)
+	b.mu.Unlock()
+	sub.close(nil)
+}
+
+// OrderCreated публикует сводку о сохранённом заказе подходящим подписчикам
+func (b *Broker) OrderCreated(order *domain.Order) {
+	b.Publish(NewSummary(order))
+}
+
+func (b *Broker) Publish(summary Summary) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		if !sub.filter.Match(summary) {
+			continue
+		}
+		select {
+		case sub.events <- summary:
+			prometheus.StreamEventsSentTotal.Inc()
+		default:
+			delete(b.subscribers, sub)
+			sub.close(ErrSlowSubscriber)
+			prometheus.StreamSubscribersDroppedTotal.Inc()
+		}
+	}
+}
+
+// Close отключает всех подписчиков, вызывается при остановке HTTP сервера
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		sub.close(ErrClosed)
+	}
+}
@@ -0,0 +1,95 @@
+package stream_test
+
+import (
+	"testing"
+	"wb_l0/internal/stream"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func summary(uid, customer, service string, amount int) stream.Summary {
+	return stream.Summary{OrderUID: uid, CustomerID: customer, DeliveryService: service, Amount: amount}
+}
+
+func TestFilter_Match(t *testing.T) {
+	event := summary("1", "test", "meest", 1817)
+
+	tests := []struct {
+		name     string
+		filter   stream.Filter
+		expected bool
+	}{
+		{name: "empty filter", filter: stream.Filter{}, expected: true},
+		{name: "customer matches", filter: stream.Filter{CustomerID: "test"}, expected: true},
+		{name: "other customer", filter: stream.Filter{CustomerID: "other"}, expected: false},
+		{name: "other delivery service", filter: stream.Filter{DeliveryService: "dhl"}, expected: false},
+		{name: "amount equals minimum", filter: stream.Filter{MinAmount: 1817}, expected: true},
+		{name: "amount below minimum", filter: stream.Filter{MinAmount: 2000}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filter.Match(event))
+		})
+	}
+}
+
+func TestBroker(t *testing.T) {
+	t.Run("delivers matching events", func(t *testing.T) {
+		broker := stream.NewBroker(4, 0)
+		sub, err := broker.Subscribe(stream.Filter{CustomerID: "test"})
+		require.NoError(t, err)
+
+		broker.Publish(summary("1", "other", "meest", 100))
+		broker.Publish(summary("2", "test", "meest", 100))
+
+		require.Len(t, sub.Events(), 1)
+		assert.Equal(t, "2", (<-sub.Events()).OrderUID)
+	})
+
+	t.Run("drops slow subscriber without blocking others", func(t *testing.T) {
+		broker := stream.NewBroker(1, 0)
+		slow, err := broker.Subscribe(stream.Filter{})
+		require.NoError(t, err)
+		fast, err := broker.Subscribe(stream.Filter{})
+		require.NoError(t, err)
+
+		broker.Publish(summary("1", "test", "meest", 100))
+		<-fast.Events()
+		broker.Publish(summary("2", "test", "meest", 100))
+
+		assert.ErrorIs(t, slow.Err(), stream.ErrSlowSubscriber)
+		assert.Equal(t, "2", (<-fast.Events()).OrderUID)
+		select {
+		case <-fast.Done():
+			t.Fatal("fast subscriber must stay connected")
+		default:
+		}
+	})
+
+	t.Run("limits subscribers", func(t *testing.T) {
+		broker := stream.NewBroker(1, 1)
+		sub, err := broker.Subscribe(stream.Filter{})
+		require.NoError(t, err)
+
+		_, err = broker.Subscribe(stream.Filter{})
+		assert.ErrorIs(t, err, stream.ErrTooManySubscribers)
+
+		broker.Unsubscribe(sub)
+		_, err = broker.Subscribe(stream.Filter{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("close disconnects subscribers", func(t *testing.T) {
+		broker := stream.NewBroker(1, 0)
+		sub, err := broker.Subscribe(stream.Filter{})
+		require.NoError(t, err)
+
+		broker.Close()
+
+		assert.ErrorIs(t, sub.Err(), stream.ErrClosed)
+		_, err = broker.Subscribe(stream.Filter{})
+		assert.ErrorIs(t, err, stream.ErrClosed)
+	})
+}
@@ -8,5 +8,26 @@ import (
 type store interface {
 	SaveOrder(ctx context.Context, order *domain.Order) error
 	GetOrderByUID(ctx context.Context, orderUID string) (*domain.Order, error)
+	GetOrdersByUIDs(ctx context.Context, orderUIDs []string) ([]*domain.Order, error)
 	DeleteOrder(ctx context.Context, orderUID string) error
+	UpdateOrder(ctx context.Context, order *domain.Order, expectedVersion int) error
+	ListOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error)
+	UpdateItemStatus(ctx context.Context, change *domain.ItemStatusChange) error
+	SaveRefund(ctx context.Context, refund *domain.Refund) error
+	GetAuditLog(ctx context.Context, orderUID string) ([]domain.AuditEntry, error)
+}
+
+// hashedStore реализуют хранилища, которые хранят хеш содержимого вместе с заказом
+type hashedStore interface {
+	GetOrderWithHash(ctx context.Context, orderUID string) (*domain.Order, string, error)
+}
+
+// bulkStore реализуют хранилища с массовой загрузкой заказов
+type bulkStore interface {
+	BulkImport(ctx context.Context, orders []*domain.Order) (domain.ImportResult, error)
+}
+
+// Notifier получает сохранённые заказы, вызов не должен блокироваться
+type Notifier interface {
+	OrderCreated(order *domain.Order)
 }
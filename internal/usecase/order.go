@@ -2,20 +2,47 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"slices"
+	"strings"
 	"time"
 	"wb_l0/internal/domain"
 )
 
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+	// ExportPageSize - число заказов, читаемых из хранилища за один запрос при выгрузке
+	ExportPageSize = 500
+	// DefaultImportBatch - число заказов, сохраняемых одной транзакцией при массовой загрузке
+	DefaultImportBatch = 5000
+)
+
 type OrderUsecase struct {
-	store      store
-	retryCount int
-	log        *slog.Logger
+	store     store
+	retry     RetryPolicy
+	notifiers []Notifier
+	log       *slog.Logger
 }
 
-func NewOrderUsecase(store store, retryCount int, log *slog.Logger) *OrderUsecase {
-	return &OrderUsecase{store: store, retryCount: retryCount, log: log}
+type Option func(*OrderUsecase)
+
+// WithNotifier подписывает notifier на успешно сохранённые заказы, опцию можно передать несколько раз
+func WithNotifier(notifier Notifier) Option {
+	return func(uc *OrderUsecase) {
+		uc.notifiers = append(uc.notifiers, notifier)
+	}
+}
+
+func NewOrderUsecase(store store, retry RetryPolicy, log *slog.Logger, opts ...Option) *OrderUsecase {
+	uc := &OrderUsecase{store: store, retry: retry, log: log}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
 }
 
 func (uc *OrderUsecase) GetOrder(ctx context.Context, orderUID string) (*domain.Order, error) {
@@ -25,6 +52,309 @@ func (uc *OrderUsecase) GetOrder(ctx context.Context, orderUID string) (*domain.
 	}
 	return order, nil
 }
+
+// GetOrderWithHash возвращает заказ и хеш его содержимого для ETag
+func (uc *OrderUsecase) GetOrderWithHash(ctx context.Context, orderUID string) (*domain.Order, string, error) {
+	if hs, ok := uc.store.(hashedStore); ok {
+		return hs.GetOrderWithHash(ctx, orderUID)
+	}
+
+	order, err := uc.store.GetOrderByUID(ctx, orderUID)
+	if err != nil {
+		return nil, "", err
+	}
+	hash, err := order.ContentHash()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to hash order: %w", err)
+	}
+	return order, hash, nil
+}
+
+// GetOrders возвращает найденные заказы в порядке запроса и список отсутствующих UID
+func (uc *OrderUsecase) GetOrders(ctx context.Context, orderUIDs []string) ([]*domain.Order, []string, error) {
+	unique := make([]string, 0, len(orderUIDs))
+	seen := make(map[string]struct{}, len(orderUIDs))
+	for _, uid := range orderUIDs {
+		if _, ok := seen[uid]; ok {
+			continue
+		}
+		seen[uid] = struct{}{}
+		unique = append(unique, uid)
+	}
+
+	orders, err := uc.store.GetOrdersByUIDs(ctx, unique)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byUID := make(map[string]*domain.Order, len(orders))
+	for _, order := range orders {
+		byUID[order.OrderUID] = order
+	}
+	found := make([]*domain.Order, 0, len(orders))
+	missing := make([]string, 0)
+	for _, uid := range unique {
+		if order, ok := byUID[uid]; ok {
+			found = append(found, order)
+		} else {
+			missing = append(missing, uid)
+		}
+	}
+	return found, missing, nil
+}
+
+// ListOrders возвращает страницу заказов и курсор следующей страницы (nil на последней странице).
+// Limit приводится к диапазону 1..MaxListLimit.
+func (uc *OrderUsecase) ListOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order,
+	*domain.OrderCursor, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultListLimit
+	}
+	filter.Limit = min(filter.Limit, MaxListLimit)
+
+	limit := filter.Limit
+	// Лишний заказ показывает, есть ли следующая страница
+	filter.Limit++
+	orders, err := uc.store.ListOrders(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(orders) <= limit {
+		return orders, nil, nil
+	}
+
+	orders = orders[:limit]
+	last := orders[limit-1]
+	return orders, &domain.OrderCursor{DateCreated: last.DateCreated, OrderUID: last.OrderUID}, nil
+}
+
+// ExportOrders передаёт в fn все заказы, подходящие под фильтр, от новых к старым. Заказы читаются страницами
+// по ExportPageSize, поэтому память не зависит от объёма выгрузки. Limit фильтра игнорируется.
+func (uc *OrderUsecase) ExportOrders(ctx context.Context, filter domain.OrderFilter,
+	fn func(order *domain.Order) error) (int, error) {
+	startTime := time.Now()
+	filter.Limit = ExportPageSize
+
+	exported := 0
+	for {
+		orders, err := uc.store.ListOrders(ctx, filter)
+		if err != nil {
+			return exported, fmt.Errorf("failed to read orders page: %w", err)
+		}
+		for _, order := range orders {
+			if err := fn(order); err != nil {
+				return exported, err
+			}
+			exported++
+		}
+		if len(orders) < ExportPageSize {
+			break
+		}
+		last := orders[len(orders)-1]
+		filter.After = &domain.OrderCursor{DateCreated: last.DateCreated, OrderUID: last.OrderUID}
+	}
+
+	uc.log.Info("Orders exported",
+		"count", exported,
+		"customer_id", filter.CustomerID,
+		"processing_time_ms", time.Since(startTime).Milliseconds(),
+	)
+	return exported, nil
+}
+
+// ImportOrders читает заказы из next до io.EOF и сохраняет их массовой загрузкой пачками по batchSize,
+// каждая пачка - отдельная транзакция. Невалидные заказы и ошибки next, обёрнутые в domain.ErrValidation,
+// пропускаются и считаются в Invalid. При ошибке возвращается итог уже сохранённых пачек, поэтому загрузку
+// можно повторить: сохранённые заказы будут пропущены. Подписчики о загруженных заказах не уведомляются.
+func (uc *OrderUsecase) ImportOrders(ctx context.Context, next func() (*domain.Order, error),
+	batchSize int) (domain.ImportResult, error) {
+	bs, ok := uc.store.(bulkStore)
+	if !ok {
+		return domain.ImportResult{}, errors.New("store does not support bulk import")
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultImportBatch
+	}
+	startTime := time.Now()
+
+	var total domain.ImportResult
+	batch := make([]*domain.Order, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		result, err := bs.BulkImport(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("failed to import batch: %w", err)
+		}
+		total.Inserted += result.Inserted
+		total.Skipped += result.Skipped
+		batch = batch[:0]
+		uc.log.Info("Import batch saved",
+			"inserted", total.Inserted,
+			"skipped", total.Skipped,
+			"invalid", total.Invalid,
+		)
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, fmt.Errorf("context cancelled: %w", err)
+		}
+		order, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err == nil {
+			if err = uc.validateOrder(*order); err != nil {
+				err = fmt.Errorf("order %s: %w", order.OrderUID, err)
+			}
+		}
+		if errors.Is(err, domain.ErrValidation) {
+			total.Invalid++
+			uc.log.Warn("Invalid order skipped", "error", err.Error())
+			continue
+		}
+		if err != nil {
+			return total, err
+		}
+
+		batch = append(batch, order)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return total, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return total, err
+	}
+
+	uc.log.Info("Orders imported",
+		"inserted", total.Inserted,
+		"skipped", total.Skipped,
+		"invalid", total.Invalid,
+		"processing_time_ms", time.Since(startTime).Milliseconds(),
+	)
+	return total, nil
+}
+
+func (uc *OrderUsecase) DeleteOrder(ctx context.Context, orderUID string) error {
+	if err := uc.store.DeleteOrder(ctx, orderUID); err != nil {
+		return err
+	}
+	uc.log.Info("Order deleted", "order_uid", orderUID)
+	return nil
+}
+
+// GetAuditLog возвращает журнал аудита заказа. Журнал доступен и для удалённого заказа,
+// пустой журнал означает, что заказа никогда не было.
+func (uc *OrderUsecase) GetAuditLog(ctx context.Context, orderUID string) ([]domain.AuditEntry, error) {
+	entries, err := uc.store.GetAuditLog(ctx, orderUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("audit log of order %s: %w", orderUID, domain.ErrRecordNotFound)
+	}
+	return entries, nil
+}
+
+// ChangeItemStatus переводит позицию заказа в статус status, если переход из текущего статуса допустим.
+// actor - инициатор изменения, он сохраняется в истории статусов.
+func (uc *OrderUsecase) ChangeItemStatus(ctx context.Context, orderUID string, chrtID, status int,
+	actor string) (*domain.ItemStatusChange, error) {
+	order, err := uc.store.GetOrderByUID(ctx, orderUID)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(order.Items, func(item domain.Item) bool { return item.ChrtID == chrtID })
+	if i < 0 {
+		return nil, fmt.Errorf("item %d of order %s: %w", chrtID, orderUID, domain.ErrRecordNotFound)
+	}
+
+	from := order.Items[i].Status
+	if !domain.CanChangeItemStatus(from, status) {
+		return nil, fmt.Errorf("%w: %d -> %d", domain.ErrInvalidTransition, from, status)
+	}
+
+	change := &domain.ItemStatusChange{OrderUID: orderUID, ChrtID: chrtID, From: from, To: status, Actor: actor}
+	if err := uc.store.UpdateItemStatus(ctx, change); err != nil {
+		return nil, err
+	}
+
+	uc.log.Info("Item status changed",
+		"order_uid", orderUID,
+		"chrt_id", chrtID,
+		"from", from,
+		"to", status,
+		"actor", actor,
+	)
+	return change, nil
+}
+
+// Refund оформляет возврат. Без ChrtID заказ отменяется, а по умолчанию возвращается весь остаток оплаты;
+// с ChrtID по умолчанию возвращается не возвращённая ранее стоимость позиции. Сумма возвратов не может
+// превышать ни стоимость позиции, ни сумму оплаты заказа.
+func (uc *OrderUsecase) Refund(ctx context.Context, refund domain.Refund) (*domain.Refund, error) {
+	refund.Reason = strings.TrimSpace(refund.Reason)
+	if refund.Reason == "" {
+		return nil, fmt.Errorf("%w: refund reason is required", domain.ErrValidation)
+	}
+	if refund.Amount < 0 {
+		return nil, fmt.Errorf("%w: refund amount must not be negative", domain.ErrValidation)
+	}
+
+	order, err := uc.store.GetOrderByUID(ctx, refund.OrderUID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Cancellation != nil {
+		return nil, fmt.Errorf("order %s is already cancelled: %w", refund.OrderUID, domain.ErrConflict)
+	}
+
+	remaining := order.Payment.Amount - order.RefundedAmount()
+	if refund.ChrtID == 0 {
+		if refund.Amount == 0 {
+			refund.Amount = remaining
+		}
+	} else {
+		itemPaid, found := order.ItemPaidAmount(refund.ChrtID)
+		if !found {
+			return nil, fmt.Errorf("item %d of order %s: %w", refund.ChrtID, refund.OrderUID,
+				domain.ErrRecordNotFound)
+		}
+		itemRemaining := itemPaid - order.ItemRefundedAmount(refund.ChrtID)
+		if refund.Amount == 0 {
+			refund.Amount = itemRemaining
+		}
+		if refund.Amount == 0 || refund.Amount > itemRemaining {
+			return nil, fmt.Errorf("%w: item %d has %d left to refund, requested %d",
+				domain.ErrRefundExceeded, refund.ChrtID, itemRemaining, refund.Amount)
+		}
+	}
+	if refund.Amount > remaining {
+		return nil, fmt.Errorf("%w: order has %d left to refund, requested %d",
+			domain.ErrRefundExceeded, remaining, refund.Amount)
+	}
+
+	if err := uc.store.SaveRefund(ctx, &refund); err != nil {
+		return nil, err
+	}
+
+	uc.log.Info("Refund created",
+		"order_uid", refund.OrderUID,
+		"chrt_id", refund.ChrtID,
+		"amount", refund.Amount,
+		"cancelled", refund.ChrtID == 0,
+		"actor", refund.Actor,
+	)
+	return &refund, nil
+}
+
+// CreateOrder сохраняет новый заказ. Повтор заказа с теми же данными пропускается, а заказ с тем же order_uid
+// и другими данными возвращает domain.ContentConflictError.
 func (uc *OrderUsecase) CreateOrder(ctx context.Context, order domain.Order) error {
 	startTime := time.Now()
 	uc.log.Info("Order creation started",
@@ -42,33 +372,59 @@ func (uc *OrderUsecase) CreateOrder(ctx context.Context, order domain.Order) err
 	)
 
 	var lastErr error
+	attempts := uc.retry.attempts()
+
+	for i := 0; i < attempts; i++ {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("context cancelled: %w", err)
+		}
 
-	for i := 0; i < uc.retryCount; i++ {
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("context cancelled: %w", ctx.Err())
-		default:
-			err := uc.store.SaveOrder(ctx, &order)
-			if err == nil {
-
-				uc.log.Info("Order business processing completed",
-					"order_uid", order.OrderUID,
-					"items_count", len(order.Items),
-					"processing_time_ms", time.Since(startTime).Milliseconds(),
-				)
-				return nil
+		err := uc.store.SaveOrder(ctx, &order)
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			uc.log.Info("Order replay skipped", "order_uid", order.OrderUID)
+			return nil
+		}
+		if errors.Is(err, domain.ErrContentConflict) {
+			// Что делать с расходящимся заказом, решает вызывающий: Kafka применяет KAFKA_DUPLICATE_POLICY,
+			// gRPC отвечает AlreadyExists
+			uc.log.Warn("Order conflicts with stored order", "order_uid", order.OrderUID)
+			return err
+		}
+		if err == nil {
+			uc.log.Info("Order business processing completed",
+				"order_uid", order.OrderUID,
+				"items_count", len(order.Items),
+				"processing_time_ms", time.Since(startTime).Milliseconds(),
+			)
+			for _, notifier := range uc.notifiers {
+				notifier.OrderCreated(&order)
 			}
+			return nil
+		}
 
-			lastErr = err
-			uc.log.Error("Retry for order %s failed: %v",
+		lastErr = err
+		if !errors.Is(err, domain.ErrTransient) {
+			uc.log.Error("Order save failed with permanent error",
+				"order_uid", order.OrderUID,
 				"error", err,
 				"retry", i+1,
-				"retry_count", uc.retryCount,
-				"order_uid", order.OrderUID,
 			)
+			return err
+		}
+		if i == attempts-1 {
+			break
+		}
 
-			delay := time.Duration(1<<uint(i)) * time.Second
-			time.Sleep(delay)
+		delay := uc.retry.delay(i)
+		uc.log.Warn("Order save failed, retrying",
+			"error", err,
+			"retry", i+1,
+			"retry_count", attempts,
+			"delay_ms", delay.Milliseconds(),
+			"order_uid", order.OrderUID,
+		)
+		if err := sleepContext(ctx, delay); err != nil {
+			return fmt.Errorf("context cancelled: %w", err)
 		}
 	}
 
@@ -80,9 +436,30 @@ func (uc *OrderUsecase) CreateOrder(ctx context.Context, order domain.Order) err
 	return lastErr
 }
 
+// UpdateOrder заменяет сохранённый заказ, если его версия равна expectedVersion, иначе возвращает
+// domain.ErrConflict. Обновлённый заказ получает версию order.Version, но не меньше expectedVersion+1.
+func (uc *OrderUsecase) UpdateOrder(ctx context.Context, order domain.Order, expectedVersion int) (*domain.Order,
+	error) {
+	if err := uc.validateOrder(order); err != nil {
+		return nil, err
+	}
+	order.Version = max(order.Version, expectedVersion+1)
+
+	if err := uc.store.UpdateOrder(ctx, &order, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	uc.log.Info("Order updated",
+		"order_uid", order.OrderUID,
+		"version", order.Version,
+		"actor", domain.ActorFromContext(ctx),
+	)
+	return &order, nil
+}
+
 func (uc *OrderUsecase) validateOrder(order domain.Order) error {
 	if err := order.Validate(); err != nil {
-		return err
+		return fmt.Errorf("%w: %w", domain.ErrValidation, err)
 	}
 	return nil
 }
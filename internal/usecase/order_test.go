@@ -3,6 +3,9 @@ package usecase_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"slices"
 	"testing"
 	"time"
 	"wb_l0/internal/domain"
@@ -25,12 +28,17 @@ func (m *MockStore) GetOrderByUID(ctx context.Context, orderUID string) (*domain
 	return args.Get(0).(*domain.Order), args.Error(1)
 }
 
-func (m *MockStore) DeleteOrder(ctx context.Context, orderUID string) error {
-	args := m.Called(ctx, orderUID)
+func (m *MockStore) GetOrdersByUIDs(ctx context.Context, orderUIDs []string) ([]*domain.Order, error) {
+	args := m.Called(ctx, orderUIDs)
 	if args.Get(0) == nil {
-		return nil
+		return nil, args.Error(1)
 	}
-	return args.Error(1)
+	return args.Get(0).([]*domain.Order), args.Error(1)
+}
+
+func (m *MockStore) DeleteOrder(ctx context.Context, orderUID string) error {
+	args := m.Called(ctx, orderUID)
+	return args.Error(0)
 }
 
 func (m *MockStore) SaveOrder(ctx context.Context, order *domain.Order) error {
@@ -38,10 +46,58 @@ func (m *MockStore) SaveOrder(ctx context.Context, order *domain.Order) error {
 	return args.Error(0)
 }
 
+func (m *MockStore) ListOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Order), args.Error(1)
+}
+
+func (m *MockStore) UpdateItemStatus(ctx context.Context, change *domain.ItemStatusChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+func (m *MockStore) SaveRefund(ctx context.Context, refund *domain.Refund) error {
+	args := m.Called(ctx, refund)
+	return args.Error(0)
+}
+
+func (m *MockStore) UpdateOrder(ctx context.Context, order *domain.Order, expectedVersion int) error {
+	args := m.Called(ctx, order, expectedVersion)
+	return args.Error(0)
+}
+
+func (m *MockStore) GetAuditLog(ctx context.Context, orderUID string) ([]domain.AuditEntry, error) {
+	args := m.Called(ctx, orderUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.AuditEntry), args.Error(1)
+}
+
+// MockBulkStore - хранилище с массовой загрузкой
+type MockBulkStore struct {
+	MockStore
+}
+
+func (m *MockBulkStore) BulkImport(ctx context.Context, orders []*domain.Order) (domain.ImportResult, error) {
+	args := m.Called(ctx, orders)
+	return args.Get(0).(domain.ImportResult), args.Error(1)
+}
+
+var testRetryPolicy = usecase.RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    5 * time.Millisecond,
+	Jitter:      0.5,
+}
+
 func TestOrderUsecase_GetOrder(t *testing.T) {
 	log := logger.NewTestLogger()
 	mockStore := new(MockStore)
-	uc := usecase.NewOrderUsecase(mockStore, 3, log)
+	uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
 
 	t.Run("successful get order", func(t *testing.T) {
 		expectedOrder := &domain.Order{
@@ -74,10 +130,426 @@ func TestOrderUsecase_GetOrder(t *testing.T) {
 	})
 }
 
+func TestOrderUsecase_GetOrderWithHash(t *testing.T) {
+	log := logger.NewTestLogger()
+	mockStore := new(MockStore)
+	uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
+
+	t.Run("hash is computed when store does not keep it", func(t *testing.T) {
+		order := domain.CreateTestOrder(1)
+		expectedHash, err := order.ContentHash()
+		assert.NoError(t, err)
+
+		mockStore.On("GetOrderByUID", mock.Anything, order.OrderUID).
+			Return(&order, nil).
+			Once()
+
+		got, hash, err := uc.GetOrderWithHash(context.Background(), order.OrderUID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &order, got)
+		assert.Equal(t, expectedHash, hash)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("order not found", func(t *testing.T) {
+		mockStore.On("GetOrderByUID", mock.Anything, "not-found").
+			Return(nil, domain.ErrRecordNotFound).
+			Once()
+
+		got, hash, err := uc.GetOrderWithHash(context.Background(), "not-found")
+
+		assert.True(t, errors.Is(err, domain.ErrRecordNotFound))
+		assert.Nil(t, got)
+		assert.Empty(t, hash)
+	})
+}
+
+func TestOrderUsecase_GetOrders(t *testing.T) {
+	log := logger.NewTestLogger()
+	mockStore := new(MockStore)
+	uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
+
+	t.Run("found and missing orders in request order", func(t *testing.T) {
+		first := &domain.Order{OrderUID: "uid-1"}
+		third := &domain.Order{OrderUID: "uid-3"}
+
+		mockStore.On("GetOrdersByUIDs", mock.Anything, []string{"uid-3", "uid-2", "uid-1"}).
+			Return([]*domain.Order{first, third}, nil).
+			Once()
+
+		orders, missing, err := uc.GetOrders(context.Background(), []string{"uid-3", "uid-2", "uid-1", "uid-3"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*domain.Order{third, first}, orders)
+		assert.Equal(t, []string{"uid-2"}, missing)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		mockStore.On("GetOrdersByUIDs", mock.Anything, []string{"uid-4"}).
+			Return(nil, errors.New("database error")).
+			Once()
+
+		orders, missing, err := uc.GetOrders(context.Background(), []string{"uid-4"})
+
+		assert.Error(t, err)
+		assert.Nil(t, orders)
+		assert.Nil(t, missing)
+		mockStore.AssertExpectations(t)
+	})
+}
+
+func TestOrderUsecase_ListOrders(t *testing.T) {
+	log := logger.NewTestLogger()
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	page := []*domain.Order{
+		{OrderUID: "00000000000000000003", DateCreated: created},
+		{OrderUID: "00000000000000000002", DateCreated: created},
+		{OrderUID: "00000000000000000001", DateCreated: created},
+	}
+
+	t.Run("next page cursor", func(t *testing.T) {
+		mockStore := new(MockStore)
+		uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
+		mockStore.On("ListOrders", context.Background(), domain.OrderFilter{Limit: 3}).Return(page, nil).Once()
+
+		orders, next, err := uc.ListOrders(context.Background(), domain.OrderFilter{Limit: 2})
+
+		assert.NoError(t, err)
+		assert.Len(t, orders, 2)
+		assert.Equal(t, &domain.OrderCursor{DateCreated: created, OrderUID: "00000000000000000002"}, next)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		mockStore := new(MockStore)
+		uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
+		mockStore.On("ListOrders", context.Background(),
+			domain.OrderFilter{Limit: usecase.DefaultListLimit + 1}).Return(page, nil).Once()
+
+		orders, next, err := uc.ListOrders(context.Background(), domain.OrderFilter{})
+
+		assert.NoError(t, err)
+		assert.Len(t, orders, 3)
+		assert.Nil(t, next)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("limit is capped", func(t *testing.T) {
+		mockStore := new(MockStore)
+		uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
+		mockStore.On("ListOrders", context.Background(),
+			domain.OrderFilter{Limit: usecase.MaxListLimit + 1}).Return(nil, nil).Once()
+
+		orders, next, err := uc.ListOrders(context.Background(), domain.OrderFilter{Limit: 100000})
+
+		assert.NoError(t, err)
+		assert.Empty(t, orders)
+		assert.Nil(t, next)
+		mockStore.AssertExpectations(t)
+	})
+}
+
+func TestOrderUsecase_ExportOrders(t *testing.T) {
+	log := logger.NewTestLogger()
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	firstPage := make([]*domain.Order, usecase.ExportPageSize)
+	for i := range firstPage {
+		firstPage[i] = &domain.Order{OrderUID: fmt.Sprintf("%020d", usecase.ExportPageSize-i+1), DateCreated: created}
+	}
+	secondPage := []*domain.Order{{OrderUID: "00000000000000000001", DateCreated: created}}
+	filter := domain.OrderFilter{CustomerID: "test", Limit: 10}
+
+	t.Run("reads all pages", func(t *testing.T) {
+		mockStore := new(MockStore)
+		uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
+		mockStore.On("ListOrders", context.Background(),
+			domain.OrderFilter{CustomerID: "test", Limit: usecase.ExportPageSize}).Return(firstPage, nil).Once()
+		mockStore.On("ListOrders", context.Background(), domain.OrderFilter{
+			CustomerID: "test",
+			Limit:      usecase.ExportPageSize,
+			After:      &domain.OrderCursor{DateCreated: created, OrderUID: "00000000000000000002"},
+		}).Return(secondPage, nil).Once()
+
+		var last string
+		count, err := uc.ExportOrders(context.Background(), filter, func(order *domain.Order) error {
+			last = order.OrderUID
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, usecase.ExportPageSize+1, count)
+		assert.Equal(t, "00000000000000000001", last)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("writer error stops export", func(t *testing.T) {
+		mockStore := new(MockStore)
+		uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
+		mockStore.On("ListOrders", context.Background(), mock.Anything).Return(firstPage, nil).Once()
+		writeErr := errors.New("broken pipe")
+
+		count, err := uc.ExportOrders(context.Background(), filter, func(*domain.Order) error {
+			return writeErr
+		})
+
+		assert.ErrorIs(t, err, writeErr)
+		assert.Zero(t, count)
+		mockStore.AssertExpectations(t)
+	})
+}
+
+func TestOrderUsecase_ImportOrders(t *testing.T) {
+	log := logger.NewTestLogger()
+	valid := func(id int) *domain.Order {
+		order := domain.CreateTestOrder(id)
+		return &order
+	}
+	invalid := valid(3)
+	invalid.Locale = ""
+	// source отдаёт заказы и ошибки по очереди, затем io.EOF
+	source := func(steps ...any) func() (*domain.Order, error) {
+		return func() (*domain.Order, error) {
+			if len(steps) == 0 {
+				return nil, io.EOF
+			}
+			step := steps[0]
+			steps = steps[1:]
+			if err, ok := step.(error); ok {
+				return nil, err
+			}
+			return step.(*domain.Order), nil
+		}
+	}
+
+	t.Run("imports in batches and skips invalid orders", func(t *testing.T) {
+		store := new(MockBulkStore)
+		uc := usecase.NewOrderUsecase(store, testRetryPolicy, log)
+		first, second, third := valid(1), valid(2), valid(4)
+		store.On("BulkImport", mock.Anything, []*domain.Order{first, second}).
+			Return(domain.ImportResult{Inserted: 1, Skipped: 1}, nil).Once()
+		store.On("BulkImport", mock.Anything, []*domain.Order{third}).
+			Return(domain.ImportResult{Inserted: 1}, nil).Once()
+
+		result, err := uc.ImportOrders(context.Background(), source(
+			first, invalid, fmt.Errorf("line 3: %w: unexpected EOF", domain.ErrValidation), second, third,
+		), 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ImportResult{Inserted: 2, Skipped: 1, Invalid: 2}, result)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("store error stops import with saved batches counted", func(t *testing.T) {
+		store := new(MockBulkStore)
+		uc := usecase.NewOrderUsecase(store, testRetryPolicy, log)
+		first, second := valid(1), valid(2)
+		store.On("BulkImport", mock.Anything, []*domain.Order{first}).
+			Return(domain.ImportResult{Inserted: 1}, nil).Once()
+		store.On("BulkImport", mock.Anything, []*domain.Order{second}).
+			Return(domain.ImportResult{}, domain.ErrTransient).Once()
+
+		result, err := uc.ImportOrders(context.Background(), source(first, second), 1)
+
+		assert.ErrorIs(t, err, domain.ErrTransient)
+		assert.Equal(t, domain.ImportResult{Inserted: 1}, result)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("read error stops import", func(t *testing.T) {
+		store := new(MockBulkStore)
+		uc := usecase.NewOrderUsecase(store, testRetryPolicy, log)
+		readErr := errors.New("read failed")
+
+		_, err := uc.ImportOrders(context.Background(), source(readErr), 1)
+
+		assert.ErrorIs(t, err, readErr)
+		store.AssertNotCalled(t, "BulkImport", mock.Anything, mock.Anything)
+	})
+
+	t.Run("store without bulk import", func(t *testing.T) {
+		uc := usecase.NewOrderUsecase(new(MockStore), testRetryPolicy, log)
+
+		_, err := uc.ImportOrders(context.Background(), source(), 1)
+
+		assert.ErrorContains(t, err, "does not support bulk import")
+	})
+}
+
+func TestOrderUsecase_DeleteOrder(t *testing.T) {
+	log := logger.NewTestLogger()
+	mockStore := new(MockStore)
+	uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
+
+	t.Run("successful delete", func(t *testing.T) {
+		mockStore.On("DeleteOrder", mock.Anything, "test-uid").
+			Return(nil).
+			Once()
+
+		err := uc.DeleteOrder(context.Background(), "test-uid")
+
+		assert.NoError(t, err)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("order not found", func(t *testing.T) {
+		mockStore.On("DeleteOrder", mock.Anything, "not-found").
+			Return(domain.ErrRecordNotFound).
+			Once()
+
+		err := uc.DeleteOrder(context.Background(), "not-found")
+
+		assert.True(t, errors.Is(err, domain.ErrRecordNotFound))
+		mockStore.AssertExpectations(t)
+	})
+}
+
+func TestOrderUsecase_ChangeItemStatus(t *testing.T) {
+	log := logger.NewTestLogger()
+	mockStore := new(MockStore)
+	uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
+
+	order := domain.CreateTestOrder(1)
+	order.OrderUID = "b563feb7b2b84b6test1"
+	chrtID := order.Items[0].ChrtID
+	mockStore.On("GetOrderByUID", mock.Anything, order.OrderUID).Return(&order, nil)
+
+	t.Run("allowed transition is stored with actor", func(t *testing.T) {
+		mockStore.On("UpdateItemStatus", mock.Anything, &domain.ItemStatusChange{
+			OrderUID: order.OrderUID, ChrtID: chrtID, From: 202, To: 300, Actor: "http:ops",
+		}).Return(nil).Once()
+
+		change, err := uc.ChangeItemStatus(context.Background(), order.OrderUID, chrtID, 300, "http:ops")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 202, change.From)
+		assert.Equal(t, 300, change.To)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("forbidden transition", func(t *testing.T) {
+		_, err := uc.ChangeItemStatus(context.Background(), order.OrderUID, chrtID, 200, "http:ops")
+
+		assert.True(t, errors.Is(err, domain.ErrInvalidTransition), "got %v", err)
+	})
+
+	t.Run("unknown item", func(t *testing.T) {
+		_, err := uc.ChangeItemStatus(context.Background(), order.OrderUID, 1, 400, "http:ops")
+
+		assert.True(t, errors.Is(err, domain.ErrRecordNotFound), "got %v", err)
+	})
+
+	t.Run("concurrent change", func(t *testing.T) {
+		mockStore.On("UpdateItemStatus", mock.Anything, mock.AnythingOfType("*domain.ItemStatusChange")).
+			Return(fmt.Errorf("status changed concurrently: %w", domain.ErrConflict)).Once()
+
+		_, err := uc.ChangeItemStatus(context.Background(), order.OrderUID, chrtID, 400, "http:ops")
+
+		assert.True(t, errors.Is(err, domain.ErrConflict), "got %v", err)
+		mockStore.AssertExpectations(t)
+	})
+}
+
+func TestOrderUsecase_Refund(t *testing.T) {
+	log := logger.NewTestLogger()
+	mockStore := new(MockStore)
+	uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
+
+	order := domain.CreateTestOrder(1)
+	order.OrderUID = "b563feb7b2b84b6test1"
+	order.Payment.Amount = 1000
+	order.Items[0].TotalPrice = 300
+	chrtID := order.Items[0].ChrtID
+	order.Refunds = []domain.Refund{{ChrtID: chrtID, Amount: 100}}
+
+	cancelled := order
+	cancelled.Cancellation = &domain.Cancellation{Reason: "customer request"}
+
+	mockStore.On("GetOrderByUID", mock.Anything, order.OrderUID).Return(&order, nil)
+
+	t.Run("item refund defaults to the rest of the item price", func(t *testing.T) {
+		mockStore.On("SaveRefund", mock.Anything, mock.MatchedBy(func(refund *domain.Refund) bool {
+			return refund.ChrtID == chrtID && refund.Amount == 200 && refund.Actor == "kafka"
+		})).Return(nil).Once()
+
+		refund, err := uc.Refund(context.Background(), domain.Refund{
+			OrderUID: order.OrderUID, ChrtID: chrtID, Reason: " damaged ", Actor: "kafka",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 200, refund.Amount)
+		assert.Equal(t, "damaged", refund.Reason)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("cancellation refunds the rest of the payment", func(t *testing.T) {
+		mockStore.On("SaveRefund", mock.Anything, mock.MatchedBy(func(refund *domain.Refund) bool {
+			return refund.ChrtID == 0 && refund.Amount == 900
+		})).Return(nil).Once()
+
+		refund, err := uc.Refund(context.Background(), domain.Refund{OrderUID: order.OrderUID, Reason: "fraud"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 900, refund.Amount)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("item refund above the item price", func(t *testing.T) {
+		_, err := uc.Refund(context.Background(), domain.Refund{
+			OrderUID: order.OrderUID, ChrtID: chrtID, Amount: 250, Reason: "damaged",
+		})
+
+		assert.True(t, errors.Is(err, domain.ErrRefundExceeded), "got %v", err)
+	})
+
+	t.Run("item split across lines is refunded up to their total", func(t *testing.T) {
+		split := order
+		split.OrderUID = "b563feb7b2b84b6test3"
+		split.Items = append(slices.Clone(order.Items), order.Items[0])
+		mockStore.On("GetOrderByUID", mock.Anything, split.OrderUID).Return(&split, nil).Once()
+		mockStore.On("SaveRefund", mock.Anything, mock.MatchedBy(func(refund *domain.Refund) bool {
+			return refund.OrderUID == split.OrderUID && refund.Amount == 500
+		})).Return(nil).Once()
+
+		refund, err := uc.Refund(context.Background(), domain.Refund{
+			OrderUID: split.OrderUID, ChrtID: chrtID, Reason: "damaged",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 500, refund.Amount)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("refund above the payment", func(t *testing.T) {
+		_, err := uc.Refund(context.Background(), domain.Refund{
+			OrderUID: order.OrderUID, Amount: 901, Reason: "fraud",
+		})
+
+		assert.True(t, errors.Is(err, domain.ErrRefundExceeded), "got %v", err)
+	})
+
+	t.Run("cancelled order", func(t *testing.T) {
+		mockStore.On("GetOrderByUID", mock.Anything, "b563feb7b2b84b6test2").Return(&cancelled, nil).Once()
+
+		_, err := uc.Refund(context.Background(), domain.Refund{
+			OrderUID: "b563feb7b2b84b6test2", ChrtID: chrtID, Reason: "damaged",
+		})
+
+		assert.True(t, errors.Is(err, domain.ErrConflict), "got %v", err)
+	})
+
+	t.Run("reason is required", func(t *testing.T) {
+		_, err := uc.Refund(context.Background(), domain.Refund{OrderUID: order.OrderUID, Reason: "  "})
+
+		assert.True(t, errors.Is(err, domain.ErrValidation), "got %v", err)
+	})
+}
+
 func TestOrderUsecase_CreateOrder(t *testing.T) {
 	log := logger.NewTestLogger()
 	mockStore := new(MockStore)
-	uc := usecase.NewOrderUsecase(mockStore, 3, log)
+	uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
 
 	validOrder := domain.CreateTestOrder(1)
 
@@ -99,25 +571,94 @@ func TestOrderUsecase_CreateOrder(t *testing.T) {
 		err := uc.CreateOrder(context.Background(), invalidOrder)
 
 		assert.Error(t, err)
+		assert.True(t, errors.Is(err, domain.ErrValidation))
 		mockStore.AssertNotCalled(t, "SaveOrder")
 	})
 
-	t.Run("retry on save failure", func(t *testing.T) {
+	t.Run("retry on transient save failure", func(t *testing.T) {
 		mockStore.On("SaveOrder", mock.Anything, mock.AnythingOfType("*domain.Order")).
-			Return(errors.New("database error")).
+			Return(fmt.Errorf("%w: database error", domain.ErrTransient)).
 			Times(3)
 
 		err := uc.CreateOrder(context.Background(), validOrder)
 
 		assert.Error(t, err)
+		assert.True(t, errors.Is(err, domain.ErrTransient))
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("success after transient failure", func(t *testing.T) {
+		mockStore.On("SaveOrder", mock.Anything, mock.AnythingOfType("*domain.Order")).
+			Return(fmt.Errorf("%w: connection reset", domain.ErrTransient)).
+			Once()
+		mockStore.On("SaveOrder", mock.Anything, mock.AnythingOfType("*domain.Order")).
+			Return(nil).
+			Once()
+
+		err := uc.CreateOrder(context.Background(), validOrder)
+
+		assert.NoError(t, err)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("permanent error is not retried", func(t *testing.T) {
+		mockStore.On("SaveOrder", mock.Anything, mock.AnythingOfType("*domain.Order")).
+			Return(fmt.Errorf("%w: check constraint violated", domain.ErrValidation)).
+			Once()
+
+		err := uc.CreateOrder(context.Background(), validOrder)
+
+		assert.Error(t, err)
+		assert.True(t, errors.Is(err, domain.ErrValidation))
 		mockStore.AssertExpectations(t)
 	})
+
+	t.Run("unclassified error is not retried", func(t *testing.T) {
+		mockStore.On("SaveOrder", mock.Anything, mock.AnythingOfType("*domain.Order")).
+			Return(errors.New("database error")).
+			Once()
+
+		err := uc.CreateOrder(context.Background(), validOrder)
+
+		assert.Error(t, err)
+		mockStore.AssertExpectations(t)
+	})
+}
+
+type recordingNotifier struct {
+	orders []string
+}
+
+func (n *recordingNotifier) OrderCreated(order *domain.Order) {
+	n.orders = append(n.orders, order.OrderUID)
+}
+
+func TestOrderUsecase_CreateOrderNotifies(t *testing.T) {
+	log := logger.NewTestLogger()
+	mockStore := new(MockStore)
+	notifier := &recordingNotifier{}
+	uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log, usecase.WithNotifier(notifier))
+
+	saved := domain.CreateTestOrder(1)
+	failed := domain.CreateTestOrder(2)
+	mockStore.On("SaveOrder", mock.Anything, mock.MatchedBy(func(o *domain.Order) bool {
+		return o.OrderUID == saved.OrderUID
+	})).Return(nil).Once()
+	mockStore.On("SaveOrder", mock.Anything, mock.MatchedBy(func(o *domain.Order) bool {
+		return o.OrderUID == failed.OrderUID
+	})).Return(errors.New("database error")).Once()
+
+	assert.NoError(t, uc.CreateOrder(context.Background(), saved))
+	assert.Error(t, uc.CreateOrder(context.Background(), failed))
+
+	assert.Equal(t, []string{saved.OrderUID}, notifier.orders)
+	mockStore.AssertExpectations(t)
 }
 
 func TestOrderUsecase_ContextCancellation(t *testing.T) {
 	log := logger.NewTestLogger()
 	mockStore := new(MockStore)
-	uc := usecase.NewOrderUsecase(mockStore, 3, log)
+	uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
 
 	validOrder := domain.CreateTestOrder(1)
 
@@ -133,4 +674,125 @@ func TestOrderUsecase_ContextCancellation(t *testing.T) {
 
 		assert.Error(t, err)
 	})
+
+	t.Run("context cancellation interrupts backoff", func(t *testing.T) {
+		mockStore := new(MockStore)
+		slowRetry := usecase.RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+		uc := usecase.NewOrderUsecase(mockStore, slowRetry, log)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		mockStore.On("SaveOrder", mock.Anything, mock.AnythingOfType("*domain.Order")).
+			Return(fmt.Errorf("%w: database error", domain.ErrTransient)).
+			Once()
+
+		start := time.Now()
+		err := uc.CreateOrder(ctx, validOrder)
+
+		assert.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestOrderUsecase_GetAuditLog(t *testing.T) {
+	log := logger.NewTestLogger()
+	mockStore := new(MockStore)
+	uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
+
+	t.Run("entries of deleted order", func(t *testing.T) {
+		entries := []domain.AuditEntry{
+			{ID: 1, OrderUID: "uid-1", Actor: "kafka", Operation: domain.AuditCreate},
+			{ID: 2, OrderUID: "uid-1", Actor: "http:ops", Operation: domain.AuditDelete},
+		}
+		mockStore.On("GetAuditLog", mock.Anything, "uid-1").Return(entries, nil).Once()
+
+		result, err := uc.GetAuditLog(context.Background(), "uid-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, entries, result)
+	})
+
+	t.Run("empty log is not found", func(t *testing.T) {
+		mockStore.On("GetAuditLog", mock.Anything, "uid-2").Return([]domain.AuditEntry(nil), nil).Once()
+
+		result, err := uc.GetAuditLog(context.Background(), "uid-2")
+
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+		assert.Nil(t, result)
+	})
+
+	mockStore.AssertExpectations(t)
+}
+
+func TestOrderUsecase_CreateOrderDuplicate(t *testing.T) {
+	log := logger.NewTestLogger()
+	order := domain.CreateTestOrder(1)
+
+	t.Run("conflict is returned to the caller", func(t *testing.T) {
+		mockStore := new(MockStore)
+		notifier := &recordingNotifier{}
+		uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log, usecase.WithNotifier(notifier))
+		mockStore.On("SaveOrder", mock.Anything, mock.Anything).Return(&domain.ContentConflictError{
+			OrderUID: order.OrderUID,
+			Diff:     map[string]domain.AuditChange{"payment.amount": {Before: 1817, After: 1900}},
+		}).Once()
+
+		err := uc.CreateOrder(context.Background(), order)
+
+		assert.ErrorIs(t, err, domain.ErrContentConflict)
+		assert.Empty(t, notifier.orders)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("replay is skipped", func(t *testing.T) {
+		mockStore := new(MockStore)
+		uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
+		mockStore.On("SaveOrder", mock.Anything, mock.Anything).
+			Return(fmt.Errorf("order: %w", domain.ErrAlreadyExists)).Once()
+
+		err := uc.CreateOrder(context.Background(), order)
+
+		assert.NoError(t, err)
+		mockStore.AssertExpectations(t)
+	})
+}
+
+func TestOrderUsecase_UpdateOrder(t *testing.T) {
+	log := logger.NewTestLogger()
+	mockStore := new(MockStore)
+	uc := usecase.NewOrderUsecase(mockStore, testRetryPolicy, log)
+	order := domain.CreateTestOrder(1)
+
+	t.Run("next version", func(t *testing.T) {
+		mockStore.On("UpdateOrder", mock.Anything, mock.MatchedBy(func(o *domain.Order) bool {
+			return o.Version == 4
+		}), 3).Return(nil).Once()
+
+		updated, err := uc.UpdateOrder(context.Background(), order, 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, updated.Version)
+	})
+
+	t.Run("version conflict", func(t *testing.T) {
+		mockStore.On("UpdateOrder", mock.Anything, mock.Anything, 1).
+			Return(fmt.Errorf("stale: %w", domain.ErrConflict)).Once()
+
+		_, err := uc.UpdateOrder(context.Background(), order, 1)
+
+		assert.ErrorIs(t, err, domain.ErrConflict)
+	})
+
+	t.Run("invalid order", func(t *testing.T) {
+		invalid := order
+		invalid.Items = nil
+
+		_, err := uc.UpdateOrder(context.Background(), invalid, 1)
+
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	})
+
+	mockStore.AssertExpectations(t)
 }
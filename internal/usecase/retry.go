@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy описывает повторы сохранения заказа при временных ошибках хранилища
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Jitter - доля задержки (от 0 до 1), на которую она случайно уменьшается
+	Jitter float64
+}
+
+// delay возвращает экспоненциальную задержку перед повтором с номером attempt (с нуля)
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.MaxDelay
+	if attempt < 32 {
+		if exp := p.BaseDelay << uint(attempt); exp > 0 && exp < p.MaxDelay {
+			d = exp
+		}
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
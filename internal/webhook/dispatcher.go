@@ -0,0 +1,280 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+	"wb_l0/configs"
+	"wb_l0/internal/domain"
+	"wb_l0/pkg/prometheus"
+)
+
+const (
+	maxErrorLength   = 255
+	maxResponseBytes = 64 << 10
+)
+
+// Dispatcher создаёт доставки для сохранённых заказов и отправляет их подписчикам.
+// Доставки хранятся в базе, поэтому повторы переживают перезапуск сервиса.
+type Dispatcher struct {
+	repo   Repository
+	client *http.Client
+	cfg    configs.WebhookConfig
+	queue  chan *domain.Order
+	wake   chan struct{}
+	now    func() time.Time
+	log    *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func NewDispatcher(repo Repository, cfg configs.WebhookConfig, log *slog.Logger) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		repo: repo,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// Перенаправление POST превращается в GET, поэтому 3xx считается неуспешной попыткой
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		cfg:    cfg,
+		queue:  make(chan *domain.Order, cfg.QueueSize),
+		wake:   make(chan struct{}, 1),
+		now:    time.Now,
+		log:    log,
+		ctx:    ctx,
+		cancel: cancel,
+		stop:   make(chan struct{}),
+	}
+}
+
+// OrderCreated ставит заказ в очередь на создание доставок. При переполненной очереди событие теряется.
+func (d *Dispatcher) OrderCreated(order *domain.Order) {
+	select {
+	case d.queue <- order:
+	default:
+		prometheus.WebhookEventsDroppedTotal.Inc()
+		d.log.Warn("Webhook queue is full, event dropped", "order_uid", order.OrderUID)
+	}
+}
+
+func (d *Dispatcher) Start() {
+	d.wg.Add(2)
+	go d.enqueueLoop()
+	go d.deliverLoop()
+}
+
+// Stop дожидается текущих отправок, но не дольше ctx
+func (d *Dispatcher) Stop(ctx context.Context) {
+	close(d.stop)
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+	}
+	d.cancel()
+}
+
+func (d *Dispatcher) enqueueLoop() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case order := <-d.queue:
+			if err := d.enqueue(order); err != nil {
+				d.log.Error("Failed to create webhook deliveries", "order_uid", order.OrderUID, "error", err)
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) enqueue(order *domain.Order) error {
+	subscriptions, err := d.repo.ActiveSubscriptions(d.ctx, EventOrderCreated)
+	if err != nil {
+		return fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+
+	var deliveries []Delivery
+	var payload []byte
+	for _, sub := range subscriptions {
+		if !sub.Match(EventOrderCreated, order) {
+			continue
+		}
+		if payload == nil {
+			payload, err = json.Marshal(Payload{Event: EventOrderCreated, OccurredAt: d.now().UTC(), Order: order})
+			if err != nil {
+				return fmt.Errorf("failed to marshal payload: %w", err)
+			}
+		}
+		deliveries = append(deliveries, Delivery{
+			SubscriptionID: sub.ID,
+			Event:          EventOrderCreated,
+			OrderUID:       order.OrderUID,
+			Payload:        payload,
+			Status:         StatusPending,
+			NextAttemptAt:  d.now(),
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := d.repo.CreateDeliveries(d.ctx, deliveries); err != nil {
+		return err
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (d *Dispatcher) deliverLoop() {
+	defer d.wg.Done()
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+		d.deliverDue()
+	}
+}
+
+// deliverDue отправляет доставки, пока в базе есть те, время попытки которых наступило
+func (d *Dispatcher) deliverDue() {
+	for {
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+
+		// Время захвата с запасом перекрывает отправку с учётом таймаута
+		tasks, err := d.repo.ClaimDueDeliveries(d.ctx, d.cfg.Workers*2, 3*d.cfg.Timeout)
+		if err != nil {
+			d.log.Error("Failed to claim webhook deliveries", "error", err)
+			return
+		}
+		if len(tasks) == 0 {
+			return
+		}
+
+		sem := make(chan struct{}, d.cfg.Workers)
+		var wg sync.WaitGroup
+		for _, task := range tasks {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				d.deliver(task)
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func (d *Dispatcher) deliver(task Task) {
+	delivery := task.Delivery
+	delivery.Attempts++
+
+	statusCode, err := d.send(task)
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+
+	result := "delivered"
+	switch {
+	case err == nil:
+		delivery.Status = StatusDelivered
+	case delivery.Attempts >= d.cfg.MaxAttempts:
+		result = "failed"
+		delivery.Status = StatusFailed
+		delivery.LastError = truncate(err.Error(), maxErrorLength)
+	default:
+		result = "retry"
+		delivery.Status = StatusPending
+		delivery.LastError = truncate(err.Error(), maxErrorLength)
+		delivery.NextAttemptAt = d.now().Add(d.backoff(delivery.Attempts))
+	}
+	prometheus.WebhookDeliveriesTotal.WithLabelValues(result).Inc()
+
+	if result != "delivered" {
+		d.log.Warn("Webhook delivery attempt failed",
+			"delivery_id", delivery.ID,
+			"subscription_id", delivery.SubscriptionID,
+			"order_uid", delivery.OrderUID,
+			"attempt", delivery.Attempts,
+			"status", delivery.Status,
+			"error", err,
+		)
+	}
+
+	if err := d.repo.UpdateDelivery(d.ctx, &delivery); err != nil {
+		d.log.Error("Failed to update webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+func (d *Dispatcher) send(task Task) (int, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, task.URL, bytes.NewReader(task.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wbOrderSaver-webhook/1.0")
+	req.Header.Set(EventHeader, task.Event)
+	req.Header.Set(DeliveryHeader, fmt.Sprintf("%d", task.ID))
+	req.Header.Set(SignatureHeader, Sign(task.Secret, d.now(), task.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff - задержка перед следующей попыткой: BaseDelay * 2^(attempts-1), не больше MaxDelay
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.BaseDelay
+	for i := 1; i < attempts && delay < d.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, d.cfg.MaxDelay)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
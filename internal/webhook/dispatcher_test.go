@@ -0,0 +1,228 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"wb_l0/configs"
+	"wb_l0/internal/domain"
+	"wb_l0/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRepo - Repository в памяти: захват доставок ведёт себя как в Postgres
+type memoryRepo struct {
+	mu            sync.Mutex
+	subscriptions []Subscription
+	deliveries    []Delivery
+}
+
+func (r *memoryRepo) CreateSubscription(_ context.Context, sub *Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub.ID = int64(len(r.subscriptions) + 1)
+	r.subscriptions = append(r.subscriptions, *sub)
+	return nil
+}
+
+func (r *memoryRepo) ListSubscriptions(context.Context) ([]Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Subscription(nil), r.subscriptions...), nil
+}
+
+func (r *memoryRepo) DeleteSubscription(context.Context, int64) error {
+	return nil
+}
+
+func (r *memoryRepo) ActiveSubscriptions(ctx context.Context, event string) ([]Subscription, error) {
+	return r.ListSubscriptions(ctx)
+}
+
+func (r *memoryRepo) CreateDeliveries(_ context.Context, deliveries []Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range deliveries {
+		delivery.ID = int64(len(r.deliveries) + 1)
+		r.deliveries = append(r.deliveries, delivery)
+	}
+	return nil
+}
+
+func (r *memoryRepo) ClaimDueDeliveries(_ context.Context, limit int, lease time.Duration) ([]Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var tasks []Task
+	for i := range r.deliveries {
+		delivery := &r.deliveries[i]
+		if len(tasks) == limit || delivery.Status != StatusPending || delivery.NextAttemptAt.After(time.Now()) {
+			continue
+		}
+		delivery.NextAttemptAt = time.Now().Add(lease)
+		sub := r.subscriptions[delivery.SubscriptionID-1]
+		tasks = append(tasks, Task{Delivery: *delivery, URL: sub.URL, Secret: sub.Secret})
+	}
+	return tasks, nil
+}
+
+func (r *memoryRepo) UpdateDelivery(_ context.Context, delivery *Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries[delivery.ID-1] = *delivery
+	return nil
+}
+
+func (r *memoryRepo) ListDeliveries(context.Context, int64, int) ([]Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Delivery(nil), r.deliveries...), nil
+}
+
+func testConfig() configs.WebhookConfig {
+	return configs.WebhookConfig{
+		Enabled:      true,
+		Workers:      2,
+		MaxAttempts:  3,
+		BaseDelay:    10 * time.Millisecond,
+		MaxDelay:     40 * time.Millisecond,
+		Timeout:      time.Second,
+		PollInterval: 10 * time.Millisecond,
+		QueueSize:    10,
+	}
+}
+
+func startDispatcher(t *testing.T, repo *memoryRepo, cfg configs.WebhookConfig) *Dispatcher {
+	t.Helper()
+	dispatcher := NewDispatcher(repo, cfg, logger.NewTestLogger())
+	dispatcher.Start()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		dispatcher.Stop(ctx)
+	})
+	return dispatcher
+}
+
+func waitDelivery(t *testing.T, repo *memoryRepo, status DeliveryStatus) Delivery {
+	t.Helper()
+	var delivery Delivery
+	require.Eventually(t, func() bool {
+		deliveries, _ := repo.ListDeliveries(context.Background(), 1, 10)
+		if len(deliveries) != 1 {
+			return false
+		}
+		delivery = deliveries[0]
+		return delivery.Status == status
+	}, 2*time.Second, 5*time.Millisecond)
+	return delivery
+}
+
+func TestDispatcher_DeliversSignedPayload(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan received, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header, body: body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	repo := &memoryRepo{}
+	require.NoError(t, repo.CreateSubscription(context.Background(),
+		&Subscription{URL: receiver.URL, Secret: "secret", Events: Events, Active: true}))
+	dispatcher := startDispatcher(t, repo, testConfig())
+
+	dispatcher.OrderCreated(&domain.Order{OrderUID: "b563feb7b2b84b6test", CustomerID: "test"})
+
+	var req received
+	select {
+	case req = <-requests:
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+	assert.Equal(t, EventOrderCreated, req.header.Get(EventHeader))
+	assert.Equal(t, "1", req.header.Get(DeliveryHeader))
+	assert.NoError(t, Verify("secret", req.header.Get(SignatureHeader), req.body, time.Minute, time.Now()))
+	assert.Contains(t, string(req.body), `"order_uid":"b563feb7b2b84b6test"`)
+
+	delivery := waitDelivery(t, repo, StatusDelivered)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, delivery.LastStatusCode)
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	repo := &memoryRepo{}
+	require.NoError(t, repo.CreateSubscription(context.Background(),
+		&Subscription{URL: receiver.URL, Secret: "secret", Events: Events, Active: true}))
+	dispatcher := startDispatcher(t, repo, testConfig())
+
+	dispatcher.OrderCreated(&domain.Order{OrderUID: "b563feb7b2b84b6test"})
+
+	delivery := waitDelivery(t, repo, StatusDelivered)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.LastStatusCode)
+	assert.Empty(t, delivery.LastError)
+}
+
+func TestDispatcher_FailsAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer receiver.Close()
+
+	repo := &memoryRepo{}
+	require.NoError(t, repo.CreateSubscription(context.Background(),
+		&Subscription{URL: receiver.URL, Secret: "secret", Events: Events, Active: true}))
+	dispatcher := startDispatcher(t, repo, testConfig())
+
+	dispatcher.OrderCreated(&domain.Order{OrderUID: "b563feb7b2b84b6test"})
+
+	delivery := waitDelivery(t, repo, StatusFailed)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, http.StatusBadGateway, delivery.LastStatusCode)
+	assert.Equal(t, "unexpected status 502", delivery.LastError)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestDispatcher_SkipsUnmatchedSubscriptions(t *testing.T) {
+	repo := &memoryRepo{}
+	require.NoError(t, repo.CreateSubscription(context.Background(),
+		&Subscription{URL: "http://127.0.0.1:1", Events: Events, CustomerID: "other", Active: true}))
+	dispatcher := NewDispatcher(repo, testConfig(), logger.NewTestLogger())
+
+	require.NoError(t, dispatcher.enqueue(&domain.Order{OrderUID: "b563feb7b2b84b6test", CustomerID: "test"}))
+
+	deliveries, _ := repo.ListDeliveries(context.Background(), 1, 10)
+	assert.Empty(t, deliveries)
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	dispatcher := NewDispatcher(&memoryRepo{}, testConfig(), logger.NewTestLogger())
+
+	assert.Equal(t, 10*time.Millisecond, dispatcher.backoff(1))
+	assert.Equal(t, 20*time.Millisecond, dispatcher.backoff(2))
+	assert.Equal(t, 40*time.Millisecond, dispatcher.backoff(3))
+	assert.Equal(t, 40*time.Millisecond, dispatcher.backoff(10))
+}
@@ -0,0 +1,143 @@
+// Package webhook доставляет события о заказах партнёрам: подписки и журнал доставок хранятся в Postgres,
+// запросы подписываются HMAC-SHA256 и повторяются с экспоненциальной задержкой.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"wb_l0/internal/domain"
+)
+
+const (
+	EventOrderCreated = "order.created"
+
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Events - события, на которые можно подписаться
+var Events = []string{EventOrderCreated}
+
+type DeliveryStatus string
+
+const (
+	StatusPending   DeliveryStatus = "pending"
+	StatusDelivered DeliveryStatus = "delivered"
+	StatusFailed    DeliveryStatus = "failed"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Subscription - подписка партнёра. CustomerID ограничивает события заказами одного покупателя.
+type Subscription struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"-"`
+	Events     []string  `json:"events"`
+	CustomerID string    `json:"customer_id,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (s Subscription) Match(event string, order *domain.Order) bool {
+	if !s.Active || !slices.Contains(s.Events, event) {
+		return false
+	}
+	return s.CustomerID == "" || s.CustomerID == order.CustomerID
+}
+
+// Delivery - доставка одного события одной подписке и результат последней попытки
+type Delivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	Event          string          `json:"event"`
+	OrderUID       string          `json:"order_uid"`
+	Payload        json.RawMessage `json:"-"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// Task - доставка, захваченная для отправки, вместе с адресом и секретом подписки
+type Task struct {
+	Delivery
+	URL    string
+	Secret string
+}
+
+// Payload - тело запроса к партнёру
+type Payload struct {
+	Event      string        `json:"event"`
+	OccurredAt time.Time     `json:"occurred_at"`
+	Order      *domain.Order `json:"order"`
+}
+
+type Repository interface {
+	CreateSubscription(ctx context.Context, sub *Subscription) error
+	ListSubscriptions(ctx context.Context) ([]Subscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	// ActiveSubscriptions возвращает активные подписки на событие
+	ActiveSubscriptions(ctx context.Context, event string) ([]Subscription, error)
+	CreateDeliveries(ctx context.Context, deliveries []Delivery) error
+	// ClaimDueDeliveries захватывает до limit доставок, время попытки которых наступило, и откладывает
+	// их на lease, чтобы другой экземпляр сервиса не отправил их одновременно
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]Task, error)
+	UpdateDelivery(ctx context.Context, delivery *Delivery) error
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]Delivery, error)
+}
+
+// Sign возвращает значение заголовка X-Webhook-Signature: "t=<unix>,v1=<hex hmac-sha256(secret, t.body)>"
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac(secret, ts, body))
+}
+
+// Verify проверяет подпись и то, что она создана не раньше tolerance назад. Функция предназначена
+// для получателей вебхуков.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var ts, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			signature = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp", ErrInvalidSignature)
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, mac(secret, ts, body)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func mac(secret, ts string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
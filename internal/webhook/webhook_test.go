@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"wb_l0/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"event":"order.created"}`)
+	now := time.Unix(1700000000, 0)
+	header := Sign("secret", now, body)
+
+	assert.Regexp(t, `^t=1700000000,v1=[0-9a-f]{64}$`, header)
+	assert.NoError(t, Verify("secret", header, body, time.Minute, now.Add(30*time.Second)))
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+	}{
+		{"wrong secret", "other", header, body, now},
+		{"modified body", "secret", header, []byte(`{"event":"order.deleted"}`), now},
+		{"expired", "secret", header, body, now.Add(2 * time.Minute)},
+		{"missing timestamp", "secret", "v1=abcd", body, now},
+		{"malformed signature", "secret", "t=1700000000,v1=zz", body, now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, time.Minute, tt.now)
+
+			assert.True(t, errors.Is(err, ErrInvalidSignature), "got %v", err)
+		})
+	}
+}
+
+func TestSubscription_Match(t *testing.T) {
+	order := &domain.Order{OrderUID: "b563feb7b2b84b6test", CustomerID: "test"}
+
+	assert.True(t, Subscription{Active: true, Events: Events}.Match(EventOrderCreated, order))
+	assert.True(t, Subscription{Active: true, Events: Events, CustomerID: "test"}.Match(EventOrderCreated, order))
+	assert.False(t, Subscription{Active: true, Events: Events, CustomerID: "other"}.Match(EventOrderCreated, order))
+	assert.False(t, Subscription{Active: false, Events: Events}.Match(EventOrderCreated, order))
+	assert.False(t, Subscription{Active: true, Events: []string{"order.deleted"}}.Match(EventOrderCreated, order))
+}
@@ -0,0 +1,319 @@
+// Package client - Go-клиент HTTP API сервиса заказов (/api/v1).
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	apiPrefix = "/api/v1"
+
+	defaultTimeout   = 10 * time.Second
+	defaultRetries   = 3
+	defaultBaseDelay = 200 * time.Millisecond
+	defaultMaxDelay  = 5 * time.Second
+)
+
+// ErrNotModified возвращается GetOrderIfNoneMatch, если заказ не изменился
+var ErrNotModified = errors.New("order not modified")
+
+// ErrNotFound - заказ не найден, проверяется через errors.Is(err, ErrNotFound)
+var ErrNotFound = errors.New("order not found")
+
+// APIError - ответ сервиса с кодом 4xx/5xx
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"error"`
+	Message    string `json:"message"`
+	// RetryAfter - значение заголовка Retry-After, если сервис его прислал
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("order api: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Is позволяет проверять 404 через errors.Is(err, ErrNotFound)
+func (e *APIError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+type BatchGetResult struct {
+	Orders  []*Order `json:"orders"`
+	Missing []string `json:"missing"`
+}
+
+type HealthStatus struct {
+	Status    string `json:"status"`
+	Timestamp string `json:"timestamp"`
+	Service   string `json:"service"`
+}
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+	apiKey     string
+	retries    int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient задаёт собственный http.Client (таймауты, транспорт)
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken добавляет заголовок "Authorization: Bearer <token>" ко всем запросам.
+// token - JWT или API-ключ.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithAPIKey добавляет заголовок "X-API-Key: <key>" ко всем запросам
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithRetries задаёт число повторов и границы экспоненциальной задержки между ними
+func WithRetries(retries int, baseDelay, maxDelay time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.baseDelay = baseDelay
+		c.maxDelay = maxDelay
+	}
+}
+
+// New создаёт клиента для сервиса с адресом baseURL, например "http://localhost:8081"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		retries:    defaultRetries,
+		baseDelay:  defaultBaseDelay,
+		maxDelay:   defaultMaxDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// GetOrder возвращает заказ по order_uid
+func (c *Client) GetOrder(ctx context.Context, orderUID string) (*Order, error) {
+	var order Order
+	if err := c.do(ctx, http.MethodGet, "/order/"+url.PathEscape(orderUID), nil, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetOrderIfNoneMatch выполняет условный запрос заказа. Возвращает заказ и его ETag,
+// либо ErrNotModified, если ETag совпал с etag.
+func (c *Client) GetOrderIfNoneMatch(ctx context.Context, orderUID, etag string) (*Order, string, error) {
+	var order Order
+	call := &call{method: http.MethodGet, path: "/order/" + url.PathEscape(orderUID), out: &order}
+	if etag != "" {
+		call.header = http.Header{"If-None-Match": []string{etag}}
+	}
+	if err := c.execute(ctx, call); err != nil {
+		return nil, "", err
+	}
+	if call.status == http.StatusNotModified {
+		return nil, etag, ErrNotModified
+	}
+	return &order, call.respHeader.Get("ETag"), nil
+}
+
+// BatchGetOrders возвращает найденные заказы и список отсутствующих UID
+func (c *Client) BatchGetOrders(ctx context.Context, orderUIDs []string) (*BatchGetResult, error) {
+	var result BatchGetResult
+	body := map[string][]string{"order_uids": orderUIDs}
+	if err := c.do(ctx, http.MethodPost, "/orders/batch-get", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DeleteOrder удаляет заказ, требует роль admin
+func (c *Client) DeleteOrder(ctx context.Context, orderUID string) error {
+	return c.do(ctx, http.MethodDelete, "/order/"+url.PathEscape(orderUID), nil, nil)
+}
+
+// Health проверяет доступность сервиса
+func (c *Client) Health(ctx context.Context) (*HealthStatus, error) {
+	var status HealthStatus
+	if err := c.do(ctx, http.MethodGet, "/health", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// call описывает один вызов API вместе с результатом последней попытки
+type call struct {
+	method string
+	path   string
+	body   any
+	out    any
+	header http.Header
+
+	status     int
+	respHeader http.Header
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	return c.execute(ctx, &call{method: method, path: path, body: body, out: out})
+}
+
+func (c *Client) execute(ctx context.Context, call *call) error {
+	var payload []byte
+	if call.body != nil {
+		var err error
+		if payload, err = json.Marshal(call.body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			delay := c.delay(attempt - 1)
+			var apiErr *APIError
+			if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > 0 {
+				delay = apiErr.RetryAfter
+			}
+			if err := sleepContext(ctx, delay); err != nil {
+				return err
+			}
+		}
+
+		retry, err := c.attempt(ctx, call, payload)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return lastErr
+}
+
+// attempt выполняет один запрос и сообщает, имеет ли смысл его повторить
+func (c *Client) attempt(ctx context.Context, call *call, payload []byte) (bool, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, call.method, c.baseURL+apiPrefix+call.path, reader)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range call.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return true, fmt.Errorf("request %s %s failed: %w", call.method, call.path, err)
+	}
+	defer resp.Body.Close()
+	call.status = resp.StatusCode
+	call.respHeader = resp.Header
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		retryable := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		return retryable, decodeError(resp)
+	}
+
+	if call.out == nil || resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(call.out); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return false, nil
+}
+
+func (c *Client) delay(attempt int) time.Duration {
+	d := c.maxDelay
+	if attempt < 32 {
+		if exp := c.baseDelay << uint(attempt); exp > 0 && exp < c.maxDelay {
+			d = exp
+		}
+	}
+	// Равномерный разброс в пределах половины задержки
+	return d/2 + time.Duration(rand.Int64N(int64(d/2)+1))
+}
+
+func decodeError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err := json.Unmarshal(data, apiErr); err != nil || apiErr.Code == "" {
+		apiErr.Code = strings.ToLower(strings.ReplaceAll(http.StatusText(resp.StatusCode), " ", "_"))
+		if apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+	}
+	return apiErr
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
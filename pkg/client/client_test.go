@@ -0,0 +1,172 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"wb_l0/internal/domain"
+	"wb_l0/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(url string, opts ...client.Option) *client.Client {
+	opts = append([]client.Option{client.WithRetries(2, time.Millisecond, 5*time.Millisecond)}, opts...)
+	return client.New(url, opts...)
+}
+
+func TestClient_GetOrder(t *testing.T) {
+	t.Run("successful get order", func(t *testing.T) {
+		expected := domain.CreateTestOrder(1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/v1/order/"+expected.OrderUID, r.URL.Path)
+			_ = json.NewEncoder(w).Encode(expected)
+		}))
+		defer server.Close()
+
+		order, err := newTestClient(server.URL).GetOrder(context.Background(), expected.OrderUID)
+
+		require.NoError(t, err)
+		assert.Equal(t, expected.OrderUID, order.OrderUID)
+		assert.Equal(t, expected.Payment.Amount, order.Payment.Amount)
+		require.Len(t, order.Items, 1)
+		assert.Equal(t, expected.Items[0].NMID, order.Items[0].NMID)
+		assert.True(t, expected.DateCreated.Equal(order.DateCreated))
+	})
+
+	t.Run("not found is decoded and not retried", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not_found","message":"order not found"}`))
+		}))
+		defer server.Close()
+
+		order, err := newTestClient(server.URL).GetOrder(context.Background(), "00000000000000000001")
+
+		assert.Nil(t, order)
+		var apiErr *client.APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "not_found", apiErr.Code)
+		assert.True(t, errors.Is(err, client.ErrNotFound))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("server errors are retried", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(client.Order{OrderUID: "00000000000000000001"})
+		}))
+		defer server.Close()
+
+		order, err := newTestClient(server.URL).GetOrder(context.Background(), "00000000000000000001")
+
+		require.NoError(t, err)
+		assert.Equal(t, "00000000000000000001", order.OrderUID)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("retries are exhausted", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error":"internal_error","message":"failed to retrieve order"}`))
+		}))
+		defer server.Close()
+
+		_, err := newTestClient(server.URL).GetOrder(context.Background(), "00000000000000000001")
+
+		var apiErr *client.APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+}
+
+func TestClient_GetOrderIfNoneMatch(t *testing.T) {
+	const etag = `"abc"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(client.Order{OrderUID: "00000000000000000001"})
+	}))
+	defer server.Close()
+	c := newTestClient(server.URL)
+
+	order, gotETag, err := c.GetOrderIfNoneMatch(context.Background(), "00000000000000000001", "")
+	require.NoError(t, err)
+	assert.Equal(t, "00000000000000000001", order.OrderUID)
+	assert.Equal(t, etag, gotETag)
+
+	order, gotETag, err = c.GetOrderIfNoneMatch(context.Background(), "00000000000000000001", gotETag)
+	assert.True(t, errors.Is(err, client.ErrNotModified))
+	assert.Nil(t, order)
+	assert.Equal(t, etag, gotETag)
+}
+
+func TestClient_BatchGetOrders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1/orders/batch-get", r.URL.Path)
+
+		var req struct {
+			OrderUIDs []string `json:"order_uids"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, []string{"00000000000000000001", "00000000000000000002"}, req.OrderUIDs)
+
+		_, _ = w.Write([]byte(`{"orders":[{"order_uid":"00000000000000000001"}],"missing":["00000000000000000002"]}`))
+	}))
+	defer server.Close()
+
+	result, err := newTestClient(server.URL).BatchGetOrders(context.Background(),
+		[]string{"00000000000000000001", "00000000000000000002"})
+
+	require.NoError(t, err)
+	require.Len(t, result.Orders, 1)
+	assert.Equal(t, "00000000000000000001", result.Orders[0].OrderUID)
+	assert.Equal(t, []string{"00000000000000000002"}, result.Missing)
+}
+
+func TestClient_WithAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "reader-key", r.Header.Get("X-API-Key"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+		_ = json.NewEncoder(w).Encode(client.Order{OrderUID: "00000000000000000001"})
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL, client.WithAPIKey("reader-key")).
+		GetOrder(context.Background(), "00000000000000000001")
+
+	assert.NoError(t, err)
+}
+
+func TestClient_DeleteOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := newTestClient(server.URL, client.WithToken("secret")).
+		DeleteOrder(context.Background(), "00000000000000000001")
+
+	assert.NoError(t, err)
+}
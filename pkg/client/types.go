@@ -0,0 +1,91 @@
+package client
+
+import "time"
+
+// Order - заказ в том виде, в котором его отдаёт API сервиса
+type Order struct {
+	OrderUID          string    `json:"order_uid"`
+	TrackNumber       string    `json:"track_number"`
+	Entry             string    `json:"entry"`
+	Delivery          Delivery  `json:"delivery"`
+	Payment           Payment   `json:"payment"`
+	Items             []Item    `json:"items"`
+	Locale            string    `json:"locale"`
+	InternalSignature string    `json:"internal_signature"`
+	CustomerID        string    `json:"customer_id"`
+	DeliveryService   string    `json:"delivery_service"`
+	ShardKey          string    `json:"shardkey"`
+	SMID              int       `json:"sm_id"`
+	DateCreated       time.Time `json:"date_created"`
+	OOFShard          string    `json:"oof_shard"`
+	Version           int       `json:"version"`
+	// Timeline, Cancellation и Refunds сервис заполняет только при наличии данных
+	Timeline     []ItemStatusChange `json:"timeline,omitempty"`
+	Cancellation *Cancellation      `json:"cancellation,omitempty"`
+	Refunds      []Refund           `json:"refunds,omitempty"`
+}
+
+type Delivery struct {
+	Name    string `json:"name"`
+	Phone   string `json:"phone"`
+	Zip     string `json:"zip"`
+	City    string `json:"city"`
+	Address string `json:"address"`
+	Region  string `json:"region"`
+	Email   string `json:"email"`
+}
+
+type Payment struct {
+	Transaction  string `json:"transaction"`
+	RequestID    string `json:"request_id"`
+	Currency     string `json:"currency"`
+	Provider     string `json:"provider"`
+	Amount       int    `json:"amount"`
+	PaymentDT    int64  `json:"payment_dt"`
+	Bank         string `json:"bank"`
+	DeliveryCost int    `json:"delivery_cost"`
+	GoodsTotal   int    `json:"goods_total"`
+	CustomFee    int    `json:"custom_fee"`
+}
+
+type Item struct {
+	ChrtID      int    `json:"chrt_id"`
+	TrackNumber string `json:"track_number"`
+	Price       int    `json:"price"`
+	RID         string `json:"rid"`
+	Name        string `json:"name"`
+	Sale        int    `json:"sale"`
+	Size        string `json:"size"`
+	TotalPrice  int    `json:"total_price"`
+	NMID        int    `json:"nm_id"`
+	Brand       string `json:"brand"`
+	Status      int    `json:"status"`
+	Quantity    int    `json:"quantity,omitempty"`
+}
+
+// ItemStatusChange - переход позиции заказа в новый статус
+type ItemStatusChange struct {
+	ChrtID    int       `json:"chrt_id"`
+	From      int       `json:"from"`
+	To        int       `json:"to"`
+	Actor     string    `json:"actor"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// Cancellation - отмена заказа
+type Cancellation struct {
+	Reason      string    `json:"reason"`
+	Actor       string    `json:"actor"`
+	CancelledAt time.Time `json:"cancelled_at"`
+}
+
+// Refund - возврат по оплате заказа, без ChrtID относится к заказу целиком
+type Refund struct {
+	ID        int64     `json:"id"`
+	ChrtID    int       `json:"chrt_id,omitempty"`
+	Amount    int       `json:"amount"`
+	Reason    string    `json:"reason"`
+	RequestID string    `json:"request_id,omitempty"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
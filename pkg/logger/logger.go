@@ -15,6 +15,9 @@ const (
 	envProd  = "prod"
 )
 
+// Level - текущий уровень логирования, может меняться во время работы через административный API
+var Level = new(slog.LevelVar)
+
 func NewLogger(cfg *configs.Config) *slog.Logger {
 
 	var logger *slog.Logger
@@ -29,21 +32,24 @@ func NewLogger(cfg *configs.Config) *slog.Logger {
 
 	switch cfg.Env {
 	case envLocal:
+		Level.Set(slog.LevelDebug)
 		logger = slog.New(
 			slog.NewJSONHandler(io.MultiWriter(os.Stdout, logRotation), &slog.HandlerOptions{
-				Level:     slog.LevelDebug,
+				Level:     Level,
 				AddSource: true,
 			}))
 	case envDev:
+		Level.Set(slog.LevelDebug)
 		logger = slog.New(
 			slog.NewJSONHandler(io.MultiWriter(os.Stdout, logRotation), &slog.HandlerOptions{
-				Level:     slog.LevelDebug,
+				Level:     Level,
 				AddSource: true,
 			}))
 	case envProd:
+		Level.Set(slog.LevelInfo)
 		logger = slog.New(
 			slog.NewJSONHandler(logRotation, &slog.HandlerOptions{ // Только в файл для prod
-				Level:     slog.LevelInfo,
+				Level:     Level,
 				AddSource: true,
 			}))
 	}
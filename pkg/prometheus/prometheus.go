@@ -24,6 +24,27 @@ var (
 		},
 		[]string{"method", "path"},
 	)
+	HttpAuthFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_auth_failures_total",
+			Help: "Total number of rejected HTTP requests by auth middleware",
+		},
+		[]string{"reason"},
+	)
+	HttpRateLimitedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_rate_limited_total",
+			Help: "Total number of HTTP requests rejected by rate limiter",
+		},
+		[]string{"group"},
+	)
+	HttpRateLimitErrorsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_rate_limit_errors_total",
+			Help: "Total number of rate limiter backend errors, requests are let through",
+		},
+		[]string{"group"},
+	)
 	HttpRequestsInFlight = promauto.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "http_requests_in_flight",
@@ -31,6 +52,56 @@ var (
 		},
 	)
 
+	GrpcRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_requests_total",
+			Help: "Total number of gRPC requests",
+		},
+		[]string{"method", "code"},
+	)
+	GrpcRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "grpc_request_duration_seconds",
+			Help:    "gRPC request duration in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method"},
+	)
+
+	StreamSubscribers = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "stream_subscribers",
+			Help: "Current number of order stream subscribers",
+		},
+		[]string{"transport"},
+	)
+	StreamEventsSentTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "stream_events_sent_total",
+			Help: "Total number of order summaries queued to stream subscribers",
+		},
+	)
+	StreamSubscribersDroppedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "stream_subscribers_dropped_total",
+			Help: "Total number of stream subscribers disconnected because their buffer was full",
+		},
+	)
+
+	WebhookDeliveriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_deliveries_total",
+			Help: "Total number of webhook delivery attempts by result (delivered, retry, failed)",
+		},
+		[]string{"result"},
+	)
+	WebhookEventsDroppedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "webhook_events_dropped_total",
+			Help: "Total number of order events dropped because the webhook queue was full",
+		},
+	)
+
 	OrdersProcessed = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "orders_processed_total",
@@ -39,6 +110,14 @@ var (
 		[]string{"status"},
 	)
 
+	OrderDuplicates = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "order_duplicates_total",
+			Help: "Total number of orders with an already stored order_uid by result (replay, conflict)",
+		},
+		[]string{"result"},
+	)
+
 	OrderProcessingDuration = promauto.NewHistogram(
 		prometheus.HistogramOpts{
 			Name:    "order_processing_duration_seconds",
@@ -97,6 +176,38 @@ var (
 		[]string{"operation", "table"},
 	)
 
+	DictionaryCacheRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "database_dictionary_cache_requests_total",
+			Help: "Total number of dictionary ID lookups in the in-process cache",
+		},
+		[]string{"dictionary", "result"},
+	)
+
+	DictionaryCacheEntries = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "database_dictionary_cache_entries",
+			Help: "Number of dictionary IDs in the in-process cache",
+		},
+		[]string{"dictionary"},
+	)
+
+	DatabaseReads = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "database_reads_total",
+			Help: "Total number of order reads by target database",
+		},
+		[]string{"target"},
+	)
+
+	DatabaseReplicaUp = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "database_replica_up",
+			Help: "Whether the read replica passed its last health check",
+		},
+		[]string{"replica"},
+	)
+
 	RedisOperationsTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "redis_operations_total",
@@ -114,6 +225,14 @@ var (
 		[]string{"operation"},
 	)
 
+	KafkaDeadLetteredTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_dead_lettered_total",
+			Help: "Total number of Kafka messages sent to the dead letter topic",
+		},
+		[]string{"topic", "reason"},
+	)
+
 	KafkaWorkersBusy = promauto.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "kafka_workers_busy",
@@ -1,5 +1,5 @@
-// const API_BASE_URL = 'http://localhost:8081/api/v1';
-const API_BASE_URL = 'http://localhost:8081';
+const API_BASE_URL = '/api/v1';
+const API_KEY_STORAGE = 'wbApiKey';
 
 function searchOrder() {
     const orderId = document.getElementById('orderInput').value.trim();
@@ -14,6 +14,11 @@ function searchOrder() {
         return;
     }
 
+    const apiKey = document.getElementById('apiKeyInput').value.trim();
+    if (apiKey) {
+        sessionStorage.setItem(API_KEY_STORAGE, apiKey);
+    }
+
     window.location.href = `/result.html?order_uid=${encodeURIComponent(orderId)}`;
 }
 
@@ -35,7 +40,13 @@ function loadOrderData(orderUid) {
 
     const startTime = performance.now();
 
-    fetch(`${API_BASE_URL}/order/${orderUid}`)
+    const headers = {};
+    const apiKey = sessionStorage.getItem(API_KEY_STORAGE);
+    if (apiKey) {
+        headers['X-API-Key'] = apiKey;
+    }
+
+    fetch(`${API_BASE_URL}/order/${orderUid}`, { headers })
         .then(response => {
             if (!response.ok) {
                 return response.json().then(err => {
@@ -157,6 +168,10 @@ function displayItems(items) {
                     <span class="info-label">price:</span>
                     <span class="info-value">${item.price}</span>
                 </div>
+                <div>
+                    <span class="info-label">quantity:</span>
+                    <span class="info-value">${item.quantity || 1}</span>
+                </div>
                 <div>
                     <span class="info-label">rid:</span>
                     <span class="info-value">${item.rid}</span>